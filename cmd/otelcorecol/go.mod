@@ -22,6 +22,7 @@ require (
 	go.opentelemetry.io/collector/exporter/otlpexporter v0.112.0
 	go.opentelemetry.io/collector/exporter/otlphttpexporter v0.112.0
 	go.opentelemetry.io/collector/extension v0.112.0
+	go.opentelemetry.io/collector/extension/filestorage v0.112.0
 	go.opentelemetry.io/collector/extension/memorylimiterextension v0.112.0
 	go.opentelemetry.io/collector/extension/zpagesextension v0.112.0
 	go.opentelemetry.io/collector/otelcol v0.112.0
@@ -239,6 +240,8 @@ replace go.opentelemetry.io/collector/extension/experimental/storage => ../../ex
 
 replace go.opentelemetry.io/collector/extension/extensioncapabilities => ../../extension/extensioncapabilities
 
+replace go.opentelemetry.io/collector/extension/filestorage => ../../extension/filestorage
+
 replace go.opentelemetry.io/collector/extension/memorylimiterextension => ../../extension/memorylimiterextension
 
 replace go.opentelemetry.io/collector/extension/zpagesextension => ../../extension/zpagesextension
@@ -0,0 +1,143 @@
+// exporter/clickhouseexporter/attributes.go
+package clickhouseexporter
+
+import (
+    "fmt"
+    "strings"
+
+    "go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+const (
+    attributesModeString = "string"
+    attributesModeTyped  = "typed"
+)
+
+// promotedColumnTypes maps the type of a promoted attribute to its column
+// type. An empty type is a string.
+var promotedColumnTypes = map[string]string{
+    "":        "LowCardinality(String)",
+    "String":  "LowCardinality(String)",
+    "Int64":   "Nullable(Int64)",
+    "Float64": "Nullable(Float64)",
+    "Bool":    "Nullable(Bool)",
+}
+
+// promotedColumnName returns the column of a promoted attribute key.
+func promotedColumnName(key string) string {
+    return "attr_" + strings.Map(func(r rune) rune {
+        if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+            return r
+        }
+        return '_'
+    }, key)
+}
+
+// typedAttributes holds the attributes of a data point split by type. In
+// string mode every attribute is in strings.
+type typedAttributes struct {
+    strings map[string]string
+    ints    map[string]int64
+    floats  map[string]float64
+    bools   map[string]bool
+}
+
+// attributeConverter turns data point attributes into column values.
+type attributeConverter struct {
+    typed    bool
+    promoted []PromotedAttribute
+}
+
+func newAttributeConverter(cfg AttributesConfig) attributeConverter {
+    return attributeConverter{
+        typed:    cfg.Mode == attributesModeTyped,
+        promoted: cfg.Promoted,
+    }
+}
+
+func (c attributeConverter) convert(attrs pcommon.Map) typedAttributes {
+    if !c.typed {
+        return typedAttributes{strings: attributesToMap(attrs)}
+    }
+    result := typedAttributes{
+        strings: make(map[string]string),
+        ints:    make(map[string]int64),
+        floats:  make(map[string]float64),
+        bools:   make(map[string]bool),
+    }
+    attrs.Range(func(k string, v pcommon.Value) bool {
+        switch v.Type() {
+        case pcommon.ValueTypeInt:
+            result.ints[k] = v.Int()
+        case pcommon.ValueTypeDouble:
+            result.floats[k] = v.Double()
+        case pcommon.ValueTypeBool:
+            result.bools[k] = v.Bool()
+        default:
+            // Strings as is; slices, maps and bytes as their JSON or
+            // base64 representation.
+            result.strings[k] = v.AsString()
+        }
+        return true
+    })
+    return result
+}
+
+// promotedColumns returns the insert column list of the promoted
+// attributes, to be appended to an INSERT statement.
+func (c attributeConverter) promotedColumns() string {
+    var b strings.Builder
+    for _, attr := range c.promoted {
+        b.WriteString(",\n        ")
+        b.WriteString(promotedColumnName(attr.Key))
+    }
+    return b.String()
+}
+
+// promotedValues returns the values of the promoted attributes of a data
+// point. Missing attributes are empty strings or NULL.
+func (c attributeConverter) promotedValues(attrs pcommon.Map) []any {
+    values := make([]any, 0, len(c.promoted))
+    for _, attr := range c.promoted {
+        v, ok := attrs.Get(attr.Key)
+        switch attr.Type {
+        case "Int64":
+            var value *int64
+            if ok && v.Type() == pcommon.ValueTypeInt {
+                value = ptr(v.Int())
+            }
+            values = append(values, value)
+        case "Float64":
+            var value *float64
+            if ok && v.Type() == pcommon.ValueTypeDouble {
+                value = ptr(v.Double())
+            } else if ok && v.Type() == pcommon.ValueTypeInt {
+                value = ptr(float64(v.Int()))
+            }
+            values = append(values, value)
+        case "Bool":
+            var value *bool
+            if ok && v.Type() == pcommon.ValueTypeBool {
+                value = ptr(v.Bool())
+            }
+            values = append(values, value)
+        default:
+            var value string
+            if ok {
+                value = v.AsString()
+            }
+            values = append(values, value)
+        }
+    }
+    return values
+}
+
+// promotedColumnDefinitions returns the column definitions of the promoted
+// attributes.
+func promotedColumnDefinitions(promoted []PromotedAttribute) []string {
+    columns := make([]string, 0, len(promoted))
+    for _, attr := range promoted {
+        columns = append(columns, fmt.Sprintf("%s %s", promotedColumnName(attr.Key), promotedColumnTypes[attr.Type]))
+    }
+    return columns
+}
@@ -0,0 +1,100 @@
+// exporter/clickhouseexporter/attributes_test.go
+package clickhouseexporter
+
+import (
+    "context"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.opentelemetry.io/collector/component/componenttest"
+    "go.opentelemetry.io/collector/pdata/pcommon"
+    "go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func testAttributes() pcommon.Map {
+    attrs := pcommon.NewMap()
+    attrs.PutStr("http.request.method", "GET")
+    attrs.PutInt("http.response.status_code", 200)
+    attrs.PutDouble("ratio", 0.5)
+    attrs.PutBool("error", false)
+    attrs.PutEmptySlice("tags").FromRaw([]any{"a", "b"})
+    return attrs
+}
+
+func TestAttributeConverterStringMode(t *testing.T) {
+    c := newAttributeConverter(AttributesConfig{Mode: attributesModeString})
+
+    labels := c.convert(testAttributes())
+    assert.Equal(t, map[string]string{
+        "http.request.method":       "GET",
+        "http.response.status_code": "200",
+        "ratio":                     "0.5",
+        "error":                     "false",
+        "tags":                      `["a","b"]`,
+    }, labels.strings)
+    assert.Empty(t, labels.ints)
+    assert.Empty(t, labels.floats)
+    assert.Empty(t, labels.bools)
+}
+
+func TestAttributeConverterTypedMode(t *testing.T) {
+    c := newAttributeConverter(AttributesConfig{Mode: attributesModeTyped})
+
+    labels := c.convert(testAttributes())
+    assert.Equal(t, map[string]string{"http.request.method": "GET", "tags": `["a","b"]`}, labels.strings)
+    assert.Equal(t, map[string]int64{"http.response.status_code": 200}, labels.ints)
+    assert.Equal(t, map[string]float64{"ratio": 0.5}, labels.floats)
+    assert.Equal(t, map[string]bool{"error": false}, labels.bools)
+}
+
+func TestAttributeConverterPromoted(t *testing.T) {
+    c := newAttributeConverter(AttributesConfig{
+        Mode: attributesModeString,
+        Promoted: []PromotedAttribute{
+            {Key: "http.request.method"},
+            {Key: "http.response.status_code", Type: "Int64"},
+            {Key: "ratio", Type: "Float64"},
+            {Key: "error", Type: "Bool"},
+            {Key: "missing", Type: "Int64"},
+        },
+    })
+
+    assert.Equal(t, ",\n        attr_http_request_method,\n        attr_http_response_status_code,\n        attr_ratio,\n        attr_error,\n        attr_missing", c.promotedColumns())
+    assert.Equal(t, []any{"GET", ptr(int64(200)), ptr(0.5), ptr(false), (*int64)(nil)}, c.promotedValues(testAttributes()))
+    assert.Equal(t, []string{
+        "attr_http_request_method LowCardinality(String)",
+        "attr_http_response_status_code Nullable(Int64)",
+        "attr_ratio Nullable(Float64)",
+        "attr_error Nullable(Bool)",
+        "attr_missing Nullable(Int64)",
+    }, promotedColumnDefinitions(c.promoted))
+}
+
+func TestConsumeMetricsTypedAttributes(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Attributes = AttributesConfig{
+        Mode:     attributesModeTyped,
+        Promoted: []PromotedAttribute{{Key: "http.response.status_code", Type: "Int64"}},
+    }
+    conn := newStandInConn()
+    conn.extraColumns = map[string]string{"attr_http_response_status_code": "Nullable(Int64)"}
+    exp, err := newClickHouseExporter(componenttest.NewNopTelemetrySettings(), cfg)
+    require.NoError(t, err)
+    exp.conn = conn
+
+    md := pmetric.NewMetrics()
+    metric := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+    metric.SetName("http.requests")
+    testAttributes().CopyTo(metric.SetEmptySum().DataPoints().AppendEmpty().Attributes())
+    histogram := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().AppendEmpty()
+    histogram.SetName("http.duration")
+    histogram.SetEmptyHistogram().DataPoints().AppendEmpty().Attributes().PutInt("http.response.status_code", 503)
+
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+
+    assert.Equal(t, map[string]int64{"http.response.status_code": 200}, conn.value(t, "otel.metrics", "labels_int", 0))
+    assert.Equal(t, map[string]bool{"error": false}, conn.value(t, "otel.metrics", "labels_bool", 0))
+    assert.Equal(t, ptr(int64(200)), conn.value(t, "otel.metrics", "attr_http_response_status_code", 0))
+    assert.Equal(t, ptr(int64(503)), conn.value(t, "otel.metrics_histogram", "attr_http_response_status_code", 0))
+}
@@ -0,0 +1,201 @@
+// exporter/clickhouseexporter/batch.go
+package clickhouseexporter
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+
+    "github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// insertQuery is an INSERT statement together with the table it writes to,
+// which is used to label the exporter telemetry.
+type insertQuery struct {
+    table string
+    sql   string
+}
+
+// batchSet collects the rows of one payload per INSERT statement, so a
+// payload only talks to the tables it has rows for. The rows are sent by
+// send one table at a time: a prepared batch holds a connection of the pool
+// until it is sent, so preparing all of them upfront would let concurrent
+// payloads each hold some connections while waiting for more.
+type batchSet struct {
+    ctx       context.Context
+    conn      dbConn
+    telemetry *telemetry
+    // sent remembers the tables a failed payload was written to, so that a
+    // retry of the payload skips them.
+    sent *sentTables
+    // token identifies the payload, and is sent as the
+    // insert_deduplication_token of every insert when deduplicate is set.
+    token       string
+    deduplicate bool
+    rows        map[insertQuery][][]any
+    // order keeps the statements in the order they were first used so that
+    // inserts happen deterministically.
+    order []insertQuery
+}
+
+func newBatchSet(ctx context.Context, conn dbConn, telemetry *telemetry, sent *sentTables, token string, deduplicate bool) *batchSet {
+    return &batchSet{
+        ctx:         ctx,
+        conn:        conn,
+        telemetry:   telemetry,
+        sent:        sent,
+        token:       token,
+        deduplicate: deduplicate,
+        rows:        make(map[insertQuery][][]any),
+    }
+}
+
+// append adds one row for query. Every row of a statement has to have the
+// same number of values.
+func (b *batchSet) append(query insertQuery, args ...any) error {
+    if b.sent.contains(b.token, query) {
+        // An earlier attempt of the payload already wrote this table.
+        return nil
+    }
+    rows, ok := b.rows[query]
+    if !ok {
+        b.order = append(b.order, query)
+    } else if len(args) != len(rows[0]) {
+        return fmt.Errorf("expected %d values, got %d", len(rows[0]), len(args))
+    }
+    b.rows[query] = append(rows, args)
+    return nil
+}
+
+// send writes the rows of every statement, each as a single block, and
+// reports the statements that could not be written. When only some of them
+// are written, the tables they went to are remembered so that retrying the
+// payload does not write their rows twice.
+func (b *batchSet) send() error {
+    var errs error
+    var written []insertQuery
+    for _, query := range b.order {
+        if err := b.sendQuery(query); err != nil {
+            errs = errors.Join(errs, err)
+            continue
+        }
+        written = append(written, query)
+    }
+    if errs != nil {
+        b.sent.add(b.token, written)
+    } else {
+        b.sent.forget(b.token)
+    }
+    return errs
+}
+
+// sendQuery prepares the batch of query, appends its rows and sends it, so
+// that the connection of the batch is released before the next one is
+// prepared.
+func (b *batchSet) sendQuery(query insertQuery) error {
+    ctx := b.ctx
+    if b.deduplicate {
+        // The query settings are sent with the INSERT statement, so the
+        // token has to be known before any row is appended. Tokens only
+        // have to be unique per table.
+        ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+            "insert_deduplication_token": b.token,
+        }))
+    }
+    batch, err := b.conn.PrepareBatch(ctx, query.sql)
+    if err != nil {
+        b.telemetry.recordPrepareError(b.ctx, query.table)
+        return fmt.Errorf("failed to prepare batch: %w", err)
+    }
+    for _, row := range b.rows[query] {
+        if err := batch.Append(row...); err != nil {
+            _ = batch.Abort()
+            return fmt.Errorf("failed to append row to %s: %w", query.table, err)
+        }
+    }
+    start := time.Now()
+    err = batch.Send()
+    b.telemetry.recordInsert(b.ctx, query.table, batch.Rows(), time.Since(start), err)
+    if err != nil {
+        return fmt.Errorf("failed to send batch: %w", err)
+    }
+    return nil
+}
+
+// deduplicationToken returns the insert_deduplication_token of an OTLP
+// encoded payload. A retried payload gets the same token, while payloads
+// that differ in any field get different ones.
+func deduplicationToken(payload []byte) string {
+    sum := sha256.Sum256(payload)
+    return hex.EncodeToString(sum[:16])
+}
+
+// maxFailedPayloads bounds the number of failed payloads whose written tables
+// are remembered. Payloads that are never retried are forgotten once it is
+// reached, the oldest first.
+const maxFailedPayloads = 1000
+
+// sentTables remembers, for the payloads that failed to be written to every
+// table, the tables they were written to. The payloads are identified by the
+// hash of their OTLP encoding.
+type sentTables struct {
+    mu     sync.Mutex
+    tables map[string]map[insertQuery]struct{}
+    // tokens are the remembered payloads, the oldest first.
+    tokens []string
+}
+
+func newSentTables() *sentTables {
+    return &sentTables{tables: make(map[string]map[insertQuery]struct{})}
+}
+
+// contains returns whether the payload was already written to the table of
+// query.
+func (s *sentTables) contains(token string, query insertQuery) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    _, ok := s.tables[token][query]
+    return ok
+}
+
+// add remembers that the payload was written to the tables of queries.
+func (s *sentTables) add(token string, queries []insertQuery) {
+    if len(queries) == 0 {
+        return
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    tables, ok := s.tables[token]
+    if !ok {
+        if len(s.tokens) >= maxFailedPayloads {
+            delete(s.tables, s.tokens[0])
+            s.tokens = s.tokens[1:]
+        }
+        tables = make(map[insertQuery]struct{}, len(queries))
+        s.tables[token] = tables
+        s.tokens = append(s.tokens, token)
+    }
+    for _, query := range queries {
+        tables[query] = struct{}{}
+    }
+}
+
+// forget drops what is remembered of a payload once it is fully written.
+func (s *sentTables) forget(token string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if _, ok := s.tables[token]; !ok {
+        return
+    }
+    delete(s.tables, token)
+    for i, t := range s.tokens {
+        if t == token {
+            s.tokens = append(s.tokens[:i], s.tokens[i+1:]...)
+            break
+        }
+    }
+}
@@ -0,0 +1,300 @@
+// exporter/clickhouseexporter/cardinality.go
+package clickhouseexporter
+
+import (
+    "context"
+    "encoding/binary"
+    "hash/fnv"
+    "math"
+    "slices"
+    "strconv"
+    "sync"
+    "time"
+
+    "go.opentelemetry.io/collector/pdata/pcommon"
+    "go.uber.org/zap"
+)
+
+const (
+    cardinalityActionDropLabels = "drop_labels"
+    cardinalityActionHashLabels = "hash_labels"
+    cardinalityActionReject     = "reject"
+)
+
+// labels converts the attributes of a data point of the metric of meta and
+// applies the cardinality limits to them. It reports false when the data
+// point is rejected and must not be written.
+func (e *clickhouseExporter) labels(ctx context.Context, meta metricMeta, attrs pcommon.Map) (typedAttributes, bool) {
+    labels := e.attributes.convert(attrs)
+    if e.cardinality == nil {
+        return labels, true
+    }
+    return e.cardinality.apply(ctx, meta.queries.tenant, meta.name, labels)
+}
+
+// cardinalityLimiter tracks the series of every metric, the distinct label
+// sets of its data points, and keeps their number within the configured
+// limit.
+//
+// When a data point would add a series past the limit, it is rejected, or
+// the label key of the metric with the most distinct values becomes an
+// offending key. Offending keys are dropped from, or have their values
+// hashed into a few buckets in, every later data point of the metric.
+// Tracking then starts over, so that the reduced series are counted anew.
+//
+// Every tenant has limits of its own, and everything tracked is forgotten
+// once per interval, so that series that are no longer sent, such as those
+// of restarted pods, do not count forever.
+type cardinalityLimiter struct {
+    cfg       CardinalityConfig
+    logger    *zap.Logger
+    telemetry *telemetry
+    now       func() time.Time
+
+    mu      sync.Mutex
+    metrics map[cardinalityKey]*metricCardinality
+    // windowStart is when the metrics were last forgotten.
+    windowStart time.Time
+}
+
+// cardinalityKey identifies a metric of a tenant.
+type cardinalityKey struct {
+    tenant string
+    name   string
+}
+
+// metricCardinality is the tracking state of a single metric.
+type metricCardinality struct {
+    series map[uint64]struct{}
+    // values holds the distinct values seen for each label key, up to one
+    // more than needed to pick the offending key.
+    values    map[string]map[uint64]struct{}
+    offending map[string]struct{}
+    // rejecting is set once a rejection of the metric has been logged.
+    rejecting bool
+}
+
+// newCardinalityLimiter returns a limiter, or nil when no metric is limited.
+func newCardinalityLimiter(cfg CardinalityConfig, logger *zap.Logger, telemetry *telemetry) *cardinalityLimiter {
+    if !cfg.enabled() {
+        return nil
+    }
+    return &cardinalityLimiter{
+        cfg:         cfg,
+        logger:      logger,
+        telemetry:   telemetry,
+        now:         time.Now,
+        metrics:     make(map[cardinalityKey]*metricCardinality),
+        windowStart: time.Now(),
+    }
+}
+
+// limit returns the number of series allowed for the metric name, zero for
+// any number.
+func (l *cardinalityLimiter) limit(name string) int {
+    if limit, ok := l.cfg.Metrics[name]; ok {
+        return limit
+    }
+    return l.cfg.MaxSeries
+}
+
+func (l *cardinalityLimiter) apply(ctx context.Context, tenant, name string, labels typedAttributes) (typedAttributes, bool) {
+    limit := l.limit(name)
+    if limit == 0 {
+        return labels, true
+    }
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if now := l.now(); l.cfg.Interval > 0 && now.Sub(l.windowStart) >= l.cfg.Interval {
+        l.metrics = make(map[cardinalityKey]*metricCardinality)
+        l.windowStart = now
+    }
+    key := cardinalityKey{tenant: tenant, name: name}
+    m, ok := l.metrics[key]
+    if !ok {
+        m = &metricCardinality{offending: make(map[string]struct{})}
+        m.reset()
+        l.metrics[key] = m
+    }
+
+    limited := false
+    for key := range m.offending {
+        if reduceLabel(&labels, key, l.cfg.Action, l.cfg.HashBuckets) {
+            limited = true
+        }
+    }
+    for {
+        entries := labelEntries(labels)
+        series := hashEntries(entries)
+        if _, ok := m.series[series]; ok {
+            break
+        }
+        if len(m.series) < limit {
+            m.add(series, entries, max(limit, l.cfg.HashBuckets)+1)
+            break
+        }
+
+        offending := ""
+        if l.cfg.Action != cardinalityActionReject {
+            offending = m.offendingKey(entries, l.cfg.Action, l.cfg.HashBuckets)
+        }
+        if offending == "" {
+            if !m.rejecting {
+                m.rejecting = true
+                l.logger.Warn("Rejecting data points of a metric over its cardinality limit",
+                    zap.String("tenant", tenant),
+                    zap.String("metric", name),
+                    zap.Int("max_series", limit),
+                )
+            }
+            l.telemetry.recordCardinalityLimited(ctx, cardinalityActionReject)
+            return typedAttributes{}, false
+        }
+        l.logger.Warn("Label key exceeds the cardinality limit of its metric",
+            zap.String("tenant", tenant),
+            zap.String("metric", name),
+            zap.String("key", offending),
+            zap.String("action", l.cfg.Action),
+            zap.Int("max_series", limit),
+        )
+        l.telemetry.recordOffendingKey(ctx, l.cfg.Action)
+        m.offending[offending] = struct{}{}
+        m.reset()
+        limited = reduceLabel(&labels, offending, l.cfg.Action, l.cfg.HashBuckets) || limited
+    }
+    if limited {
+        l.telemetry.recordCardinalityLimited(ctx, l.cfg.Action)
+    }
+    return labels, true
+}
+
+// reset forgets the tracked series and values, but not the offending keys.
+func (m *metricCardinality) reset() {
+    m.series = make(map[uint64]struct{})
+    m.values = make(map[string]map[uint64]struct{})
+    m.rejecting = false
+}
+
+// add tracks a new series, keeping at most maxValues values of each key.
+func (m *metricCardinality) add(series uint64, entries []labelEntry, maxValues int) {
+    m.series[series] = struct{}{}
+    for _, entry := range entries {
+        values, ok := m.values[entry.key]
+        if !ok {
+            values = make(map[uint64]struct{})
+            m.values[entry.key] = values
+        }
+        if len(values) < maxValues {
+            values[hashEntries([]labelEntry{{value: entry.value}})] = struct{}{}
+        }
+    }
+}
+
+// offendingKey returns the key of the entries with the most distinct values
+// that is not offending yet, or an empty string when none can reduce the
+// number of series: every key has a single value, or, when hashing, no
+// more values than there are buckets.
+func (m *metricCardinality) offendingKey(entries []labelEntry, action string, buckets int) string {
+    threshold := 1
+    if action == cardinalityActionHashLabels {
+        threshold = buckets
+    }
+    var key string
+    for _, entry := range entries {
+        if _, ok := m.offending[entry.key]; ok {
+            continue
+        }
+        if n := len(m.values[entry.key]); n > threshold {
+            threshold = n
+            key = entry.key
+        }
+    }
+    return key
+}
+
+// reduceLabel drops or hashes the offending key of the labels. It reports
+// whether the key was present.
+func reduceLabel(labels *typedAttributes, key, action string, buckets int) bool {
+    value, ok := labelValue(*labels, key)
+    if !ok {
+        return false
+    }
+    delete(labels.strings, key)
+    delete(labels.ints, key)
+    delete(labels.floats, key)
+    delete(labels.bools, key)
+    if action == cardinalityActionHashLabels {
+        labels.strings[key] = hashBucket(value, buckets)
+    }
+    return true
+}
+
+// hashBucket returns the value a hashed label is written with.
+func hashBucket(value string, buckets int) string {
+    h := fnv.New32a()
+    _, _ = h.Write([]byte(value))
+    return "hash:" + strconv.FormatUint(uint64(h.Sum32()%uint32(buckets)), 10)
+}
+
+// labelEntry is a label with its value encoded with its type, so that the
+// string "1" and the integer 1 are distinct values.
+type labelEntry struct {
+    key   string
+    value string
+}
+
+func labelValue(labels typedAttributes, key string) (string, bool) {
+    if v, ok := labels.strings[key]; ok {
+        return "s" + v, true
+    }
+    if v, ok := labels.ints[key]; ok {
+        return "i" + strconv.FormatInt(v, 10), true
+    }
+    if v, ok := labels.floats[key]; ok {
+        return "f" + strconv.FormatUint(math.Float64bits(v), 16), true
+    }
+    if v, ok := labels.bools[key]; ok {
+        return "b" + strconv.FormatBool(v), true
+    }
+    return "", false
+}
+
+// labelEntries returns the labels sorted by key.
+func labelEntries(labels typedAttributes) []labelEntry {
+    keys := make([]string, 0, len(labels.strings)+len(labels.ints)+len(labels.floats)+len(labels.bools))
+    for k := range labels.strings {
+        keys = append(keys, k)
+    }
+    for k := range labels.ints {
+        keys = append(keys, k)
+    }
+    for k := range labels.floats {
+        keys = append(keys, k)
+    }
+    for k := range labels.bools {
+        keys = append(keys, k)
+    }
+    slices.Sort(keys)
+    entries := make([]labelEntry, 0, len(keys))
+    for _, k := range keys {
+        v, _ := labelValue(labels, k)
+        entries = append(entries, labelEntry{key: k, value: v})
+    }
+    return entries
+}
+
+// hashEntries hashes label entries, each length-prefixed so that no two
+// label sets share an encoding.
+func hashEntries(entries []labelEntry) uint64 {
+    h := fnv.New64a()
+    var buf [binary.MaxVarintLen64]byte
+    for _, entry := range entries {
+        for _, s := range []string{entry.key, entry.value} {
+            n := binary.PutUvarint(buf[:], uint64(len(s)))
+            _, _ = h.Write(buf[:n])
+            _, _ = h.Write([]byte(s))
+        }
+    }
+    return h.Sum64()
+}
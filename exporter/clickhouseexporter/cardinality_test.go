@@ -0,0 +1,225 @@
+// exporter/clickhouseexporter/cardinality_test.go
+package clickhouseexporter
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.opentelemetry.io/collector/component/componenttest"
+    "go.opentelemetry.io/collector/pdata/pmetric"
+    sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// newCardinalityTestExporter returns an exporter limiting every metric to
+// three series, and one recording its telemetry.
+func newCardinalityTestExporter(t *testing.T, conn dbConn, configure func(*Config)) (*clickhouseExporter, *sdkmetric.ManualReader) {
+    reader := sdkmetric.NewManualReader()
+    set := componenttest.NewNopTelemetrySettings()
+    set.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = "localhost:9000"
+    cfg.Cardinality.MaxSeries = 3
+    configure(cfg)
+    require.NoError(t, cfg.Validate())
+    exp, err := newClickHouseExporter(set, cfg)
+    require.NoError(t, err)
+    exp.conn = conn
+    return exp, reader
+}
+
+// generateUserGauge returns a gauge of the metric name with a data point
+// for each of users users, each carrying its user_id.
+func generateUserGauge(name string, users int) pmetric.Metrics {
+    md := pmetric.NewMetrics()
+    metric := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+    metric.SetName(name)
+    points := metric.SetEmptyGauge().DataPoints()
+    for i := range users {
+        dp := points.AppendEmpty()
+        dp.SetIntValue(int64(i))
+        dp.Attributes().PutStr("path", "/")
+        dp.Attributes().PutInt("user_id", int64(i))
+    }
+    return md
+}
+
+func labelsOf(rows []map[string]any, column string) []map[string]any {
+    var labels []map[string]any
+    for _, row := range rows {
+        values := map[string]any{}
+        switch m := row[column].(type) {
+        case map[string]string:
+            for k, v := range m {
+                values[k] = v
+            }
+        case map[string]int64:
+            for k, v := range m {
+                values[k] = v
+            }
+        }
+        labels = append(labels, values)
+    }
+    return labels
+}
+
+func TestCardinalityDropLabels(t *testing.T) {
+    conn := &recordingConn{}
+    exp, reader := newCardinalityTestExporter(t, conn, func(cfg *Config) {
+        cfg.Cardinality.Metrics = map[string]int{"unlimited": 0}
+    })
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateUserGauge("requests", 10)))
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateUserGauge("unlimited", 10)))
+
+    rows := conn.rows("otel.metrics")
+    require.Len(t, rows, 20)
+    labels := labelsOf(rows[:10], "labels")
+    // The first series are written as they are.
+    assert.Equal(t, map[string]any{"path": "/", "user_id": "2"}, labels[2])
+    // The fourth exceeds the limit, so user_id is dropped from then on.
+    for _, l := range labels[3:] {
+        assert.Equal(t, map[string]any{"path": "/"}, l)
+    }
+    // Metrics without a limit keep every label.
+    assert.Equal(t, map[string]any{"path": "/", "user_id": "9"}, labelsOf(rows[10:], "labels")[9])
+
+    // Later payloads have user_id dropped too.
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateUserGauge("requests", 1)))
+    assert.Equal(t, map[string]any{"path": "/"}, labelsOf(conn.rows("otel.metrics")[20:], "labels")[0])
+
+    assert.Equal(t, map[string]int64{"drop_labels": 8}, collectSums(t, reader, "otelcol_exporter_clickhouse_cardinality_limited_points", "action"))
+    assert.Equal(t, map[string]int64{"drop_labels": 1}, collectSums(t, reader, "otelcol_exporter_clickhouse_cardinality_offending_keys", "action"))
+}
+
+func TestCardinalityHashLabels(t *testing.T) {
+    conn := &recordingConn{}
+    exp, reader := newCardinalityTestExporter(t, conn, func(cfg *Config) {
+        cfg.Attributes.Mode = attributesModeTyped
+        cfg.Cardinality.Action = cardinalityActionHashLabels
+        cfg.Cardinality.HashBuckets = 2
+    })
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateUserGauge("requests", 10)))
+
+    rows := conn.rows("otel.metrics")
+    require.Len(t, rows, 10)
+    assert.Equal(t, map[string]any{"user_id": int64(2)}, labelsOf(rows, "labels_int")[2])
+    // Hashed values are strings, whatever the type of the attribute.
+    for i, row := range rows[3:] {
+        assert.Empty(t, row["labels_int"], "row %d", i+3)
+        assert.Contains(t, []any{"hash:0", "hash:1"}, labelsOf(rows[3+i:4+i], "labels")[0]["user_id"])
+    }
+    assert.Equal(t, map[string]int64{"hash_labels": 7}, collectSums(t, reader, "otelcol_exporter_clickhouse_cardinality_limited_points", "action"))
+}
+
+func TestCardinalityHashLabelsRejectsWhenHashingCannotHelp(t *testing.T) {
+    conn := &recordingConn{}
+    exp, reader := newCardinalityTestExporter(t, conn, func(cfg *Config) {
+        cfg.Cardinality.Action = cardinalityActionHashLabels
+    })
+    // No key has more values than the 64 buckets hashing reduces them to.
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateUserGauge("requests", 5)))
+
+    assert.Len(t, conn.rows("otel.metrics"), 3)
+    assert.Equal(t, map[string]int64{"reject": 2}, collectSums(t, reader, "otelcol_exporter_clickhouse_cardinality_limited_points", "action"))
+}
+
+func TestCardinalityReject(t *testing.T) {
+    conn := &recordingConn{}
+    exp, reader := newCardinalityTestExporter(t, conn, func(cfg *Config) {
+        cfg.Cardinality.Action = cardinalityActionReject
+    })
+    md := generateUserGauge("requests", 5)
+    // Known series are still written.
+    points := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints()
+    points.At(0).CopyTo(points.AppendEmpty())
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+
+    var users []any
+    for _, labels := range labelsOf(conn.rows("otel.metrics"), "labels") {
+        users = append(users, labels["user_id"])
+    }
+    assert.Equal(t, []any{"0", "1", "2", "0"}, users)
+    assert.Equal(t, map[string]int64{"reject": 2}, collectSums(t, reader, "otelcol_exporter_clickhouse_cardinality_limited_points", "action"))
+    assert.Empty(t, collectSums(t, reader, "otelcol_exporter_clickhouse_cardinality_offending_keys", "action"))
+}
+
+func TestCardinalityLimitsEveryMetricTable(t *testing.T) {
+    conn := &recordingConn{}
+    exp, _ := newCardinalityTestExporter(t, conn, func(cfg *Config) {
+        cfg.Cardinality.Action = cardinalityActionReject
+        cfg.Cardinality.MaxSeries = 1
+    })
+    md := pmetric.NewMetrics()
+    metrics := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+    histogram := metrics.AppendEmpty()
+    histogram.SetName("duration")
+    histogram.SetEmptyHistogram()
+    exponential := metrics.AppendEmpty()
+    exponential.SetName("duration.exponential")
+    exponential.SetEmptyExponentialHistogram()
+    summary := metrics.AppendEmpty()
+    summary.SetName("duration.summary")
+    summary.SetEmptySummary()
+    for i := range 3 {
+        histogram.Histogram().DataPoints().AppendEmpty().Attributes().PutInt("user_id", int64(i))
+        exponential.ExponentialHistogram().DataPoints().AppendEmpty().Attributes().PutInt("user_id", int64(i))
+        summary.Summary().DataPoints().AppendEmpty().Attributes().PutInt("user_id", int64(i))
+    }
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+
+    assert.Len(t, conn.rows("otel.metrics_histogram"), 1)
+    assert.Len(t, conn.rows("otel.metrics_exponential_histogram"), 1)
+    assert.Len(t, conn.rows("otel.metrics_summary"), 1)
+}
+
+func TestCardinalityForgetsSeriesEveryInterval(t *testing.T) {
+    conn := &recordingConn{}
+    exp, _ := newCardinalityTestExporter(t, conn, func(cfg *Config) {
+        cfg.Cardinality.Action = cardinalityActionReject
+        cfg.Cardinality.Interval = time.Minute
+    })
+    now := time.Now()
+    exp.cardinality.now = func() time.Time { return now }
+
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateUserGauge("requests", 5)))
+    assert.Len(t, conn.rows("otel.metrics"), 3)
+
+    // Within the interval, the series of the restarted users stay rejected.
+    now = now.Add(59 * time.Second)
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateUserGauge("requests", 5)))
+    assert.Len(t, conn.rows("otel.metrics"), 6)
+
+    // Once it elapsed, the series are counted anew.
+    now = now.Add(time.Second)
+    md := generateUserGauge("requests", 5)
+    md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+        userID, _ := dp.Attributes().Get("user_id")
+        return userID.Int() < 2
+    })
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+    var users []any
+    for _, labels := range labelsOf(conn.rows("otel.metrics")[6:], "labels") {
+        users = append(users, labels["user_id"])
+    }
+    assert.Equal(t, []any{"2", "3", "4"}, users)
+}
+
+func TestCardinalityLimitsEveryTenant(t *testing.T) {
+    conn := &recordingConn{}
+    exp, _ := newCardinalityTestExporter(t, conn, func(cfg *Config) {
+        cfg.Cardinality.Action = cardinalityActionReject
+        cfg.Tenancy.ResourceAttribute = "tenant.id"
+        cfg.Tenancy.Allowed = []string{"team_a", "team_b"}
+    })
+    for _, tenant := range []string{"team_a", "team_b"} {
+        md := generateUserGauge("requests", 5)
+        md.ResourceMetrics().At(0).Resource().Attributes().PutStr("tenant.id", tenant)
+        require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+    }
+
+    // The series of one tenant do not count against the limit of another.
+    assert.Len(t, conn.rows("otel_team_a.metrics"), 3)
+    assert.Len(t, conn.rows("otel_team_b.metrics"), 3)
+}
@@ -0,0 +1,166 @@
+// exporter/clickhouseexporter/client.go
+package clickhouseexporter
+
+import (
+    "bufio"
+    "context"
+    "encoding/base64"
+    "fmt"
+    "net"
+    "net/http"
+    "net/url"
+    "time"
+
+    "github.com/ClickHouse/clickhouse-go/v2"
+    "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// dbConn is the part of the ClickHouse client the exporter depends on. It is
+// implemented by the native client, by httpConn and by the fakes used in
+// tests.
+type dbConn interface {
+    PrepareBatch(ctx context.Context, query string, opts ...driver.PrepareBatchOption) (driver.Batch, error)
+    Exec(ctx context.Context, query string, args ...any) error
+    QueryRow(ctx context.Context, query string, args ...any) driver.Row
+    Ping(ctx context.Context) error
+    Close() error
+}
+
+// openConn opens a connection for the protocol of opts. No request is made
+// to the server, so the connection has to be checked with Ping.
+func openConn(opts *clickhouse.Options) (dbConn, error) {
+    if opts.Protocol == clickhouse.HTTP {
+        return openHTTPConn(opts), nil
+    }
+    conn, err := clickhouse.Open(opts)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open ClickHouse connection: %w", err)
+    }
+    return conn, nil
+}
+
+// clientOptions translates the configuration into connection options for
+// the ClickHouse client.
+func clientOptions(ctx context.Context, cfg *Config) (*clickhouse.Options, error) {
+    opts := &clickhouse.Options{
+        Addr:     cfg.addresses(),
+        Protocol: clickhouse.Native,
+        // Tables are always fully qualified, and the database may not
+        // exist until the schema is created.
+        Auth: clickhouse.Auth{
+            Username: cfg.Username,
+            Password: string(cfg.Password),
+        },
+        DialTimeout:      cfg.DialTimeout,
+        MaxOpenConns:     cfg.MaxOpenConns,
+        MaxIdleConns:     cfg.MaxIdleConns,
+        ConnMaxLifetime:  cfg.ConnMaxLifetime,
+        ConnOpenStrategy: clickhouse.ConnOpenInOrder,
+        Settings: clickhouse.Settings{
+            "max_execution_time": 60,
+        },
+        Compression: &clickhouse.Compression{
+            Method: compressionMethods[cfg.Compression],
+        },
+    }
+    if cfg.AsyncInsert {
+        opts.Settings["async_insert"] = 1
+        opts.Settings["wait_for_async_insert"] = 0
+        if cfg.WaitForAsyncInsert {
+            opts.Settings["wait_for_async_insert"] = 1
+        }
+        // Async inserts are only deduplicated when asked to.
+        if cfg.InsertDeduplication {
+            opts.Settings["async_insert_deduplicate"] = 1
+        }
+    }
+    if cfg.ConnOpenStrategy == connOpenRoundRobin {
+        opts.ConnOpenStrategy = clickhouse.ConnOpenRoundRobin
+    }
+
+    // A local server without TLS is reached by turning secure off. The TLS
+    // settings return no configuration when tls::insecure is set.
+    if cfg.Secure {
+        tlsConfig, err := cfg.TLSSetting.LoadTLSConfig(ctx)
+        if err != nil {
+            return nil, fmt.Errorf("failed to load TLS configuration: %w", err)
+        }
+        opts.TLS = tlsConfig
+    }
+
+    if cfg.Protocol == protocolHTTP {
+        opts.Protocol = clickhouse.HTTP
+        if len(cfg.HTTPSettings.Headers) > 0 {
+            opts.HttpHeaders = make(map[string]string, len(cfg.HTTPSettings.Headers))
+            for name, value := range cfg.HTTPSettings.Headers {
+                opts.HttpHeaders[name] = string(value)
+            }
+        }
+        // Without proxy_url the HTTP_PROXY and HTTPS_PROXY environment
+        // variables are honored by the client.
+        if cfg.HTTPSettings.ProxyURL != "" {
+            proxy, err := url.Parse(cfg.HTTPSettings.ProxyURL)
+            if err != nil {
+                return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
+            }
+            opts.DialContext = proxyDialer(proxy, cfg.DialTimeout)
+        }
+    }
+
+    return opts, nil
+}
+
+// proxyAddress returns the host and port of proxy, with the default HTTP
+// port when the URL has none.
+func proxyAddress(proxy *url.URL) string {
+    if proxy.Port() != "" {
+        return proxy.Host
+    }
+    return net.JoinHostPort(proxy.Hostname(), "80")
+}
+
+// proxyDialer returns a dial function that reaches the server through an
+// HTTP CONNECT tunnel opened on proxy. TLS to the server, if enabled, is
+// negotiated by the client inside the tunnel.
+func proxyDialer(proxy *url.URL, timeout time.Duration) func(ctx context.Context, addr string) (net.Conn, error) {
+    dialer := &net.Dialer{Timeout: timeout}
+    proxyAddr := proxyAddress(proxy)
+    return func(ctx context.Context, addr string) (net.Conn, error) {
+        conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+        if err != nil {
+            return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxyAddr, err)
+        }
+        if timeout > 0 {
+            _ = conn.SetDeadline(time.Now().Add(timeout))
+        }
+
+        req := &http.Request{
+            Method: http.MethodConnect,
+            URL:    &url.URL{Opaque: addr},
+            Host:   addr,
+            Header: make(http.Header),
+        }
+        if proxy.User != nil {
+            password, _ := proxy.User.Password()
+            credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+            req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+        }
+        if err := req.Write(conn); err != nil {
+            _ = conn.Close()
+            return nil, fmt.Errorf("failed to open tunnel through proxy %s: %w", proxy.Host, err)
+        }
+        resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+        if err != nil {
+            _ = conn.Close()
+            return nil, fmt.Errorf("failed to open tunnel through proxy %s: %w", proxy.Host, err)
+        }
+        _ = resp.Body.Close()
+        if resp.StatusCode != http.StatusOK {
+            _ = conn.Close()
+            return nil, fmt.Errorf("proxy %s refused the tunnel to %s: %s", proxy.Host, addr, resp.Status)
+        }
+
+        _ = conn.SetDeadline(time.Time{})
+        return conn, nil
+    }
+}
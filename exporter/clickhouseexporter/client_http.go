@@ -0,0 +1,134 @@
+// exporter/clickhouseexporter/client_http.go
+package clickhouseexporter
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/ClickHouse/clickhouse-go/v2"
+    "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// httpConn serves the http protocol. The client only speaks HTTP through
+// database/sql, so httpConn adapts a *sql.DB to the dbConn interface used
+// by the exporter. Each batch runs in its own transaction, which the
+// client sends as a single native block when it is committed.
+type httpConn struct {
+    db *sql.DB
+}
+
+func openHTTPConn(opts *clickhouse.Options) *httpConn {
+    // The pool is owned by database/sql, which refuses these options.
+    maxOpen, maxIdle, maxLifetime := opts.MaxOpenConns, opts.MaxIdleConns, opts.ConnMaxLifetime
+    opts.MaxOpenConns, opts.MaxIdleConns, opts.ConnMaxLifetime = 0, 0, 0
+
+    db := clickhouse.OpenDB(opts)
+    db.SetMaxOpenConns(maxOpen)
+    db.SetMaxIdleConns(maxIdle)
+    db.SetConnMaxLifetime(maxLifetime)
+    return &httpConn{db: db}
+}
+
+func (c *httpConn) PrepareBatch(ctx context.Context, query string, _ ...driver.PrepareBatchOption) (driver.Batch, error) {
+    tx, err := c.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, err
+    }
+    // The client finds the table by matching the start of the statement.
+    stmt, err := tx.PrepareContext(ctx, strings.TrimSpace(query))
+    if err != nil {
+        _ = tx.Rollback()
+        return nil, err
+    }
+    return &httpBatch{ctx: ctx, tx: tx, stmt: stmt}, nil
+}
+
+func (c *httpConn) Exec(ctx context.Context, query string, args ...any) error {
+    _, err := c.db.ExecContext(ctx, query, args...)
+    return err
+}
+
+func (c *httpConn) QueryRow(ctx context.Context, query string, args ...any) driver.Row {
+    return httpRow{Row: c.db.QueryRowContext(ctx, query, args...)}
+}
+
+func (c *httpConn) Ping(ctx context.Context) error {
+    return c.db.PingContext(ctx)
+}
+
+func (c *httpConn) Close() error {
+    return c.db.Close()
+}
+
+type httpRow struct {
+    *sql.Row
+}
+
+func (r httpRow) ScanStruct(any) error {
+    return errors.New("ScanStruct is not supported over http")
+}
+
+// httpBatch appends the rows of a batch one at a time through database/sql,
+// which has no equivalent of the column and struct appends of the native
+// batch.
+type httpBatch struct {
+    ctx  context.Context
+    tx   *sql.Tx
+    stmt *sql.Stmt
+    rows int
+    sent bool
+}
+
+func (b *httpBatch) Append(v ...any) error {
+    if _, err := b.stmt.ExecContext(b.ctx, v...); err != nil {
+        return fmt.Errorf("failed to append row: %w", err)
+    }
+    b.rows++
+    return nil
+}
+
+func (b *httpBatch) AppendStruct(any) error {
+    return errors.New("AppendStruct is not supported over http")
+}
+
+func (b *httpBatch) Column(int) driver.BatchColumn {
+    return httpBatchColumn{}
+}
+
+func (b *httpBatch) Flush() error {
+    return errors.New("Flush is not supported over http")
+}
+
+func (b *httpBatch) Send() error {
+    b.sent = true
+    return b.tx.Commit()
+}
+
+func (b *httpBatch) Abort() error {
+    if b.sent {
+        return nil
+    }
+    b.sent = true
+    return b.tx.Rollback()
+}
+
+func (b *httpBatch) IsSent() bool {
+    return b.sent
+}
+
+func (b *httpBatch) Rows() int {
+    return b.rows
+}
+
+type httpBatchColumn struct{}
+
+func (httpBatchColumn) Append(any) error {
+    return errors.New("column appends are not supported over http")
+}
+
+func (httpBatchColumn) AppendRow(any) error {
+    return errors.New("column appends are not supported over http")
+}
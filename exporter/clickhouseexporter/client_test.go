@@ -0,0 +1,423 @@
+// exporter/clickhouseexporter/client_test.go
+package clickhouseexporter
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "path/filepath"
+    "slices"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/ClickHouse/ch-go/compress"
+    chproto "github.com/ClickHouse/ch-go/proto"
+    "github.com/ClickHouse/clickhouse-go/v2"
+    "github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.opentelemetry.io/collector/config/configcompression"
+    "go.opentelemetry.io/collector/component/componenttest"
+    "go.opentelemetry.io/collector/config/configopaque"
+)
+
+func TestClientOptions(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = "clickhouse-1:9440"
+    cfg.Endpoints = []string{"clickhouse-2:9440"}
+    cfg.ConnOpenStrategy = connOpenRoundRobin
+    cfg.TLSSetting.ServerName = "clickhouse.internal"
+    cfg.TLSSetting.InsecureSkipVerify = true
+
+    opts, err := clientOptions(context.Background(), cfg)
+    require.NoError(t, err)
+    assert.Equal(t, []string{"clickhouse-1:9440", "clickhouse-2:9440"}, opts.Addr)
+    assert.Equal(t, clickhouse.ConnOpenRoundRobin, opts.ConnOpenStrategy)
+    assert.Equal(t, 30*time.Second, opts.DialTimeout)
+    assert.Equal(t, 10, opts.MaxOpenConns)
+    assert.Equal(t, 5, opts.MaxIdleConns)
+    assert.Equal(t, time.Hour, opts.ConnMaxLifetime)
+    require.NotNil(t, opts.TLS)
+    assert.Equal(t, "clickhouse.internal", opts.TLS.ServerName)
+    assert.True(t, opts.TLS.InsecureSkipVerify)
+}
+
+func TestClientOptionsPlaintext(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = "localhost:9000"
+    cfg.Secure = false
+    opts, err := clientOptions(context.Background(), cfg)
+    require.NoError(t, err)
+    assert.Nil(t, opts.TLS)
+    assert.Equal(t, clickhouse.ConnOpenInOrder, opts.ConnOpenStrategy)
+
+    cfg.Secure = true
+    cfg.TLSSetting.Insecure = true
+    opts, err = clientOptions(context.Background(), cfg)
+    require.NoError(t, err)
+    assert.Nil(t, opts.TLS)
+}
+
+func TestClientOptionsAsyncInsert(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = "localhost:9000"
+    opts, err := clientOptions(context.Background(), cfg)
+    require.NoError(t, err)
+    assert.Equal(t, clickhouse.Settings{"max_execution_time": 60}, opts.Settings)
+
+    cfg.AsyncInsert = true
+    opts, err = clientOptions(context.Background(), cfg)
+    require.NoError(t, err)
+    assert.Equal(t, clickhouse.Settings{
+        "max_execution_time":    60,
+        "async_insert":          1,
+        "wait_for_async_insert": 1,
+    }, opts.Settings)
+
+    cfg.WaitForAsyncInsert = false
+    cfg.InsertDeduplication = true
+    opts, err = clientOptions(context.Background(), cfg)
+    require.NoError(t, err)
+    assert.Equal(t, clickhouse.Settings{
+        "max_execution_time":       60,
+        "async_insert":             1,
+        "wait_for_async_insert":    0,
+        "async_insert_deduplicate": 1,
+    }, opts.Settings)
+}
+
+func TestClientOptionsCompression(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = "localhost:9000"
+    tests := []struct {
+        compression configcompression.Type
+        method      clickhouse.CompressionMethod
+    }{
+        {configcompression.TypeLz4, clickhouse.CompressionLZ4},
+        {configcompression.TypeZstd, clickhouse.CompressionZSTD},
+        {configcompression.TypeGzip, clickhouse.CompressionGZIP},
+        {configcompression.TypeDeflate, clickhouse.CompressionDeflate},
+        {"none", clickhouse.CompressionNone},
+        {"", clickhouse.CompressionNone},
+    }
+    for _, tt := range tests {
+        cfg.Compression = tt.compression
+        opts, err := clientOptions(context.Background(), cfg)
+        require.NoError(t, err)
+        assert.Equal(t, tt.method, opts.Compression.Method, tt.compression)
+    }
+}
+
+func TestClientOptionsInvalidCA(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = "localhost:9440"
+    cfg.TLSSetting.CAFile = filepath.Join("testdata", "missing-ca.pem")
+    _, err := clientOptions(context.Background(), cfg)
+    assert.ErrorContains(t, err, "failed to load TLS configuration")
+}
+
+// standInHTTPServer is an httptest stand-in for the ClickHouse HTTP
+// interface. It answers the queries issued by the client when connecting
+// and preparing batches, and decodes the native blocks that are inserted.
+type standInHTTPServer struct {
+    *httptest.Server
+
+    mu      sync.Mutex
+    headers []http.Header
+    rows    map[string]int
+    // inserts holds the URL parameters, which carry the query settings, of
+    // every insert.
+    inserts []url.Values
+}
+
+func newStandInHTTPServer(t *testing.T) *standInHTTPServer {
+    s := &standInHTTPServer{rows: make(map[string]int)}
+    s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+    t.Cleanup(s.Close)
+    return s
+}
+
+func (s *standInHTTPServer) handle(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    s.headers = append(s.headers, r.Header.Clone())
+    s.mu.Unlock()
+
+    params := r.URL.Query()
+    body, err := io.ReadAll(r.Body)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    query := params.Get("query")
+    if query == "" {
+        query = string(body)
+        body = nil
+    }
+
+    var result *proto.Block
+    switch {
+    case query == "SELECT timezone()":
+        result = standInResult([]string{"timezone()"}, "UTC")
+    case query == "SELECT version()":
+        result = standInResult([]string{"version()"}, "24.3.1.1")
+    case query == "SELECT 1":
+        result = &proto.Block{}
+        _ = result.AddColumn("1", "UInt8")
+        _ = result.Append(uint8(1))
+    case strings.HasPrefix(query, "DESCRIBE TABLE "):
+        columns, ok := standInSchema[strings.TrimPrefix(query, "DESCRIBE TABLE ")]
+        if !ok {
+            http.Error(w, "Code: 60. DB::Exception: Unknown table", http.StatusNotFound)
+            return
+        }
+        names := []string{"name", "type", "default_type", "default_expression", "comment", "codec_expression", "ttl_expression"}
+        result = standInResult(names)
+        for _, col := range columns {
+            _ = result.Append(col[0], col[1], "", "", "", "", "")
+        }
+    case strings.HasPrefix(query, "INSERT INTO "):
+        table := strings.Fields(query)[2]
+        rows, err := decodeStandInBlocks(body, params.Get("decompress") == "1")
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        s.mu.Lock()
+        s.rows[table] += rows
+        s.inserts = append(s.inserts, params)
+        s.mu.Unlock()
+        return
+    default:
+        http.Error(w, "unsupported query: "+query, http.StatusBadRequest)
+        return
+    }
+
+    var buf chproto.Buffer
+    if err := result.Encode(&buf, 0); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    data := buf.Buf
+    if params.Get("compress") == "1" {
+        compressor := compress.NewWriter()
+        if err := compressor.Compress(compress.LZ4, data); err != nil {
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        data = compressor.Data
+    }
+    _, _ = w.Write(data)
+}
+
+// standInResult returns a block of String columns holding one row of
+// values, or no rows when values is empty.
+func standInResult(names []string, values ...string) *proto.Block {
+    block := &proto.Block{}
+    for _, name := range names {
+        _ = block.AddColumn(name, "String")
+    }
+    if len(values) > 0 {
+        row := make([]any, len(values))
+        for i, v := range values {
+            row[i] = v
+        }
+        _ = block.Append(row...)
+    }
+    return block
+}
+
+func decodeStandInBlocks(body []byte, compressed bool) (int, error) {
+    reader := chproto.NewReader(bytes.NewReader(body))
+    if compressed {
+        reader.EnableCompression()
+    }
+    rows := 0
+    for {
+        block := proto.Block{Timezone: time.UTC}
+        if err := block.Decode(reader, 0); err != nil {
+            if errors.Is(err, io.EOF) {
+                return rows, nil
+            }
+            return rows, err
+        }
+        rows += block.Rows()
+    }
+}
+
+func (s *standInHTTPServer) insertedRows(table string) int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.rows[table]
+}
+
+func (s *standInHTTPServer) insertParams() []url.Values {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return slices.Clone(s.inserts)
+}
+
+func (s *standInHTTPServer) lastHeaders() http.Header {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.headers[len(s.headers)-1]
+}
+
+func TestExporterProtocols(t *testing.T) {
+    server := newStandInHTTPServer(t)
+
+    tests := []struct {
+        protocol string
+        want     clickhouse.Protocol
+        // conn is the connection to use instead of opening one from the
+        // configuration. The native interface has no HTTP stand-in.
+        conn func() dbConn
+    }{
+        {protocol: protocolNative, want: clickhouse.Native, conn: func() dbConn { return newStandInConn() }},
+        {protocol: protocolHTTP, want: clickhouse.HTTP},
+    }
+    for _, tt := range tests {
+        t.Run(tt.protocol, func(t *testing.T) {
+            cfg := createDefaultConfig().(*Config)
+            cfg.Protocol = tt.protocol
+            cfg.Endpoint = server.Listener.Addr().String()
+            cfg.Secure = false
+            cfg.HTTPSettings.Headers = map[string]configopaque.String{"X-Tenant": "team-a"}
+
+            opts, err := clientOptions(context.Background(), cfg)
+            require.NoError(t, err)
+            assert.Equal(t, tt.want, opts.Protocol)
+
+            exp, err := newClickHouseExporter(componenttest.NewNopTelemetrySettings(), cfg)
+            require.NoError(t, err)
+            if tt.conn != nil {
+                exp.conn = tt.conn()
+            } else {
+                require.NoError(t, exp.start(context.Background(), nil))
+            }
+            defer func() { assert.NoError(t, exp.Shutdown(context.Background())) }()
+
+            require.NoError(t, exp.ConsumeMetrics(context.Background(), generateMetrics(2, 5)))
+
+            if tt.protocol == protocolHTTP {
+                assert.Equal(t, 20, server.insertedRows("otel.metrics"))
+                assert.Equal(t, 10, server.insertedRows("otel.metrics_histogram"))
+                assert.Equal(t, "team-a", server.lastHeaders().Get("X-Tenant"))
+            }
+        })
+    }
+}
+
+func TestExporterHTTPProxy(t *testing.T) {
+    server := newStandInHTTPServer(t)
+
+    var tunnels []string
+    var mu sync.Mutex
+    proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodConnect {
+            http.Error(w, "only CONNECT is supported", http.StatusMethodNotAllowed)
+            return
+        }
+        mu.Lock()
+        tunnels = append(tunnels, r.Host)
+        mu.Unlock()
+        upstream, err := net.Dial("tcp", r.Host)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadGateway)
+            return
+        }
+        client, _, err := w.(http.Hijacker).Hijack()
+        if err != nil {
+            _ = upstream.Close()
+            return
+        }
+        _, _ = client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+        go func() {
+            _, _ = io.Copy(upstream, client)
+            _ = upstream.Close()
+        }()
+        _, _ = io.Copy(client, upstream)
+        _ = client.Close()
+    }))
+    defer proxy.Close()
+
+    cfg := createDefaultConfig().(*Config)
+    cfg.Protocol = protocolHTTP
+    cfg.Endpoint = server.Listener.Addr().String()
+    cfg.Secure = false
+    cfg.HTTPSettings.ProxyURL = proxy.URL
+
+    exp, err := newClickHouseExporter(componenttest.NewNopTelemetrySettings(), cfg)
+    require.NoError(t, err)
+    require.NoError(t, exp.start(context.Background(), nil))
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateMetrics(1, 5)))
+    require.NoError(t, exp.Shutdown(context.Background()))
+
+    assert.Equal(t, 10, server.insertedRows("otel.metrics"))
+    mu.Lock()
+    defer mu.Unlock()
+    require.NotEmpty(t, tunnels)
+    assert.Equal(t, cfg.Endpoint, tunnels[0])
+}
+
+func TestHTTPBatchUnsupportedMethods(t *testing.T) {
+    batch := &httpBatch{}
+    assert.ErrorContains(t, batch.AppendStruct(struct{}{}), "not supported over http")
+    assert.ErrorContains(t, batch.Flush(), "not supported over http")
+    assert.ErrorContains(t, batch.Column(0).Append(1), "not supported over http")
+    assert.ErrorContains(t, batch.Column(0).AppendRow(1), "not supported over http")
+}
+
+func TestProxyAddress(t *testing.T) {
+    for raw, want := range map[string]string{
+        "http://proxy":       "proxy:80",
+        "http://proxy:3128":  "proxy:3128",
+        "http://[::1]":       "[::1]:80",
+        "http://user@proxy/": "proxy:80",
+    } {
+        proxy, err := url.Parse(raw)
+        require.NoError(t, err)
+        assert.Equal(t, want, proxyAddress(proxy), raw)
+    }
+}
+
+func TestExporterInsertDeduplicationToken(t *testing.T) {
+    server := newStandInHTTPServer(t)
+
+    cfg := createDefaultConfig().(*Config)
+    cfg.Protocol = protocolHTTP
+    cfg.Endpoint = server.Listener.Addr().String()
+    cfg.Secure = false
+    cfg.AsyncInsert = true
+    cfg.InsertDeduplication = true
+
+    exp, err := newClickHouseExporter(componenttest.NewNopTelemetrySettings(), cfg)
+    require.NoError(t, err)
+    require.NoError(t, exp.start(context.Background(), nil))
+    defer func() { assert.NoError(t, exp.Shutdown(context.Background())) }()
+
+    // A retry sends the same payload again, another payload differs.
+    md := generateMetrics(1, 5)
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateMetrics(1, 6)))
+
+    // Every payload inserts into the metrics and histogram tables.
+    inserts := server.insertParams()
+    require.Len(t, inserts, 6)
+    for _, params := range inserts {
+        assert.Equal(t, "1", params.Get("async_insert"))
+        assert.Equal(t, "1", params.Get("wait_for_async_insert"))
+        assert.Len(t, params.Get("insert_deduplication_token"), 32)
+    }
+    token := inserts[0].Get("insert_deduplication_token")
+    assert.Equal(t, token, inserts[1].Get("insert_deduplication_token"))
+    assert.Equal(t, token, inserts[2].Get("insert_deduplication_token"))
+    assert.NotEqual(t, token, inserts[4].Get("insert_deduplication_token"))
+}
@@ -0,0 +1,107 @@
+// exporter/clickhouseexporter/codecs.go
+package clickhouseexporter
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/ClickHouse/clickhouse-go/v2"
+    "go.opentelemetry.io/collector/config/configcompression"
+)
+
+const compressionNone configcompression.Type = "none"
+
+// compressionMethods maps the compression names to the methods of the
+// client. The native protocol only supports lz4 and zstd.
+var compressionMethods = map[configcompression.Type]clickhouse.CompressionMethod{
+    "":                            clickhouse.CompressionNone,
+    compressionNone:               clickhouse.CompressionNone,
+    configcompression.TypeLz4:     clickhouse.CompressionLZ4,
+    configcompression.TypeZstd:    clickhouse.CompressionZSTD,
+    configcompression.TypeGzip:    clickhouse.CompressionGZIP,
+    configcompression.TypeDeflate: clickhouse.CompressionDeflate,
+}
+
+// compressionSupported reports whether the compression can be used with
+// the protocol.
+func compressionSupported(compression configcompression.Type, protocol string) bool {
+    if _, ok := compressionMethods[compression]; !ok {
+        return false
+    }
+    switch compression {
+    case configcompression.TypeGzip, configcompression.TypeDeflate:
+        return protocol == protocolHTTP
+    }
+    return true
+}
+
+// timestampCodecs and valueCodecs are the codecs that can be applied to
+// the timestamp and the value columns. T64 only applies to integers.
+var (
+    timestampCodecs = []string{"Delta", "DoubleDelta", "Gorilla", "T64"}
+    valueCodecs     = []string{"Delta", "DoubleDelta", "Gorilla"}
+)
+
+// maxZSTDLevel is the highest level of the ZSTD codec.
+const maxZSTDLevel = 22
+
+// codecClause renders the CODEC of a column stored with codec, followed by
+// ZSTD at the configured level. It is empty when neither is set, which
+// leaves the column with the server default.
+func (cfg CodecsConfig) codecClause(codec string) string {
+    var codecs []string
+    if codec != "" {
+        codecs = append(codecs, codec)
+    }
+    if cfg.ZSTDLevel > 0 {
+        codecs = append(codecs, fmt.Sprintf("ZSTD(%d)", cfg.ZSTDLevel))
+    }
+    if len(codecs) == 0 {
+        return ""
+    }
+    return "CODEC(" + strings.Join(codecs, ", ") + ")"
+}
+
+// codecStatements set the configured codecs on the timestamp and value
+// columns of every table. Existing parts keep their codecs until they are
+// merged.
+func (s schema) codecStatements() []string {
+    timestamp := s.codecs.codecClause(s.codecs.Timestamp)
+    value := s.codecs.codecClause(s.codecs.Value)
+
+    type tableColumns struct {
+        name       string
+        timestamps []string
+        values     []string
+    }
+    metricTimestamps := []string{"timestamp", "start_time_unix"}
+    tables := []tableColumns{
+        {s.tables.Metrics, metricTimestamps, []string{"value"}},
+        {s.tables.MetricsHistogram, metricTimestamps, []string{"sum", "min", "max"}},
+        {s.tables.MetricsExponentialHistogram, metricTimestamps, []string{"sum", "min", "max"}},
+        {s.tables.MetricsSummary, metricTimestamps, []string{"sum"}},
+        {s.tables.Traces, []string{"timestamp"}, nil},
+        {s.tables.Logs, []string{"timestamp", "observed_timestamp"}, nil},
+        {s.tables.ProfileSamples, []string{"timestamp"}, nil},
+    }
+
+    var statements []string
+    for _, table := range tables {
+        var clauses []string
+        if timestamp != "" {
+            for _, column := range table.timestamps {
+                clauses = append(clauses, fmt.Sprintf("\n    MODIFY COLUMN %s %s", column, timestamp))
+            }
+        }
+        if value != "" {
+            for _, column := range table.values {
+                clauses = append(clauses, fmt.Sprintf("\n    MODIFY COLUMN %s %s", column, value))
+            }
+        }
+        if len(clauses) == 0 {
+            continue
+        }
+        statements = append(statements, fmt.Sprintf("ALTER TABLE %s%s%s", s.table(table.name), s.onCluster(), strings.Join(clauses, ",")))
+    }
+    return statements
+}
@@ -4,29 +4,488 @@ package clickhouseexporter
 import (
     "os"
     "fmt"
+    "errors"
+    "net/url"
+    "regexp"
+    "slices"
+    "strings"
+    "time"
+
+    "go.opentelemetry.io/collector/config/configcompression"
+    "go.opentelemetry.io/collector/config/configopaque"
+    "go.opentelemetry.io/collector/config/configretry"
+    "go.opentelemetry.io/collector/config/configtls"
+    "go.opentelemetry.io/collector/exporter/exporterhelper"
 )
 
+// HTTPClientConfig holds the settings specific to the http protocol. The
+// endpoints, TLS, timeouts and compression of the connection are set by
+// Config for both protocols.
+type HTTPClientConfig struct {
+    // ProxyURL is the http:// URL of a proxy the connections are tunneled
+    // through.
+    ProxyURL string `mapstructure:"proxy_url"`
+    // Headers are added to every request.
+    Headers map[string]configopaque.String `mapstructure:"headers"`
+}
+
 type Config struct {
-    Endpoint string
-    Username string
-    Password string
-    Database string
-    Secure   bool
+    exporterhelper.TimeoutConfig `mapstructure:",squash"`
+    configretry.BackOffConfig    `mapstructure:"retry_on_failure"`
+    QueueSettings                exporterhelper.QueueConfig `mapstructure:"sending_queue"`
+
+    // Protocol is native, for the native TCP interface, or http, for the
+    // HTTP(S) interface.
+    Protocol string `mapstructure:"protocol"`
+    // HTTPSettings holds the proxy and custom headers used by the http
+    // protocol. Endpoints and TLS are taken from the settings below.
+    HTTPSettings HTTPClientConfig `mapstructure:"http"`
+
+    // Endpoint is the host:port of the ClickHouse interface selected by
+    // Protocol.
+    Endpoint string `mapstructure:"endpoint"`
+    // Endpoints lists further servers of the same cluster. Connections are
+    // spread over Endpoint and Endpoints according to ConnOpenStrategy.
+    Endpoints []string `mapstructure:"endpoints"`
+    // ConnOpenStrategy is in_order, which fails over to the next server only
+    // when one is unreachable, or round_robin, which balances connections.
+    ConnOpenStrategy string `mapstructure:"conn_open_strategy"`
+    Username string `mapstructure:"username"`
+    Password configopaque.String `mapstructure:"password"`
+    Database string `mapstructure:"database"`
+    // Secure enables TLS for the connection, configured by TLSSetting.
+    Secure bool `mapstructure:"secure"`
+    // TLSSetting holds the CA, client certificate and verification settings.
+    // Setting its insecure flag also disables TLS.
+    TLSSetting configtls.ClientConfig `mapstructure:"tls"`
+
+    // DialTimeout bounds the time to establish a connection.
+    DialTimeout time.Duration `mapstructure:"dial_timeout"`
+    // MaxOpenConns, MaxIdleConns and ConnMaxLifetime size the connection pool.
+    MaxOpenConns    int           `mapstructure:"max_open_conns"`
+    MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+    ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+
+    // AsyncInsert has the server buffer inserts and write them together,
+    // which reduces the number of parts when many collectors send small
+    // batches.
+    AsyncInsert bool `mapstructure:"async_insert"`
+    // WaitForAsyncInsert makes an async insert return only once the buffer
+    // is written. Without it, errors writing the buffer are not reported
+    // back and the data is lost.
+    WaitForAsyncInsert bool `mapstructure:"wait_for_async_insert"`
+    // InsertDeduplication sends every insert with an
+    // insert_deduplication_token derived from a hash of the payload, so
+    // that the server drops the rows of an insert retried after a timeout
+    // or a network failure that it had in fact written.
+    InsertDeduplication bool `mapstructure:"insert_deduplication"`
+    // Compression compresses the data sent to the server: none, lz4 or
+    // zstd, and with the http protocol also gzip or deflate.
+    Compression configcompression.Type `mapstructure:"compression"`
+
+    // CreateSchema creates the database and tables on start and applies any
+    // pending schema migrations.
+    CreateSchema bool `mapstructure:"create_schema"`
+    // Tables holds the names of the tables written to, inside Database.
+    Tables TablesConfig `mapstructure:"tables"`
+    // TableEngine is the engine of the created tables.
+    TableEngine TableEngine `mapstructure:"table_engine"`
+    // ClusterName, when set, creates the schema ON CLUSTER.
+    ClusterName string `mapstructure:"cluster_name"`
+    // TTL is how long rows are kept. Zero keeps them forever.
+    TTL time.Duration `mapstructure:"ttl"`
+    // PartitionBy overrides the partition expression of the created tables.
+    PartitionBy string `mapstructure:"partition_by"`
+    // Codecs sets the codecs the timestamp and value columns are stored with.
+    Codecs CodecsConfig `mapstructure:"codecs"`
+
+    // Attributes controls how metric data point attributes are stored.
+    Attributes AttributesConfig `mapstructure:"attributes"`
+    // Rollups controls the downsampled gauge and sum tables created with the
+    // schema.
+    Rollups RollupsConfig `mapstructure:"rollups"`
+    // Tenancy routes the data of each tenant to a database of its own.
+    Tenancy TenancyConfig `mapstructure:"tenancy"`
+    // Cardinality limits the number of series of each metric.
+    Cardinality CardinalityConfig `mapstructure:"cardinality"`
+}
+
+// CardinalityConfig limits the number of series, distinct label sets, that
+// each metric may have. Data points past the limit are rejected, or have
+// the label key with the most distinct values dropped or hashed from then
+// on. Limits are tracked per exporter instance, tenant and metric.
+type CardinalityConfig struct {
+    // MaxSeries is the number of series of every metric. Zero does not
+    // limit them.
+    MaxSeries int `mapstructure:"max_series"`
+    // Metrics overrides MaxSeries for the metrics it names. Zero does not
+    // limit the series of the metric.
+    Metrics map[string]int `mapstructure:"metrics"`
+    // Action is what happens to data points past the limit: drop_labels,
+    // hash_labels or reject.
+    Action string `mapstructure:"action"`
+    // HashBuckets is the number of values hash_labels reduces the values of
+    // an offending key to.
+    HashBuckets int `mapstructure:"hash_buckets"`
+    // Interval is how long series are tracked. Every interval, the series
+    // and the offending keys of every metric are forgotten, so that series
+    // that are no longer sent stop counting. Zero tracks them since the
+    // start of the exporter.
+    Interval time.Duration `mapstructure:"interval"`
+}
+
+// TenancyConfig names where the tenant of the data is read from and the
+// tenants that are routed. The data of tenant t is written to the database
+// <database>_t, which has the same tables as the configured database.
+type TenancyConfig struct {
+    // ResourceAttribute is the resource attribute naming the tenant, such as
+    // tenant.id.
+    ResourceAttribute string `mapstructure:"resource_attribute"`
+    // MetadataKey is the client metadata key naming the tenant, as kept by
+    // receivers with include_metadata. It applies to resources that do not
+    // name a tenant with ResourceAttribute.
+    MetadataKey string `mapstructure:"metadata_key"`
+    // Allowed lists the tenants that get a database of their own.
+    Allowed []string `mapstructure:"allowed"`
+    // Default is the tenant of data naming no tenant or one that is not
+    // allowed. Empty writes such data to the configured database.
+    Default string `mapstructure:"default"`
+}
+
+// CodecsConfig sets the codecs of the timestamp and value columns, which
+// make up most of the stored data. Each is applied before the ZSTD level,
+// e.g. timestamp DoubleDelta and zstd_level 1 store the timestamps with
+// CODEC(DoubleDelta, ZSTD(1)). Unset columns keep the server default.
+type CodecsConfig struct {
+    // Timestamp is the codec of the timestamp columns: Delta, DoubleDelta,
+    // Gorilla or T64.
+    Timestamp string `mapstructure:"timestamp"`
+    // Value is the codec of the metric value columns: Delta, DoubleDelta or
+    // Gorilla.
+    Value string `mapstructure:"value"`
+    // ZSTDLevel compresses the columns with ZSTD at this level, from 1 to
+    // 22, rather than with the default of the server.
+    ZSTDLevel int `mapstructure:"zstd_level"`
+}
+
+// RollupsConfig lists the rollups of the metrics table. Each interval gets
+// an AggregatingMergeTree table fed by a materialized view, and a view with
+// the increase and rate of counters, which handles counter resets.
+type RollupsConfig struct {
+    // Intervals are the rollup resolutions, in whole minutes, e.g. 1m and 1h.
+    Intervals []time.Duration `mapstructure:"intervals"`
+    // TTL is how long rollup rows are kept. Zero keeps them forever.
+    TTL time.Duration `mapstructure:"ttl"`
+}
+
+// AttributesConfig controls how metric data point attributes are stored.
+type AttributesConfig struct {
+    // Mode is string, which writes every attribute to the labels map as a
+    // string, or typed, which writes integer, double and boolean attributes
+    // to the labels_int, labels_float and labels_bool maps instead.
+    Mode string `mapstructure:"mode"`
+    // Promoted lists attributes that are also written to a top-level
+    // column of their own, for fast filtering.
+    Promoted []PromotedAttribute `mapstructure:"promoted"`
+}
+
+// PromotedAttribute is an attribute written to the column attr_<key>, with
+// every character of the key that is not allowed in an identifier replaced
+// by an underscore.
+type PromotedAttribute struct {
+    Key string `mapstructure:"key"`
+    // Type is the column type: String, Int64, Float64 or Bool. Defaults to
+    // String.
+    Type string `mapstructure:"type"`
+}
+
+// TablesConfig names the table of each kind of row.
+type TablesConfig struct {
+    Metrics                     string `mapstructure:"metrics"`
+    MetricsHistogram            string `mapstructure:"metrics_histogram"`
+    MetricsExponentialHistogram string `mapstructure:"metrics_exponential_histogram"`
+    MetricsSummary              string `mapstructure:"metrics_summary"`
+    Traces                      string `mapstructure:"traces"`
+    Logs                        string `mapstructure:"logs"`
+    // ProfileSamples holds the samples of profiles. The locations, functions
+    // and mappings they reference are stored once in the tables below.
+    ProfileSamples              string `mapstructure:"profile_samples"`
+    ProfileLocations            string `mapstructure:"profile_locations"`
+    ProfileFunctions            string `mapstructure:"profile_functions"`
+    ProfileMappings             string `mapstructure:"profile_mappings"`
+}
+
+// TableEngine is a MergeTree family engine and its parameters, e.g.
+// ReplicatedMergeTree with '/clickhouse/tables/{shard}/{table}', '{replica}'.
+type TableEngine struct {
+    Name   string `mapstructure:"name"`
+    Params string `mapstructure:"params"`
+}
+
+const (
+    protocolNative = "native"
+    protocolHTTP   = "http"
+)
+
+const (
+    connOpenInOrder    = "in_order"
+    connOpenRoundRobin = "round_robin"
+)
+
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// tenantRe matches tenant names, which are appended to the database name.
+var tenantRe = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+var supportedEngines = map[string]struct{}{
+    "MergeTree":           {},
+    "ReplicatedMergeTree": {},
+}
+
+// addresses returns every configured server address.
+func (cfg *Config) addresses() []string {
+    var addrs []string
+    if cfg.Endpoint != "" {
+        addrs = append(addrs, cfg.Endpoint)
+    }
+    return append(addrs, cfg.Endpoints...)
+}
+
+// Validate checks that the configuration is usable.
+func (cfg *Config) Validate() error {
+    if len(cfg.addresses()) == 0 {
+        return errors.New("endpoint must be specified")
+    }
+    for _, endpoint := range cfg.Endpoints {
+        if endpoint == "" {
+            return errors.New("endpoints must not contain empty values")
+        }
+    }
+    switch cfg.Protocol {
+    case protocolNative, protocolHTTP:
+    default:
+        return fmt.Errorf("protocol %q is not supported, use %s or %s", cfg.Protocol, protocolNative, protocolHTTP)
+    }
+    if cfg.HTTPSettings.ProxyURL != "" {
+        proxy, err := url.Parse(cfg.HTTPSettings.ProxyURL)
+        if err != nil || proxy.Scheme != "http" || proxy.Host == "" {
+            return fmt.Errorf("http::proxy_url %q must be an http:// URL", cfg.HTTPSettings.ProxyURL)
+        }
+    }
+    switch cfg.ConnOpenStrategy {
+    case connOpenInOrder, connOpenRoundRobin:
+    default:
+        return fmt.Errorf("conn_open_strategy %q is not supported, use %s or %s", cfg.ConnOpenStrategy, connOpenInOrder, connOpenRoundRobin)
+    }
+    if cfg.DialTimeout < 0 || cfg.ConnMaxLifetime < 0 {
+        return errors.New("dial_timeout and conn_max_lifetime must not be negative")
+    }
+    if cfg.MaxOpenConns < 0 || cfg.MaxIdleConns < 0 {
+        return errors.New("max_open_conns and max_idle_conns must not be negative")
+    }
+    if cfg.Database == "" {
+        return errors.New("database must be specified")
+    }
+    // Names are interpolated into SQL, so only plain identifiers are allowed.
+    names := [][2]string{
+        {"database", cfg.Database},
+        {"tables::metrics", cfg.Tables.Metrics},
+        {"tables::metrics_histogram", cfg.Tables.MetricsHistogram},
+        {"tables::metrics_exponential_histogram", cfg.Tables.MetricsExponentialHistogram},
+        {"tables::metrics_summary", cfg.Tables.MetricsSummary},
+        {"tables::traces", cfg.Tables.Traces},
+        {"tables::logs", cfg.Tables.Logs},
+        {"tables::profile_samples", cfg.Tables.ProfileSamples},
+        {"tables::profile_locations", cfg.Tables.ProfileLocations},
+        {"tables::profile_functions", cfg.Tables.ProfileFunctions},
+        {"tables::profile_mappings", cfg.Tables.ProfileMappings},
+    }
+    for _, name := range names {
+        if !identifierRe.MatchString(name[1]) {
+            return fmt.Errorf("%s %q is not a valid identifier", name[0], name[1])
+        }
+    }
+    if cfg.ClusterName != "" && !identifierRe.MatchString(cfg.ClusterName) {
+        return fmt.Errorf("cluster_name %q is not a valid identifier", cfg.ClusterName)
+    }
+    if _, ok := supportedEngines[cfg.TableEngine.Name]; !ok {
+        return fmt.Errorf("table_engine::name %q is not supported, use MergeTree or ReplicatedMergeTree", cfg.TableEngine.Name)
+    }
+    if cfg.TTL < 0 {
+        return errors.New("ttl must not be negative")
+    }
+    if err := cfg.Attributes.Validate(); err != nil {
+        return err
+    }
+    if err := cfg.Rollups.Validate(); err != nil {
+        return err
+    }
+    if !compressionSupported(cfg.Compression, cfg.Protocol) {
+        return fmt.Errorf("compression %q is not supported with protocol %s", cfg.Compression, cfg.Protocol)
+    }
+    if err := cfg.Codecs.Validate(); err != nil {
+        return err
+    }
+    if err := cfg.Tenancy.Validate(); err != nil {
+        return err
+    }
+    if err := cfg.Cardinality.Validate(); err != nil {
+        return err
+    }
+    // The persistent queue, and the spillover storage once the memory is
+    // full, do not keep the context of a request, so its client metadata is
+    // gone by the time the request is exported.
+    if cfg.Tenancy.MetadataKey != "" && cfg.QueueSettings.Enabled && cfg.QueueSettings.StorageID != nil {
+        return errors.New("tenancy::metadata_key cannot be used with a persistent sending_queue")
+    }
+    if cfg.Tenancy.MetadataKey != "" && cfg.QueueSettings.Enabled && cfg.QueueSettings.SpilloverStorageID != nil {
+        return errors.New("tenancy::metadata_key cannot be used with sending_queue::spillover_storage")
+    }
+    return nil
+}
+
+// Validate checks that routed tenants are named where the tenant is read
+// from, and that their names can be part of a database name.
+func (cfg TenancyConfig) Validate() error {
+    if !cfg.enabled() {
+        if len(cfg.Allowed) > 0 || cfg.Default != "" {
+            return errors.New("tenancy::allowed and tenancy::default require tenancy::resource_attribute or tenancy::metadata_key")
+        }
+        return nil
+    }
+    if len(cfg.Allowed) == 0 {
+        return errors.New("tenancy::allowed must list at least one tenant")
+    }
+    for _, tenant := range cfg.Allowed {
+        if !tenantRe.MatchString(tenant) {
+            return fmt.Errorf("tenancy::allowed tenant %q may only contain letters, digits and underscores", tenant)
+        }
+    }
+    if cfg.Default != "" && !slices.Contains(cfg.Allowed, cfg.Default) {
+        return fmt.Errorf("tenancy::default %q is not an allowed tenant", cfg.Default)
+    }
+    return nil
+}
+
+func (cfg TenancyConfig) enabled() bool {
+    return cfg.ResourceAttribute != "" || cfg.MetadataKey != ""
+}
+
+// Validate checks the limits, the interval, the action and the number of
+// hash buckets.
+func (cfg CardinalityConfig) Validate() error {
+    if cfg.MaxSeries < 0 {
+        return errors.New("cardinality::max_series must not be negative")
+    }
+    if cfg.Interval < 0 {
+        return errors.New("cardinality::interval must not be negative")
+    }
+    for name, limit := range cfg.Metrics {
+        if limit < 0 {
+            return fmt.Errorf("cardinality::metrics limit of %q must not be negative", name)
+        }
+    }
+    switch cfg.Action {
+    case cardinalityActionDropLabels, cardinalityActionReject:
+    case cardinalityActionHashLabels:
+        if cfg.HashBuckets < 1 {
+            return errors.New("cardinality::hash_buckets must be positive")
+        }
+    default:
+        return fmt.Errorf("cardinality::action %q is not supported, use %s, %s or %s",
+            cfg.Action, cardinalityActionDropLabels, cardinalityActionHashLabels, cardinalityActionReject)
+    }
+    return nil
+}
+
+// enabled reports whether any metric has a limit.
+func (cfg CardinalityConfig) enabled() bool {
+    if cfg.MaxSeries > 0 {
+        return true
+    }
+    for _, limit := range cfg.Metrics {
+        if limit > 0 {
+            return true
+        }
+    }
+    return false
+}
+
+// Validate checks the attribute mode and the promoted attributes.
+func (cfg AttributesConfig) Validate() error {
+    switch cfg.Mode {
+    case attributesModeString, attributesModeTyped:
+    default:
+        return fmt.Errorf("attributes::mode %q is not supported, use %s or %s", cfg.Mode, attributesModeString, attributesModeTyped)
+    }
+    columns := make(map[string]string, len(cfg.Promoted))
+    for _, attr := range cfg.Promoted {
+        if attr.Key == "" {
+            return errors.New("attributes::promoted keys must not be empty")
+        }
+        if _, ok := promotedColumnTypes[attr.Type]; !ok {
+            return fmt.Errorf("attributes::promoted type %q of %q is not supported, use String, Int64, Float64 or Bool", attr.Type, attr.Key)
+        }
+        column := promotedColumnName(attr.Key)
+        if other, ok := columns[column]; ok {
+            return fmt.Errorf("attributes::promoted keys %q and %q both map to column %s", other, attr.Key, column)
+        }
+        columns[column] = attr.Key
+    }
+    return nil
 }
 
+// Validate checks the codec names and the ZSTD level.
+func (cfg CodecsConfig) Validate() error {
+    if cfg.Timestamp != "" && !slices.Contains(timestampCodecs, cfg.Timestamp) {
+        return fmt.Errorf("codecs::timestamp %q is not supported, use one of %s", cfg.Timestamp, strings.Join(timestampCodecs, ", "))
+    }
+    if cfg.Value != "" && !slices.Contains(valueCodecs, cfg.Value) {
+        return fmt.Errorf("codecs::value %q is not supported, use one of %s", cfg.Value, strings.Join(valueCodecs, ", "))
+    }
+    if cfg.ZSTDLevel < 0 || cfg.ZSTDLevel > maxZSTDLevel {
+        return fmt.Errorf("codecs::zstd_level %d must be between 0 (server default) and %d", cfg.ZSTDLevel, maxZSTDLevel)
+    }
+    return nil
+}
+
+// Validate checks that the rollup intervals are distinct whole minutes.
+func (cfg RollupsConfig) Validate() error {
+    seen := make(map[time.Duration]struct{}, len(cfg.Intervals))
+    for _, interval := range cfg.Intervals {
+        if interval <= 0 || interval%time.Minute != 0 {
+            return fmt.Errorf("rollups::intervals %s must be a positive whole number of minutes", interval)
+        }
+        if _, ok := seen[interval]; ok {
+            return fmt.Errorf("rollups::intervals %s is listed twice", interval)
+        }
+        seen[interval] = struct{}{}
+    }
+    if cfg.TTL < 0 {
+        return errors.New("rollups::ttl must not be negative")
+    }
+    return nil
+}
+
+// NewConfig builds a configuration for standalone usage from the
+// CLICKHOUSE_* environment variables. The endpoint and password have no
+// default and must be set.
 func NewConfig() (*Config, error) {
+    endpoint := os.Getenv("CLICKHOUSE_ENDPOINT")
+    if endpoint == "" {
+        return nil, fmt.Errorf("CLICKHOUSE_ENDPOINT environment variable is not set")
+    }
     password := os.Getenv("CLICKHOUSE_PASSWORD")
     if password == "" {
         return nil, fmt.Errorf("CLICKHOUSE_PASSWORD environment variable is not set")
     }
 
-    return &Config{
-        Endpoint: getEnvWithDefault("CLICKHOUSE_ENDPOINT", "t3v0qmphlz.ap-south-1.aws.clickhouse.cloud:9440"),
-        Username: getEnvWithDefault("CLICKHOUSE_USERNAME", "default"),
-        Password: password,
-        Database: getEnvWithDefault("CLICKHOUSE_DATABASE", "otel"),
-        Secure:   true,
-    }, nil
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = endpoint
+    cfg.Username = getEnvWithDefault("CLICKHOUSE_USERNAME", cfg.Username)
+    cfg.Password = configopaque.String(password)
+    cfg.Database = getEnvWithDefault("CLICKHOUSE_DATABASE", cfg.Database)
+    return cfg, nil
 }
 
 func getEnvWithDefault(key, defaultValue string) string {
@@ -34,4 +493,4 @@ func getEnvWithDefault(key, defaultValue string) string {
         return value
     }
     return defaultValue
-}
\ No newline at end of file
+}
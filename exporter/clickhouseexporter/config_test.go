@@ -0,0 +1,271 @@
+// exporter/clickhouseexporter/config_test.go
+package clickhouseexporter
+
+import (
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.opentelemetry.io/collector/component"
+    "go.opentelemetry.io/collector/config/configopaque"
+    "go.opentelemetry.io/collector/config/configretry"
+    "go.opentelemetry.io/collector/confmap"
+    "go.opentelemetry.io/collector/confmap/confmaptest"
+    "go.opentelemetry.io/collector/exporter/exporterhelper"
+    "go.opentelemetry.io/collector/exporter/exporterqueue"
+)
+
+func TestUnmarshalDefaultConfig(t *testing.T) {
+    // The environment must not leak into the component configuration.
+    t.Setenv("CLICKHOUSE_ENDPOINT", "from-env:9000")
+
+    factory := NewFactory()
+    cfg := factory.CreateDefaultConfig()
+    require.NoError(t, confmap.New().Unmarshal(&cfg))
+    assert.Equal(t, factory.CreateDefaultConfig(), cfg)
+    assert.Empty(t, cfg.(*Config).Endpoint)
+    // The endpoint has no default, so the default config is invalid.
+    assert.Error(t, component.ValidateConfig(cfg))
+}
+
+func TestUnmarshalConfigRejectsUnsupportedHTTPSettings(t *testing.T) {
+    // Only proxy_url and headers apply to the http protocol, the other
+    // connection settings are configured for both protocols.
+    cm := confmap.NewFromStringMap(map[string]any{
+        "http": map[string]any{
+            "proxy_url": "http://proxy:3128",
+            "timeout":   "5s",
+        },
+    })
+    cfg := NewFactory().CreateDefaultConfig()
+    err := cm.Unmarshal(&cfg)
+    require.Error(t, err)
+    assert.Contains(t, err.Error(), "timeout")
+}
+
+func TestUnmarshalConfig(t *testing.T) {
+    cm, err := confmaptest.LoadConf(filepath.Join("testdata", "config.yaml"))
+    require.NoError(t, err)
+    factory := NewFactory()
+    cfg := factory.CreateDefaultConfig()
+    require.NoError(t, cm.Unmarshal(&cfg))
+
+    expected := factory.CreateDefaultConfig().(*Config)
+    expected.Endpoint = "clickhouse.example.com:9440"
+    expected.Username = "otel"
+    expected.Password = "s3cr3t"
+    expected.Database = "telemetry"
+    expected.Protocol = "http"
+    expected.HTTPSettings.ProxyURL = "http://proxy.example.com:3128"
+    expected.HTTPSettings.Headers = map[string]configopaque.String{"X-Tenant": "team-a"}
+    expected.Endpoints = []string{"clickhouse-2.example.com:9440"}
+    expected.ConnOpenStrategy = "round_robin"
+    expected.TLSSetting.CAFile = "/etc/clickhouse/ca.pem"
+    expected.TLSSetting.CertFile = "/etc/clickhouse/client.pem"
+    expected.TLSSetting.KeyFile = "/etc/clickhouse/client-key.pem"
+    expected.TLSSetting.ServerName = "clickhouse.internal"
+    expected.DialTimeout = 5 * time.Second
+    expected.MaxOpenConns = 20
+    expected.MaxIdleConns = 10
+    expected.ConnMaxLifetime = 30 * time.Minute
+    expected.AsyncInsert = true
+    expected.WaitForAsyncInsert = false
+    expected.InsertDeduplication = true
+    expected.CreateSchema = true
+    expected.Tables.Metrics = "otel_metrics"
+    expected.Tables.Traces = "spans"
+    expected.TableEngine = TableEngine{Name: "ReplicatedMergeTree", Params: "'/clickhouse/tables/{shard}/{table}', '{replica}'"}
+    expected.ClusterName = "main"
+    expected.TTL = 72 * time.Hour
+    expected.PartitionBy = "toDate(timestamp)"
+    expected.Compression = "zstd"
+    expected.Codecs = CodecsConfig{Timestamp: "DoubleDelta", Value: "Gorilla", ZSTDLevel: 1}
+    expected.Attributes = AttributesConfig{
+        Mode: "typed",
+        Promoted: []PromotedAttribute{
+            {Key: "http.request.method"},
+            {Key: "http.response.status_code", Type: "Int64"},
+        },
+    }
+    expected.Rollups = RollupsConfig{
+        Intervals: []time.Duration{time.Minute, time.Hour},
+        TTL:       365 * 24 * time.Hour,
+    }
+    expected.Tenancy = TenancyConfig{
+        ResourceAttribute: "tenant.id",
+        MetadataKey:       "x-tenant",
+        Allowed:           []string{"team_a", "team_b"},
+        Default:           "team_a",
+    }
+    expected.Cardinality = CardinalityConfig{
+        MaxSeries:   10000,
+        Metrics:     map[string]int{"http.server.duration": 50000, "debug.requests": 0},
+        Action:      "hash_labels",
+        HashBuckets: 32,
+        Interval:    30 * time.Minute,
+    }
+    expected.TimeoutConfig = exporterhelper.TimeoutConfig{Timeout: 10 * time.Second}
+    expected.BackOffConfig = configretry.BackOffConfig{
+        Enabled:             true,
+        InitialInterval:     10 * time.Second,
+        RandomizationFactor: 0.7,
+        Multiplier:          1.3,
+        MaxInterval:         1 * time.Minute,
+        MaxElapsedTime:      10 * time.Minute,
+    }
+    expected.QueueSettings = exporterhelper.QueueConfig{
+        Enabled:             true,
+        NumConsumers:        2,
+        QueueSize:           10,
+        AdaptiveConcurrency: exporterqueue.NewDefaultAdaptiveConcurrencyConfig(),
+    }
+    assert.Equal(t, expected, cfg)
+    assert.NoError(t, component.ValidateConfig(cfg))
+}
+
+func TestConfigValidate(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = "localhost:9000"
+    assert.NoError(t, cfg.Validate())
+
+    cfg.Tables.Logs = "logs; DROP TABLE otel.metrics"
+    assert.EqualError(t, cfg.Validate(), `tables::logs "logs; DROP TABLE otel.metrics" is not a valid identifier`)
+
+    cfg.Tables.Logs = "otel_logs"
+    cfg.TableEngine.Name = "Log"
+    assert.EqualError(t, cfg.Validate(), `table_engine::name "Log" is not supported, use MergeTree or ReplicatedMergeTree`)
+
+    cfg.TableEngine.Name = "MergeTree"
+    cfg.ConnOpenStrategy = "random"
+    assert.EqualError(t, cfg.Validate(), `conn_open_strategy "random" is not supported, use in_order or round_robin`)
+
+    cfg.ConnOpenStrategy = "round_robin"
+    cfg.Protocol = "grpc"
+    assert.EqualError(t, cfg.Validate(), `protocol "grpc" is not supported, use native or http`)
+
+    cfg.Protocol = "http"
+    cfg.HTTPSettings.ProxyURL = "socks5://proxy:1080"
+    assert.EqualError(t, cfg.Validate(), `http::proxy_url "socks5://proxy:1080" must be an http:// URL`)
+
+    cfg.HTTPSettings.ProxyURL = "http://proxy:3128"
+    cfg.Endpoint = ""
+    cfg.Endpoints = []string{"clickhouse-1:9000", "clickhouse-2:9000"}
+    assert.NoError(t, cfg.Validate())
+
+    cfg.Endpoints = nil
+    assert.EqualError(t, cfg.Validate(), "endpoint must be specified")
+
+    cfg.Endpoint = "localhost:9000"
+    cfg.Attributes.Mode = "json"
+    assert.EqualError(t, cfg.Validate(), `attributes::mode "json" is not supported, use string or typed`)
+
+    cfg.Attributes.Mode = "typed"
+    cfg.Attributes.Promoted = []PromotedAttribute{{Key: "http.method"}, {Key: "http_method"}}
+    assert.EqualError(t, cfg.Validate(), `attributes::promoted keys "http.method" and "http_method" both map to column attr_http_method`)
+
+    cfg.Attributes.Promoted = []PromotedAttribute{{Key: "http.status_code", Type: "UInt16"}}
+    assert.EqualError(t, cfg.Validate(), `attributes::promoted type "UInt16" of "http.status_code" is not supported, use String, Int64, Float64 or Bool`)
+
+    cfg.Attributes.Promoted = nil
+    cfg.Rollups.Intervals = []time.Duration{time.Minute, 90 * time.Second}
+    assert.EqualError(t, cfg.Validate(), "rollups::intervals 1m30s must be a positive whole number of minutes")
+
+    cfg.Rollups.Intervals = []time.Duration{time.Hour, time.Hour}
+    assert.EqualError(t, cfg.Validate(), "rollups::intervals 1h0m0s is listed twice")
+
+    cfg.Rollups.Intervals = nil
+    cfg.Compression = "snappy"
+    assert.EqualError(t, cfg.Validate(), `compression "snappy" is not supported with protocol http`)
+
+    cfg.Protocol = "native"
+    cfg.Compression = "gzip"
+    assert.EqualError(t, cfg.Validate(), `compression "gzip" is not supported with protocol native`)
+
+    cfg.Compression = "zstd"
+    cfg.Codecs.Value = "T64"
+    assert.EqualError(t, cfg.Validate(), `codecs::value "T64" is not supported, use one of Delta, DoubleDelta, Gorilla`)
+
+    cfg.Codecs.Value = "Gorilla"
+    cfg.Codecs.ZSTDLevel = -1
+    assert.EqualError(t, cfg.Validate(), "codecs::zstd_level -1 must be between 0 (server default) and 22")
+
+    cfg.Codecs.ZSTDLevel = 23
+    assert.EqualError(t, cfg.Validate(), "codecs::zstd_level 23 must be between 0 (server default) and 22")
+
+    // The bounds are valid levels.
+    for _, level := range []int{0, 1, 22} {
+        cfg.Codecs.ZSTDLevel = level
+        assert.NoError(t, cfg.Validate(), "level %d", level)
+    }
+
+    cfg.Codecs.ZSTDLevel = 3
+    cfg.Tenancy.Allowed = []string{"team_a"}
+    assert.EqualError(t, cfg.Validate(), "tenancy::allowed and tenancy::default require tenancy::resource_attribute or tenancy::metadata_key")
+
+    cfg.Tenancy.ResourceAttribute = "tenant.id"
+    cfg.Tenancy.Allowed = []string{"team-a"}
+    assert.EqualError(t, cfg.Validate(), `tenancy::allowed tenant "team-a" may only contain letters, digits and underscores`)
+
+    cfg.Tenancy.Allowed = []string{"team_a"}
+    cfg.Tenancy.Default = "team_b"
+    assert.EqualError(t, cfg.Validate(), `tenancy::default "team_b" is not an allowed tenant`)
+
+    cfg.Tenancy.Default = ""
+    cfg.Tenancy.MetadataKey = "x-tenant"
+    storage := component.MustNewID("file_storage")
+    cfg.QueueSettings.StorageID = &storage
+    assert.EqualError(t, cfg.Validate(), "tenancy::metadata_key cannot be used with a persistent sending_queue")
+
+    cfg.QueueSettings.StorageID = nil
+    cfg.QueueSettings.SpilloverStorageID = &storage
+    assert.EqualError(t, cfg.Validate(), "tenancy::metadata_key cannot be used with sending_queue::spillover_storage")
+
+    cfg.QueueSettings.SpilloverStorageID = nil
+    cfg.Cardinality.MaxSeries = -1
+    assert.EqualError(t, cfg.Validate(), "cardinality::max_series must not be negative")
+
+    cfg.Cardinality.MaxSeries = 1000
+    cfg.Cardinality.Interval = -time.Minute
+    assert.EqualError(t, cfg.Validate(), "cardinality::interval must not be negative")
+
+    cfg.Cardinality.Interval = time.Hour
+    cfg.Cardinality.Metrics = map[string]int{"http.requests": -1}
+    assert.EqualError(t, cfg.Validate(), `cardinality::metrics limit of "http.requests" must not be negative`)
+
+    cfg.Cardinality.Metrics = nil
+    cfg.Cardinality.Action = "sample"
+    assert.EqualError(t, cfg.Validate(), `cardinality::action "sample" is not supported, use drop_labels, hash_labels or reject`)
+
+    cfg.Cardinality.Action = "hash_labels"
+    cfg.Cardinality.HashBuckets = 0
+    assert.EqualError(t, cfg.Validate(), "cardinality::hash_buckets must be positive")
+
+    cfg.Cardinality.HashBuckets = 16
+    assert.NoError(t, cfg.Validate())
+
+    cfg.Database = ""
+    assert.EqualError(t, cfg.Validate(), "database must be specified")
+}
+
+func TestNewConfigFromEnvironment(t *testing.T) {
+    t.Setenv("CLICKHOUSE_ENDPOINT", "")
+    t.Setenv("CLICKHOUSE_PASSWORD", "s3cr3t")
+    _, err := NewConfig()
+    assert.EqualError(t, err, "CLICKHOUSE_ENDPOINT environment variable is not set")
+
+    t.Setenv("CLICKHOUSE_ENDPOINT", "clickhouse.example.com:9440")
+    t.Setenv("CLICKHOUSE_USERNAME", "otel")
+    t.Setenv("CLICKHOUSE_DATABASE", "")
+    cfg, err := NewConfig()
+    require.NoError(t, err)
+    assert.Equal(t, "clickhouse.example.com:9440", cfg.Endpoint)
+    assert.Equal(t, "otel", cfg.Username)
+    assert.Equal(t, configopaque.String("s3cr3t"), cfg.Password)
+    assert.Equal(t, "otel", cfg.Database)
+
+    t.Setenv("CLICKHOUSE_PASSWORD", "")
+    _, err = NewConfig()
+    assert.EqualError(t, err, "CLICKHOUSE_PASSWORD environment variable is not set")
+}
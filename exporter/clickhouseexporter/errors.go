@@ -0,0 +1,53 @@
+// exporter/clickhouseexporter/errors.go
+package clickhouseexporter
+
+import (
+    "errors"
+
+    "github.com/ClickHouse/clickhouse-go/v2"
+    "github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+    "go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+// permanentErrorCodes are ClickHouse server error codes caused by a mismatch
+// between the data and the table schema. Sending the same data again cannot
+// succeed, so these are not retried.
+var permanentErrorCodes = map[int32]struct{}{
+    16:  {}, // NO_SUCH_COLUMN_IN_TABLE
+    27:  {}, // CANNOT_PARSE_INPUT_ASSERTION_FAILED
+    41:  {}, // CANNOT_PARSE_DATETIME
+    43:  {}, // ILLEGAL_TYPE_OF_ARGUMENT
+    47:  {}, // UNKNOWN_IDENTIFIER
+    53:  {}, // TYPE_MISMATCH
+    60:  {}, // UNKNOWN_TABLE
+    62:  {}, // SYNTAX_ERROR
+    70:  {}, // CANNOT_CONVERT_TYPE
+    81:  {}, // UNKNOWN_DATABASE
+    117: {}, // INCORRECT_DATA
+}
+
+// classifyError marks schema and type errors as permanent so that the
+// exporterhelper retry sender drops the data instead of retrying it. Every
+// other error, such as network failures, timeouts or an overloaded server,
+// is returned as is and retried.
+func classifyError(err error) error {
+    if err == nil {
+        return nil
+    }
+
+    var exception *clickhouse.Exception
+    if errors.As(err, &exception) {
+        if _, ok := permanentErrorCodes[exception.Code]; ok {
+            return consumererror.NewPermanent(err)
+        }
+        return err
+    }
+
+    // The client failed to convert a value into its column type.
+    var blockErr *proto.BlockError
+    if errors.As(err, &blockErr) {
+        return consumererror.NewPermanent(err)
+    }
+
+    return err
+}
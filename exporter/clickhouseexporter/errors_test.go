@@ -0,0 +1,56 @@
+// exporter/clickhouseexporter/errors_test.go
+package clickhouseexporter
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+
+    "github.com/ClickHouse/clickhouse-go/v2"
+    "github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+    "github.com/stretchr/testify/assert"
+    "go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+func TestClassifyError(t *testing.T) {
+    tests := []struct {
+        name      string
+        err       error
+        permanent bool
+    }{
+        {
+            name:      "unknown table",
+            err:       fmt.Errorf("failed to prepare batch: %w", &clickhouse.Exception{Code: 60, Name: "DB::Exception"}),
+            permanent: true,
+        },
+        {
+            name:      "type mismatch",
+            err:       &clickhouse.Exception{Code: 53},
+            permanent: true,
+        },
+        {
+            name:      "too many parts",
+            err:       &clickhouse.Exception{Code: 252},
+            permanent: false,
+        },
+        {
+            name:      "column conversion",
+            err:       fmt.Errorf("failed to insert metric m: %w", &proto.BlockError{Op: "AppendRow", Err: errors.New("converting string to Float64 is unsupported")}),
+            permanent: true,
+        },
+        {
+            name:      "network",
+            err:       errors.New("dial tcp 127.0.0.1:9000: connect: connection refused"),
+            permanent: false,
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := classifyError(tt.err)
+            assert.ErrorIs(t, err, tt.err)
+            assert.Equal(t, tt.permanent, consumererror.IsPermanent(err))
+        })
+    }
+
+    assert.NoError(t, classifyError(nil))
+}
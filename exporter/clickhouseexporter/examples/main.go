@@ -67,8 +67,14 @@ func init() {
 func main() {
     ctx := context.Background()
     
+    // Build the configuration from the environment
+    cfg, err := clickhouseexporter.NewConfig()
+    if err != nil {
+        log.Fatalf("Failed to load configuration: %v", err)
+    }
+
     // Create the exporter
-    exp, err := clickhouseexporter.NewClickHouseExporter(ctx)
+    exp, err := clickhouseexporter.NewClickHouseExporter(ctx, cfg)
     if err != nil {
         log.Fatalf("Failed to create exporter: %v", err)
     }
@@ -87,26 +93,17 @@ func main() {
     // Add a delay to ensure metrics are written
     time.Sleep(time.Second * 2)
 
-    // Get configuration from environment variables
-    endpoint := os.Getenv("CLICKHOUSE_ENDPOINT")
-    username := os.Getenv("CLICKHOUSE_USERNAME")
-    password := os.Getenv("CLICKHOUSE_PASSWORD")
-    database := os.Getenv("CLICKHOUSE_DATABASE")
-
-    // Validate required environment variables
-    if endpoint == "" || username == "" || password == "" || database == "" {
-        log.Fatal("Missing required environment variables")
-    }
+    database := cfg.Database
 
     // Verify the inserted data
     conn := clickhouse.OpenDB(&clickhouse.Options{
-        Addr: []string{endpoint},
+        Addr: []string{cfg.Endpoint},
         Protocol: clickhouse.Native,
         TLS: &tls.Config{},
         Auth: clickhouse.Auth{
             Database: database,
-            Username: username,
-            Password: password,
+            Username: cfg.Username,
+            Password: string(cfg.Password),
         },
     })
 
@@ -4,87 +4,184 @@ package clickhouseexporter
 import (
     "context"
     "fmt"
+    "sync"
     "time"
-    "crypto/tls"
-    "database/sql"
-    "github.com/joho/godotenv"
-    "os"
+
     "github.com/ClickHouse/clickhouse-go/v2"
     "go.opentelemetry.io/collector/component"
     "go.opentelemetry.io/collector/consumer"
+    "go.opentelemetry.io/collector/consumer/consumererror"
     "go.opentelemetry.io/collector/exporter"
     "go.opentelemetry.io/collector/pdata/pcommon"
     "go.opentelemetry.io/collector/pdata/pmetric"
+    metricnoop "go.opentelemetry.io/otel/metric/noop"
+    tracenoop "go.opentelemetry.io/otel/trace/noop"
     "go.uber.org/zap"
 )
 
 type clickhouseExporter struct {
-    cfg    *Config
-    db     *sql.DB
-    logger *zap.Logger
+    cfg         *Config
+    conn        dbConn
+    // open opens the connection in start. Tests replace it with a fake.
+    open        func(*clickhouse.Options) (dbConn, error)
+    logger      *zap.Logger
+    telemetry   *telemetry
+    status      statusReporter
+    tenants     tenantRouter
+    attributes  attributeConverter
+    // cardinality is nil when no metric has a cardinality limit.
+    cardinality *cardinalityLimiter
+    // sent remembers the tables written by the payloads that partially failed.
+    sent        *sentTables
+
+    // schemaMu guards schemaPending, which is set when ClickHouse was not
+    // reachable on start and the schema still has to be created.
+    schemaMu      sync.Mutex
+    schemaPending bool
 }
 
-func init() {
-    // Load .env file
-    if err := godotenv.Load(); err != nil {
-        // It's okay if .env doesn't exist in production
-        fmt.Printf("Note: .env file not found. Using environment variables.\n")
+// insertQueries holds the INSERT statements for the configured database and
+// table names.
+type insertQueries struct {
+    // tenant is the tenant the statements write to, empty for the
+    // configured database.
+    tenant               string
+    metrics              insertQuery
+    histogram            insertQuery
+    exponentialHistogram insertQuery
+    summary              insertQuery
+    traces               insertQuery
+    logs                 insertQuery
+    profileSamples       insertQuery
+    profileLocations     insertQuery
+    profileFunctions     insertQuery
+    profileMappings      insertQuery
+}
+
+func newInsertQueries(s schema, attributes attributeConverter) insertQueries {
+    promoted := attributes.promotedColumns()
+    query := func(template, name string, args ...any) insertQuery {
+        table := s.table(name)
+        return insertQuery{table: table, sql: fmt.Sprintf(template, append([]any{table}, args...)...)}
+    }
+    return insertQueries{
+        metrics:              query(insertMetricsSQL, s.tables.Metrics, promoted),
+        histogram:            query(insertHistogramSQL, s.tables.MetricsHistogram, promoted),
+        exponentialHistogram: query(insertExponentialHistogramSQL, s.tables.MetricsExponentialHistogram, promoted),
+        summary:              query(insertSummarySQL, s.tables.MetricsSummary, promoted),
+        traces:               query(insertTracesSQL, s.tables.Traces),
+        logs:                 query(insertLogsSQL, s.tables.Logs),
+        profileSamples:       query(insertProfileSamplesSQL, s.tables.ProfileSamples),
+        profileLocations:     query(insertProfileLocationsSQL, s.tables.ProfileLocations),
+        profileFunctions:     query(insertProfileFunctionsSQL, s.tables.ProfileFunctions),
+        profileMappings:      query(insertProfileMappingsSQL, s.tables.ProfileMappings),
     }
 }
 
 // NewClickHouseExporter creates a new instance of clickhouseExporter for standalone usage
-func NewClickHouseExporter(ctx context.Context) (exporter.Metrics, error) {
-    logger, _ := zap.NewProduction()
-
-    // Get configuration from environment variables
-    endpoint := os.Getenv("CLICKHOUSE_ENDPOINT")
-    username := os.Getenv("CLICKHOUSE_USERNAME")
-    password := os.Getenv("CLICKHOUSE_PASSWORD")
-    database := os.Getenv("CLICKHOUSE_DATABASE")
-
-    // Validate required environment variables
-    if endpoint == "" || username == "" || password == "" {
-        return nil, fmt.Errorf("missing required environment variables: CLICKHOUSE_ENDPOINT, CLICKHOUSE_USERNAME, CLICKHOUSE_PASSWORD")
-    }
-
-    if database == "" {
-        database = "otel" // default database name
-    }
-
-    // Connect using the native protocol
-    db := clickhouse.OpenDB(&clickhouse.Options{
-        Addr: []string{endpoint},
-        Protocol: clickhouse.Native,
-        TLS: &tls.Config{},
-        Auth: clickhouse.Auth{
-            Database: database,
-            Username: username,
-            Password: password,
-        },
-        Debug: true,
-        Settings: clickhouse.Settings{
-            "max_execution_time": 60,
-        },
-        Compression: &clickhouse.Compression{
-            Method: clickhouse.CompressionLZ4,
-        },
-    })
+func NewClickHouseExporter(ctx context.Context, cfg *Config) (exporter.Metrics, error) {
+    if err := cfg.Validate(); err != nil {
+        return nil, err
+    }
+    logger, err := zap.NewProduction()
+    if err != nil {
+        return nil, err
+    }
+    exp, err := newClickHouseExporter(component.TelemetrySettings{
+        Logger:         logger,
+        TracerProvider: tracenoop.NewTracerProvider(),
+        MeterProvider:  metricnoop.NewMeterProvider(),
+    }, cfg)
+    if err != nil {
+        return nil, err
+    }
+    if err := exp.start(ctx, nil); err != nil {
+        return nil, err
+    }
+    return exp, nil
+}
+
+// newClickHouseExporter returns an exporter that can serve any of the
+// supported signals. The connection is opened by start.
+func newClickHouseExporter(set component.TelemetrySettings, cfg *Config) (*clickhouseExporter, error) {
+    telemetry, err := newTelemetry(set)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create telemetry: %w", err)
+    }
+
+    attributes := newAttributeConverter(cfg.Attributes)
+    return &clickhouseExporter{
+        cfg:         cfg,
+        open:        openConn,
+        logger:      set.Logger,
+        telemetry:   telemetry,
+        tenants:     newTenantRouter(cfg, attributes),
+        attributes:  attributes,
+        cardinality: newCardinalityLimiter(cfg.Cardinality, set.Logger, telemetry),
+        sent:        newSentTables(),
+    }, nil
+}
+
+// start connects to ClickHouse and checks that the server is reachable.
+// The host, which is nil for standalone usage, receives the status events
+// reported while exporting. A server that is not reachable yet does not fail
+// start: a recoverable error is reported and the exports fail, and are
+// retried, until it is back.
+func (e *clickhouseExporter) start(ctx context.Context, host component.Host) error {
+    e.status.setHost(host)
+
+    opts, err := clientOptions(ctx, e.cfg)
+    if err != nil {
+        return err
+    }
+
+    conn, err := e.open(opts)
+    if err != nil {
+        return err
+    }
+
+    e.conn = conn
 
     // Test the connection
-    if err := db.Ping(); err != nil {
-        return nil, fmt.Errorf("failed to ping ClickHouse: %w", err)
+    if err := conn.Ping(ctx); err != nil {
+        err = fmt.Errorf("failed to ping ClickHouse: %w", err)
+        e.logger.Warn("ClickHouse is not reachable, exports are retried until it is",
+            zap.Strings("endpoints", opts.Addr),
+            zap.Error(err),
+        )
+        e.status.report(err)
+        e.schemaPending = e.cfg.CreateSchema
+        return nil
     }
 
-    logger.Info("Successfully connected to ClickHouse",
-        zap.String("endpoint", endpoint),
-        zap.String("database", database),
-        zap.String("username", username),
+    e.logger.Info("Successfully connected to ClickHouse",
+        zap.Strings("endpoints", opts.Addr),
+        zap.String("protocol", e.cfg.Protocol),
+        zap.String("database", e.cfg.Database),
+        zap.String("username", e.cfg.Username),
     )
 
-    return &clickhouseExporter{
-        db:     db,
-        logger: logger,
-    }, nil
+    if e.cfg.CreateSchema {
+        if err := e.createSchema(ctx); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// ensureSchema creates the schema before the first export when ClickHouse
+// was not reachable on start.
+func (e *clickhouseExporter) ensureSchema(ctx context.Context) error {
+    e.schemaMu.Lock()
+    defer e.schemaMu.Unlock()
+    if !e.schemaPending {
+        return nil
+    }
+    if err := e.createSchema(ctx); err != nil {
+        return e.fail(fmt.Errorf("failed to create schema: %w", err))
+    }
+    e.schemaPending = false
+    return nil
 }
 
 // Capabilities implements the consumer.Capabilities interface.
@@ -97,25 +194,51 @@ func (e *clickhouseExporter) Start(_ context.Context, _ component.Host) error {
     return nil
 }
 
+const insertMetricsSQL = `
+    INSERT INTO %s (
+        timestamp,
+        metric_name,
+        metric_type,
+        value,
+        labels,
+        service_name,
+        host_name,
+        metric_description,
+        metric_unit,
+        resource_attributes,
+        resource_schema_url,
+        scope_name,
+        scope_version,
+        scope_attributes,
+        scope_schema_url,
+        start_time_unix,
+        flags,
+        is_monotonic,
+        temporality,
+        exemplars.filtered_attributes,
+        exemplars.timestamp,
+        exemplars.value,
+        exemplars.span_id,
+        exemplars.trace_id,
+        labels_int,
+        labels_float,
+        labels_bool%s
+    )
+`
+
 func (e *clickhouseExporter) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+    if err := e.ensureSchema(ctx); err != nil {
+        return err
+    }
     metrics := md.ResourceMetrics()
 
-    // Prepare the query
-    stmt, err := e.db.Prepare(`
-        INSERT INTO otel.metrics (
-            timestamp,
-            metric_name,
-            metric_type,
-            value,
-            labels,
-            service_name,
-            host_name
-        ) VALUES (?, ?, ?, ?, ?, ?, ?)
-    `)
+    token, err := e.deduplicationToken(func() ([]byte, error) {
+        return (&pmetric.ProtoMarshaler{}).MarshalMetrics(md)
+    })
     if err != nil {
-        return fmt.Errorf("failed to prepare statement: %w", err)
+        return err
     }
-    defer stmt.Close()
+    batches := newBatchSet(ctx, e.conn, e.telemetry, e.sent, token, e.cfg.InsertDeduplication)
 
     for i := 0; i < metrics.Len(); i++ {
         rm := metrics.At(i)
@@ -128,71 +251,142 @@ func (e *clickhouseExporter) ConsumeMetrics(ctx context.Context, md pmetric.Metr
         if hostAttr, ok := resource.Attributes().Get("host.name"); ok {
             hostName = hostAttr.Str()
         }
+        resourceAttributes := attributesToMap(resource.Attributes())
+        queries := e.tenants.route(ctx, resource.Attributes())
 
         ilm := rm.ScopeMetrics()
         for j := 0; j < ilm.Len(); j++ {
-            ilMetrics := ilm.At(j).Metrics()
+            sm := ilm.At(j)
+            scope := sm.Scope()
+            scopeAttributes := attributesToMap(scope.Attributes())
+            ilMetrics := sm.Metrics()
             
             for k := 0; k < ilMetrics.Len(); k++ {
                 metric := ilMetrics.At(k)
+                meta := metricMeta{
+                    name:               metric.Name(),
+                    description:        metric.Description(),
+                    unit:               metric.Unit(),
+                    serviceName:        serviceName,
+                    hostName:           hostName,
+                    resourceAttributes: resourceAttributes,
+                    resourceSchemaURL:  rm.SchemaUrl(),
+                    scopeName:          scope.Name(),
+                    scopeVersion:       scope.Version(),
+                    scopeAttributes:    scopeAttributes,
+                    scopeSchemaURL:     sm.SchemaUrl(),
+                    queries:            queries,
+                }
                 
                 switch metric.Type() {
                 case pmetric.MetricTypeGauge:
-                    if err := e.exportDataPoints(ctx, stmt, metric.Gauge().DataPoints(), metric.Name(), "gauge", serviceName, hostName); err != nil {
-                        e.logger.Error("Failed to export gauge metric", zap.Error(err))
+                    if err := e.exportDataPoints(batches, metric.Gauge().DataPoints(), meta, "gauge", false, pmetric.AggregationTemporalityUnspecified); err != nil {
+                        return e.fail(err)
                     }
 
                 case pmetric.MetricTypeSum:
-                    if err := e.exportDataPoints(ctx, stmt, metric.Sum().DataPoints(), metric.Name(), "sum", serviceName, hostName); err != nil {
-                        e.logger.Error("Failed to export sum metric", zap.Error(err))
+                    sum := metric.Sum()
+                    if err := e.exportDataPoints(batches, sum.DataPoints(), meta, "sum", sum.IsMonotonic(), sum.AggregationTemporality()); err != nil {
+                        return e.fail(err)
                     }
 
                 case pmetric.MetricTypeHistogram:
-                    dp := metric.Histogram().DataPoints()
-                    for l := 0; l < dp.Len(); l++ {
-                        point := dp.At(l)
-                        labels := attributesToMap(point.Attributes())
-                        
-                        if point.Count() > 0 {
-                            value := point.Sum() / float64(point.Count())
-                            
-                            _, err := stmt.ExecContext(ctx,
-                                time.Unix(0, int64(point.Timestamp())).UTC(),
-                                metric.Name(),
-                                "histogram",
-                                value,
-                                labels,
-                                serviceName,
-                                hostName,
-                            )
-                            if err != nil {
-                                e.logger.Error("Failed to insert histogram metric",
-                                    zap.Error(err),
-                                    zap.String("metric", metric.Name()),
-                                )
-                            }
-                        }
+                    histogram := metric.Histogram()
+                    if err := e.exportHistogramDataPoints(batches, histogram.DataPoints(), meta, histogram.AggregationTemporality()); err != nil {
+                        return e.fail(err)
+                    }
+
+                case pmetric.MetricTypeExponentialHistogram:
+                    histogram := metric.ExponentialHistogram()
+                    if err := e.exportExponentialHistogramDataPoints(batches, histogram.DataPoints(), meta, histogram.AggregationTemporality()); err != nil {
+                        return e.fail(err)
+                    }
+
+                case pmetric.MetricTypeSummary:
+                    if err := e.exportSummaryDataPoints(batches, metric.Summary().DataPoints(), meta); err != nil {
+                        return e.fail(err)
                     }
                 }
             }
         }
     }
 
+    // Each table receives the whole payload as a single block.
+    return e.finish(ctx, batches, signalMetrics, func() int {
+        return (&pmetric.ProtoMarshaler{}).MetricsSize(md)
+    })
+}
+
+const (
+    signalMetrics  = "metrics"
+    signalTraces   = "traces"
+    signalLogs     = "logs"
+    signalProfiles = "profiles"
+)
+
+// finish sends the batches of a payload, records its size when it was
+// written and reports the health of the connection.
+func (e *clickhouseExporter) finish(ctx context.Context, batches *batchSet, signal string, size func() int) error {
+    if err := batches.send(); err != nil {
+        return e.fail(err)
+    }
+    e.status.report(nil)
+    e.telemetry.recordBytes(ctx, signal, size())
     return nil
 }
 
+// deduplicationToken returns the token of the payload encoded by marshal.
+// It identifies the payload when it is retried, and is only sent to the
+// server when insert deduplication is enabled.
+func (e *clickhouseExporter) deduplicationToken(marshal func() ([]byte, error)) (string, error) {
+    payload, err := marshal()
+    if err != nil {
+        return "", consumererror.NewPermanent(fmt.Errorf("failed to encode payload: %w", err))
+    }
+    return deduplicationToken(payload), nil
+}
+
+// fail classifies an export error and reports it to the host unless it is
+// caused by the data.
+func (e *clickhouseExporter) fail(err error) error {
+    err = classifyError(err)
+    e.status.report(err)
+    return err
+}
+
+// metricMeta holds the metric, resource and scope fields shared by every
+// data point of a metric.
+type metricMeta struct {
+    name               string
+    description        string
+    unit               string
+    serviceName        string
+    hostName           string
+    resourceAttributes map[string]string
+    resourceSchemaURL  string
+    scopeName          string
+    scopeVersion       string
+    scopeAttributes    map[string]string
+    scopeSchemaURL     string
+    // queries are the insert statements of the tenant of the resource.
+    queries            insertQueries
+}
+
 func (e *clickhouseExporter) exportDataPoints(
-    ctx context.Context,
-    stmt *sql.Stmt,
+    batches *batchSet,
     dp pmetric.NumberDataPointSlice,
-    metricName string,
+    meta metricMeta,
     metricType string,
-    serviceName string,
-    hostName string,
+    isMonotonic bool,
+    temporality pmetric.AggregationTemporality,
 ) error {
     for i := 0; i < dp.Len(); i++ {
         point := dp.At(i)
-        labels := attributesToMap(point.Attributes())
+        labels, ok := e.labels(batches.ctx, meta, point.Attributes())
+        if !ok {
+            continue
+        }
+        ex := convertExemplars(point.Exemplars())
         
         var value float64
 
@@ -203,22 +397,55 @@ func (e *clickhouseExporter) exportDataPoints(
             value = float64(point.IntValue())
         }
 
-        _, err := stmt.ExecContext(ctx,
+        args := []any{
             time.Unix(0, int64(point.Timestamp())).UTC(),
-            metricName,
+            meta.name,
             metricType,
             value,
-            labels,
-            serviceName,
-            hostName,
-        )
-        if err != nil {
-            return fmt.Errorf("failed to insert metric %s: %w", metricName, err)
+            labels.strings,
+            meta.serviceName,
+            meta.hostName,
+            meta.description,
+            meta.unit,
+            meta.resourceAttributes,
+            meta.resourceSchemaURL,
+            meta.scopeName,
+            meta.scopeVersion,
+            meta.scopeAttributes,
+            meta.scopeSchemaURL,
+            time.Unix(0, int64(point.StartTimestamp())).UTC(),
+            uint32(point.Flags()),
+            isMonotonic,
+            temporalityName(temporality),
+            ex.attributes,
+            ex.timestamps,
+            ex.values,
+            ex.spanIDs,
+            ex.traceIDs,
+            labels.ints,
+            labels.floats,
+            labels.bools,
+        }
+        args = append(args, e.attributes.promotedValues(point.Attributes())...)
+        if err := batches.append(meta.queries.metrics, args...); err != nil {
+            return fmt.Errorf("failed to insert metric %s: %w", meta.name, err)
         }
     }
     return nil
 }
 
+// temporalityName returns the value of the temporality enum column.
+func temporalityName(temporality pmetric.AggregationTemporality) string {
+    switch temporality {
+    case pmetric.AggregationTemporalityDelta:
+        return "delta"
+    case pmetric.AggregationTemporalityCumulative:
+        return "cumulative"
+    default:
+        return "unspecified"
+    }
+}
+
 func attributesToMap(attrs pcommon.Map) map[string]string {
     result := make(map[string]string)
     attrs.Range(func(k string, v pcommon.Value) bool {
@@ -229,8 +456,8 @@ func attributesToMap(attrs pcommon.Map) map[string]string {
 }
 
 func (e *clickhouseExporter) Shutdown(ctx context.Context) error {
-    if e.db != nil {
-        return e.db.Close()
+    if e.conn != nil {
+        return e.conn.Close()
     }
     return nil
 }
\ No newline at end of file
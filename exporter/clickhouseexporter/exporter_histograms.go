@@ -0,0 +1,337 @@
+// exporter/clickhouseexporter/exporter_histograms.go
+package clickhouseexporter
+
+import (
+    "fmt"
+    "time"
+
+    "go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+const insertHistogramSQL = `
+    INSERT INTO %s (
+        timestamp,
+        metric_name,
+        labels,
+        service_name,
+        host_name,
+        count,
+        sum,
+        min,
+        max,
+        bucket_counts,
+        explicit_bounds,
+        exemplars.filtered_attributes,
+        exemplars.timestamp,
+        exemplars.value,
+        exemplars.span_id,
+        exemplars.trace_id,
+        metric_description,
+        metric_unit,
+        resource_attributes,
+        resource_schema_url,
+        scope_name,
+        scope_version,
+        scope_attributes,
+        scope_schema_url,
+        start_time_unix,
+        flags,
+        temporality,
+        labels_int,
+        labels_float,
+        labels_bool%s
+    )
+`
+
+const insertExponentialHistogramSQL = `
+    INSERT INTO %s (
+        timestamp,
+        metric_name,
+        labels,
+        service_name,
+        host_name,
+        count,
+        sum,
+        min,
+        max,
+        scale,
+        zero_count,
+        positive_offset,
+        positive_bucket_counts,
+        negative_offset,
+        negative_bucket_counts,
+        exemplars.filtered_attributes,
+        exemplars.timestamp,
+        exemplars.value,
+        exemplars.span_id,
+        exemplars.trace_id,
+        metric_description,
+        metric_unit,
+        resource_attributes,
+        resource_schema_url,
+        scope_name,
+        scope_version,
+        scope_attributes,
+        scope_schema_url,
+        start_time_unix,
+        flags,
+        temporality,
+        labels_int,
+        labels_float,
+        labels_bool%s
+    )
+`
+
+const insertSummarySQL = `
+    INSERT INTO %s (
+        timestamp,
+        metric_name,
+        labels,
+        service_name,
+        host_name,
+        count,
+        sum,
+        quantiles.quantile,
+        quantiles.value,
+        metric_description,
+        metric_unit,
+        resource_attributes,
+        resource_schema_url,
+        scope_name,
+        scope_version,
+        scope_attributes,
+        scope_schema_url,
+        start_time_unix,
+        flags,
+        labels_int,
+        labels_float,
+        labels_bool%s
+    )
+`
+
+func (e *clickhouseExporter) exportHistogramDataPoints(
+    batches *batchSet,
+    dp pmetric.HistogramDataPointSlice,
+    meta metricMeta,
+    temporality pmetric.AggregationTemporality,
+) error {
+    for i := 0; i < dp.Len(); i++ {
+        point := dp.At(i)
+        ex := convertExemplars(point.Exemplars())
+
+        var sum, minValue, maxValue *float64
+        if point.HasSum() {
+            sum = ptr(point.Sum())
+        }
+        if point.HasMin() {
+            minValue = ptr(point.Min())
+        }
+        if point.HasMax() {
+            maxValue = ptr(point.Max())
+        }
+
+        labels, ok := e.labels(batches.ctx, meta, point.Attributes())
+        if !ok {
+            continue
+        }
+        args := []any{
+            time.Unix(0, int64(point.Timestamp())).UTC(),
+            meta.name,
+            labels.strings,
+            meta.serviceName,
+            meta.hostName,
+            point.Count(),
+            sum,
+            minValue,
+            maxValue,
+            point.BucketCounts().AsRaw(),
+            point.ExplicitBounds().AsRaw(),
+            ex.attributes,
+            ex.timestamps,
+            ex.values,
+            ex.spanIDs,
+            ex.traceIDs,
+            meta.description,
+            meta.unit,
+            meta.resourceAttributes,
+            meta.resourceSchemaURL,
+            meta.scopeName,
+            meta.scopeVersion,
+            meta.scopeAttributes,
+            meta.scopeSchemaURL,
+            time.Unix(0, int64(point.StartTimestamp())).UTC(),
+            uint32(point.Flags()),
+            temporalityName(temporality),
+            labels.ints,
+            labels.floats,
+            labels.bools,
+        }
+        args = append(args, e.attributes.promotedValues(point.Attributes())...)
+        if err := batches.append(meta.queries.histogram, args...); err != nil {
+            return fmt.Errorf("failed to insert histogram metric %s: %w", meta.name, err)
+        }
+    }
+    return nil
+}
+
+func (e *clickhouseExporter) exportExponentialHistogramDataPoints(
+    batches *batchSet,
+    dp pmetric.ExponentialHistogramDataPointSlice,
+    meta metricMeta,
+    temporality pmetric.AggregationTemporality,
+) error {
+    for i := 0; i < dp.Len(); i++ {
+        point := dp.At(i)
+        ex := convertExemplars(point.Exemplars())
+
+        var sum, minValue, maxValue *float64
+        if point.HasSum() {
+            sum = ptr(point.Sum())
+        }
+        if point.HasMin() {
+            minValue = ptr(point.Min())
+        }
+        if point.HasMax() {
+            maxValue = ptr(point.Max())
+        }
+
+        labels, ok := e.labels(batches.ctx, meta, point.Attributes())
+        if !ok {
+            continue
+        }
+        args := []any{
+            time.Unix(0, int64(point.Timestamp())).UTC(),
+            meta.name,
+            labels.strings,
+            meta.serviceName,
+            meta.hostName,
+            point.Count(),
+            sum,
+            minValue,
+            maxValue,
+            point.Scale(),
+            point.ZeroCount(),
+            point.Positive().Offset(),
+            point.Positive().BucketCounts().AsRaw(),
+            point.Negative().Offset(),
+            point.Negative().BucketCounts().AsRaw(),
+            ex.attributes,
+            ex.timestamps,
+            ex.values,
+            ex.spanIDs,
+            ex.traceIDs,
+            meta.description,
+            meta.unit,
+            meta.resourceAttributes,
+            meta.resourceSchemaURL,
+            meta.scopeName,
+            meta.scopeVersion,
+            meta.scopeAttributes,
+            meta.scopeSchemaURL,
+            time.Unix(0, int64(point.StartTimestamp())).UTC(),
+            uint32(point.Flags()),
+            temporalityName(temporality),
+            labels.ints,
+            labels.floats,
+            labels.bools,
+        }
+        args = append(args, e.attributes.promotedValues(point.Attributes())...)
+        if err := batches.append(meta.queries.exponentialHistogram, args...); err != nil {
+            return fmt.Errorf("failed to insert exponential histogram metric %s: %w", meta.name, err)
+        }
+    }
+    return nil
+}
+
+func (e *clickhouseExporter) exportSummaryDataPoints(
+    batches *batchSet,
+    dp pmetric.SummaryDataPointSlice,
+    meta metricMeta,
+) error {
+    for i := 0; i < dp.Len(); i++ {
+        point := dp.At(i)
+        quantiles := point.QuantileValues()
+
+        qs := make([]float64, 0, quantiles.Len())
+        values := make([]float64, 0, quantiles.Len())
+        for j := 0; j < quantiles.Len(); j++ {
+            qs = append(qs, quantiles.At(j).Quantile())
+            values = append(values, quantiles.At(j).Value())
+        }
+
+        labels, ok := e.labels(batches.ctx, meta, point.Attributes())
+        if !ok {
+            continue
+        }
+        args := []any{
+            time.Unix(0, int64(point.Timestamp())).UTC(),
+            meta.name,
+            labels.strings,
+            meta.serviceName,
+            meta.hostName,
+            point.Count(),
+            point.Sum(),
+            qs,
+            values,
+            meta.description,
+            meta.unit,
+            meta.resourceAttributes,
+            meta.resourceSchemaURL,
+            meta.scopeName,
+            meta.scopeVersion,
+            meta.scopeAttributes,
+            meta.scopeSchemaURL,
+            time.Unix(0, int64(point.StartTimestamp())).UTC(),
+            uint32(point.Flags()),
+            labels.ints,
+            labels.floats,
+            labels.bools,
+        }
+        args = append(args, e.attributes.promotedValues(point.Attributes())...)
+        if err := batches.append(meta.queries.summary, args...); err != nil {
+            return fmt.Errorf("failed to insert summary metric %s: %w", meta.name, err)
+        }
+    }
+    return nil
+}
+
+// exemplars holds the parallel arrays backing an exemplars Nested column.
+type exemplars struct {
+    attributes []map[string]string
+    timestamps []time.Time
+    values     []float64
+    spanIDs    []string
+    traceIDs   []string
+}
+
+func convertExemplars(es pmetric.ExemplarSlice) exemplars {
+    ex := exemplars{
+        attributes: make([]map[string]string, 0, es.Len()),
+        timestamps: make([]time.Time, 0, es.Len()),
+        values:     make([]float64, 0, es.Len()),
+        spanIDs:    make([]string, 0, es.Len()),
+        traceIDs:   make([]string, 0, es.Len()),
+    }
+    for i := 0; i < es.Len(); i++ {
+        exemplar := es.At(i)
+
+        var value float64
+        switch exemplar.ValueType() {
+        case pmetric.ExemplarValueTypeDouble:
+            value = exemplar.DoubleValue()
+        case pmetric.ExemplarValueTypeInt:
+            value = float64(exemplar.IntValue())
+        }
+
+        ex.attributes = append(ex.attributes, attributesToMap(exemplar.FilteredAttributes()))
+        ex.timestamps = append(ex.timestamps, exemplar.Timestamp().AsTime().UTC())
+        ex.values = append(ex.values, value)
+        ex.spanIDs = append(ex.spanIDs, exemplar.SpanID().String())
+        ex.traceIDs = append(ex.traceIDs, exemplar.TraceID().String())
+    }
+    return ex
+}
+
+func ptr[T any](v T) *T {
+    return &v
+}
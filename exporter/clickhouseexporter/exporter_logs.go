@@ -0,0 +1,97 @@
+// exporter/clickhouseexporter/exporter_logs.go
+package clickhouseexporter
+
+import (
+    "context"
+    "fmt"
+
+    "go.opentelemetry.io/collector/pdata/plog"
+)
+
+const insertLogsSQL = `
+    INSERT INTO %s (
+        timestamp,
+        observed_timestamp,
+        trace_id,
+        span_id,
+        trace_flags,
+        severity_text,
+        severity_number,
+        service_name,
+        body,
+        resource_attributes,
+        scope_name,
+        scope_version,
+        scope_attributes,
+        log_attributes
+    )
+`
+
+func (e *clickhouseExporter) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+    if err := e.ensureSchema(ctx); err != nil {
+        return err
+    }
+    logs := ld.ResourceLogs()
+
+    token, err := e.deduplicationToken(func() ([]byte, error) {
+        return (&plog.ProtoMarshaler{}).MarshalLogs(ld)
+    })
+    if err != nil {
+        return err
+    }
+    batches := newBatchSet(ctx, e.conn, e.telemetry, e.sent, token, e.cfg.InsertDeduplication)
+
+    for i := 0; i < logs.Len(); i++ {
+        rl := logs.At(i)
+        resource := rl.Resource()
+        resourceAttrs := attributesToMap(resource.Attributes())
+        queries := e.tenants.route(ctx, resource.Attributes())
+
+        var serviceName string
+        if serviceAttr, ok := resource.Attributes().Get("service.name"); ok {
+            serviceName = serviceAttr.Str()
+        }
+
+        sl := rl.ScopeLogs()
+        for j := 0; j < sl.Len(); j++ {
+            scope := sl.At(j).Scope()
+            scopeAttrs := attributesToMap(scope.Attributes())
+            records := sl.At(j).LogRecords()
+
+            for k := 0; k < records.Len(); k++ {
+                record := records.At(k)
+
+                // Records without a timestamp fall back to the time the
+                // collector observed them, as recommended by the data model.
+                timestamp := record.Timestamp()
+                if timestamp == 0 {
+                    timestamp = record.ObservedTimestamp()
+                }
+
+                err := batches.append(queries.logs,
+                    timestamp.AsTime().UTC(),
+                    record.ObservedTimestamp().AsTime().UTC(),
+                    record.TraceID().String(),
+                    record.SpanID().String(),
+                    uint32(record.Flags()),
+                    record.SeverityText(),
+                    int32(record.SeverityNumber()),
+                    serviceName,
+                    record.Body().AsString(),
+                    resourceAttrs,
+                    scope.Name(),
+                    scope.Version(),
+                    scopeAttrs,
+                    attributesToMap(record.Attributes()),
+                )
+                if err != nil {
+                    return e.fail(fmt.Errorf("failed to insert log record: %w", err))
+                }
+            }
+        }
+    }
+
+    return e.finish(ctx, batches, signalLogs, func() int {
+        return (&plog.ProtoMarshaler{}).LogsSize(ld)
+    })
+}
@@ -0,0 +1,478 @@
+// exporter/clickhouseexporter/exporter_profiles.go
+package clickhouseexporter
+
+import (
+    "context"
+    "encoding/binary"
+    "fmt"
+    "hash/fnv"
+    "time"
+
+    "go.opentelemetry.io/collector/pdata/pcommon"
+    "go.opentelemetry.io/collector/pdata/pprofile"
+)
+
+const insertProfileSamplesSQL = `
+    INSERT INTO %s (
+        timestamp,
+        profile_id,
+        service_name,
+        resource_attributes,
+        scope_name,
+        scope_version,
+        profile_attributes,
+        sample_type,
+        sample_unit,
+        period_type,
+        period_unit,
+        period,
+        value,
+        location_ids,
+        attributes,
+        trace_id,
+        span_id
+    )
+`
+
+const insertProfileLocationsSQL = `
+    INSERT INTO %s (
+        location_id,
+        mapping_id,
+        address,
+        function_ids,
+        lines,
+        line_columns,
+        is_folded,
+        last_seen
+    )
+`
+
+const insertProfileFunctionsSQL = `
+    INSERT INTO %s (
+        function_id,
+        name,
+        system_name,
+        filename,
+        start_line,
+        last_seen
+    )
+`
+
+const insertProfileMappingsSQL = `
+    INSERT INTO %s (
+        mapping_id,
+        memory_start,
+        memory_limit,
+        file_offset,
+        filename,
+        build_id,
+        build_id_kind,
+        has_functions,
+        has_filenames,
+        has_line_numbers,
+        has_inline_frames,
+        last_seen
+    )
+`
+
+func (e *clickhouseExporter) ConsumeProfiles(ctx context.Context, pd pprofile.Profiles) error {
+    if err := e.ensureSchema(ctx); err != nil {
+        return err
+    }
+    token, err := e.deduplicationToken(func() ([]byte, error) {
+        return (&pprofile.ProtoMarshaler{}).MarshalProfiles(pd)
+    })
+    if err != nil {
+        return err
+    }
+    batches := newBatchSet(ctx, e.conn, e.telemetry, e.sent, token, e.cfg.InsertDeduplication)
+
+    // Locations, functions and mappings shared by several profiles of the
+    // payload are written once.
+    w := &profileWriter{
+        batches: batches,
+        written: make(map[profileRowKey]struct{}),
+    }
+
+    resourceProfiles := pd.ResourceProfiles()
+    for i := 0; i < resourceProfiles.Len(); i++ {
+        rp := resourceProfiles.At(i)
+        resource := rp.Resource()
+        resourceAttrs := attributesToMap(resource.Attributes())
+        queries := e.tenants.route(ctx, resource.Attributes())
+
+        var serviceName string
+        if serviceAttr, ok := resource.Attributes().Get("service.name"); ok {
+            serviceName = serviceAttr.Str()
+        }
+
+        scopeProfiles := rp.ScopeProfiles()
+        for j := 0; j < scopeProfiles.Len(); j++ {
+            scope := scopeProfiles.At(j).Scope()
+            containers := scopeProfiles.At(j).Profiles()
+            for k := 0; k < containers.Len(); k++ {
+                meta := profileMeta{
+                    serviceName:        serviceName,
+                    resourceAttributes: resourceAttrs,
+                    scopeName:          scope.Name(),
+                    scopeVersion:       scope.Version(),
+                    queries:            queries,
+                }
+                if err := w.writeProfile(containers.At(k), meta); err != nil {
+                    return e.fail(fmt.Errorf("failed to insert profile: %w", err))
+                }
+            }
+        }
+    }
+
+    return e.finish(ctx, batches, signalProfiles, func() int {
+        return (&pprofile.ProtoMarshaler{}).ProfilesSize(pd)
+    })
+}
+
+// profileMeta holds the resource and scope fields shared by every sample of
+// a profile.
+type profileMeta struct {
+    serviceName        string
+    resourceAttributes map[string]string
+    scopeName          string
+    scopeVersion       string
+    // queries are the insert statements of the tenant of the resource.
+    queries            insertQueries
+}
+
+// profileRowKey identifies a row of one of the lookup tables.
+type profileRowKey struct {
+    table string
+    id    uint64
+}
+
+// profileWriter converts profiles into rows. Profiles reference their
+// locations, functions, mappings and strings by index into tables of their
+// own, so the indexes are replaced by ids hashed from the content, which
+// are the same for every profile and collector.
+type profileWriter struct {
+    batches *batchSet
+    written map[profileRowKey]struct{}
+}
+
+func (w *profileWriter) writeProfile(container pprofile.ProfileContainer, meta profileMeta) error {
+    profile := container.Profile()
+    strs := profile.StringTable()
+
+    // Profiles are timestamped by their end, so that lookup rows of a long
+    // running profile are not expired early.
+    seen := container.EndTime().AsTime().UTC()
+    if container.EndTime() == 0 {
+        seen = container.StartTime().AsTime().UTC()
+    }
+
+    mappingIDs, err := w.writeMappings(meta.queries, profile, strs, seen)
+    if err != nil {
+        return err
+    }
+    functionIDs, err := w.writeFunctions(meta.queries, profile, strs, seen)
+    if err != nil {
+        return err
+    }
+    locationIDs, err := w.writeLocations(meta.queries, profile, mappingIDs, functionIDs, seen)
+    if err != nil {
+        return err
+    }
+
+    attributeTable := indexedAttributes(profile.AttributeTable())
+    profileAttrs := attributesToMap(container.Attributes())
+    profileID := container.ProfileID()
+    periodType := profile.PeriodType()
+
+    samples := profile.Sample()
+    for i := 0; i < samples.Len(); i++ {
+        sample := samples.At(i)
+
+        timestamp := container.StartTime()
+        if sample.TimestampsUnixNano().Len() > 0 {
+            timestamp = pcommon.Timestamp(sample.TimestampsUnixNano().At(0))
+        }
+        stack := sampleLocations(profile, sample, locationIDs)
+        attrs := sampleAttributes(sample, attributeTable, strs)
+
+        var traceID, spanID string
+        if link := int(sample.Link()); link < profile.LinkTable().Len() {
+            traceID = profile.LinkTable().At(link).TraceID().String()
+            spanID = profile.LinkTable().At(link).SpanID().String()
+        }
+
+        values := sample.Value()
+        for v := 0; v < values.Len(); v++ {
+            var sampleType, sampleUnit string
+            if v < profile.SampleType().Len() {
+                sampleType = stringAt(strs, profile.SampleType().At(v).Type())
+                sampleUnit = stringAt(strs, profile.SampleType().At(v).Unit())
+            }
+            err := w.batches.append(meta.queries.profileSamples,
+                timestamp.AsTime().UTC(),
+                hexProfileID(profileID),
+                meta.serviceName,
+                meta.resourceAttributes,
+                meta.scopeName,
+                meta.scopeVersion,
+                profileAttrs,
+                sampleType,
+                sampleUnit,
+                stringAt(strs, periodType.Type()),
+                stringAt(strs, periodType.Unit()),
+                profile.Period(),
+                values.At(v),
+                stack,
+                attrs,
+                traceID,
+                spanID,
+            )
+            if err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+func (w *profileWriter) writeMappings(queries insertQueries, profile pprofile.Profile, strs pcommon.StringSlice, seen time.Time) ([]uint64, error) {
+    mappings := profile.Mapping()
+    ids := make([]uint64, mappings.Len())
+    for i := 0; i < mappings.Len(); i++ {
+        mapping := mappings.At(i)
+        filename := stringAt(strs, mapping.Filename())
+        buildID := stringAt(strs, mapping.BuildID())
+
+        h := newContentHash()
+        h.str(filename)
+        h.str(buildID)
+        h.uint(mapping.MemoryStart())
+        h.uint(mapping.MemoryLimit())
+        h.uint(mapping.FileOffset())
+        ids[i] = h.sum()
+
+        if !w.first(queries.profileMappings.table, ids[i]) {
+            continue
+        }
+        err := w.batches.append(queries.profileMappings,
+            ids[i],
+            mapping.MemoryStart(),
+            mapping.MemoryLimit(),
+            mapping.FileOffset(),
+            filename,
+            buildID,
+            mapping.BuildIDKind().String(),
+            mapping.HasFunctions(),
+            mapping.HasFilenames(),
+            mapping.HasLineNumbers(),
+            mapping.HasInlineFrames(),
+            seen,
+        )
+        if err != nil {
+            return nil, err
+        }
+    }
+    return ids, nil
+}
+
+func (w *profileWriter) writeFunctions(queries insertQueries, profile pprofile.Profile, strs pcommon.StringSlice, seen time.Time) ([]uint64, error) {
+    functions := profile.Function()
+    ids := make([]uint64, functions.Len())
+    for i := 0; i < functions.Len(); i++ {
+        function := functions.At(i)
+        name := stringAt(strs, function.Name())
+        systemName := stringAt(strs, function.SystemName())
+        filename := stringAt(strs, function.Filename())
+
+        h := newContentHash()
+        h.str(name)
+        h.str(systemName)
+        h.str(filename)
+        h.uint(uint64(function.StartLine()))
+        ids[i] = h.sum()
+
+        if !w.first(queries.profileFunctions.table, ids[i]) {
+            continue
+        }
+        err := w.batches.append(queries.profileFunctions,
+            ids[i],
+            name,
+            systemName,
+            filename,
+            function.StartLine(),
+            seen,
+        )
+        if err != nil {
+            return nil, err
+        }
+    }
+    return ids, nil
+}
+
+func (w *profileWriter) writeLocations(queries insertQueries, profile pprofile.Profile, mappingIDs, functionIDs []uint64, seen time.Time) ([]uint64, error) {
+    locations := profile.Location()
+    ids := make([]uint64, locations.Len())
+    for i := 0; i < locations.Len(); i++ {
+        location := locations.At(i)
+
+        var mappingID uint64
+        if index := location.MappingIndex(); index < uint64(len(mappingIDs)) {
+            mappingID = mappingIDs[index]
+        }
+        lines := location.Line()
+        lineFunctions := make([]uint64, lines.Len())
+        lineNumbers := make([]int64, lines.Len())
+        lineColumns := make([]int64, lines.Len())
+        for l := 0; l < lines.Len(); l++ {
+            line := lines.At(l)
+            if index := line.FunctionIndex(); index < uint64(len(functionIDs)) {
+                lineFunctions[l] = functionIDs[index]
+            }
+            lineNumbers[l] = line.Line()
+            lineColumns[l] = line.Column()
+        }
+
+        h := newContentHash()
+        h.uint(mappingID)
+        h.uint(location.Address())
+        for l := range lineFunctions {
+            h.uint(lineFunctions[l])
+            h.uint(uint64(lineNumbers[l]))
+            h.uint(uint64(lineColumns[l]))
+        }
+        if location.IsFolded() {
+            h.uint(1)
+        }
+        ids[i] = h.sum()
+
+        if !w.first(queries.profileLocations.table, ids[i]) {
+            continue
+        }
+        err := w.batches.append(queries.profileLocations,
+            ids[i],
+            mappingID,
+            location.Address(),
+            lineFunctions,
+            lineNumbers,
+            lineColumns,
+            location.IsFolded(),
+            seen,
+        )
+        if err != nil {
+            return nil, err
+        }
+    }
+    return ids, nil
+}
+
+// first reports whether the row with id has not been written to table by
+// this payload yet.
+func (w *profileWriter) first(table string, id uint64) bool {
+    key := profileRowKey{table: table, id: id}
+    if _, ok := w.written[key]; ok {
+        return false
+    }
+    w.written[key] = struct{}{}
+    return true
+}
+
+// sampleLocations returns the location ids of the stack of a sample, leaf
+// first. Stacks are a range of the location indices of the profile, or
+// the deprecated per-sample list of indexes.
+func sampleLocations(profile pprofile.Profile, sample pprofile.Sample, locationIDs []uint64) []uint64 {
+    var indexes []uint64
+    if sample.LocationsLength() > 0 {
+        all := profile.LocationIndices()
+        for i := sample.LocationsStartIndex(); i < sample.LocationsStartIndex()+sample.LocationsLength() && i < uint64(all.Len()); i++ {
+            indexes = append(indexes, uint64(all.At(int(i))))
+        }
+    } else {
+        indexes = sample.LocationIndex().AsRaw()
+    }
+
+    stack := make([]uint64, 0, len(indexes))
+    for _, index := range indexes {
+        if index < uint64(len(locationIDs)) {
+            stack = append(stack, locationIDs[index])
+        }
+    }
+    return stack
+}
+
+// attributeEntry is an entry of the attribute table of a profile.
+type attributeEntry struct {
+    key   string
+    value string
+}
+
+// indexedAttributes returns the attribute table of a profile in order, as
+// samples reference its entries by index.
+func indexedAttributes(table pcommon.Map) []attributeEntry {
+    entries := make([]attributeEntry, 0, table.Len())
+    table.Range(func(k string, v pcommon.Value) bool {
+        entries = append(entries, attributeEntry{key: k, value: v.AsString()})
+        return true
+    })
+    return entries
+}
+
+// sampleAttributes merges the attributes and the labels of a sample.
+func sampleAttributes(sample pprofile.Sample, table []attributeEntry, strs pcommon.StringSlice) map[string]string {
+    attrs := make(map[string]string)
+    for i := 0; i < sample.Attributes().Len(); i++ {
+        if index := sample.Attributes().At(i); index < uint64(len(table)) {
+            attrs[table[index].key] = table[index].value
+        }
+    }
+    labels := sample.Label()
+    for i := 0; i < labels.Len(); i++ {
+        label := labels.At(i)
+        if label.Str() != 0 {
+            attrs[stringAt(strs, label.Key())] = stringAt(strs, label.Str())
+        } else {
+            attrs[stringAt(strs, label.Key())] = fmt.Sprint(label.Num())
+        }
+    }
+    return attrs
+}
+
+// stringAt returns the entry of the string table at index, or an empty
+// string for an index out of range.
+func stringAt(strs pcommon.StringSlice, index int64) string {
+    if index < 0 || index >= int64(strs.Len()) {
+        return ""
+    }
+    return strs.At(int(index))
+}
+
+func hexProfileID(id pprofile.ProfileID) string {
+    if id.IsEmpty() {
+        return ""
+    }
+    return fmt.Sprintf("%x", [16]byte(id))
+}
+
+// contentHash hashes the fields of a row into its id.
+type contentHash struct {
+    buf []byte
+}
+
+func newContentHash() *contentHash {
+    return &contentHash{}
+}
+
+func (h *contentHash) str(s string) {
+    h.buf = binary.AppendUvarint(h.buf, uint64(len(s)))
+    h.buf = append(h.buf, s...)
+}
+
+func (h *contentHash) uint(v uint64) {
+    h.buf = binary.LittleEndian.AppendUint64(h.buf, v)
+}
+
+func (h *contentHash) sum() uint64 {
+    f := fnv.New64a()
+    _, _ = f.Write(h.buf)
+    return f.Sum64()
+}
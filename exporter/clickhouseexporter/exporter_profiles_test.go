@@ -0,0 +1,178 @@
+// exporter/clickhouseexporter/exporter_profiles_test.go
+package clickhouseexporter
+
+import (
+    "context"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.opentelemetry.io/collector/pdata/pcommon"
+    "go.opentelemetry.io/collector/pdata/pprofile"
+)
+
+// generateProfiles returns a payload of n copies of a cpu profile of two
+// samples, whose stacks share the frame of main.
+func generateProfiles(n int) pprofile.Profiles {
+    pd := pprofile.NewProfiles()
+    rp := pd.ResourceProfiles().AppendEmpty()
+    rp.Resource().Attributes().PutStr("service.name", "checkout")
+    sp := rp.ScopeProfiles().AppendEmpty()
+    sp.Scope().SetName("ebpf-profiler")
+    sp.Scope().SetVersion("0.1.0")
+
+    for i := 0; i < n; i++ {
+        container := sp.Profiles().AppendEmpty()
+        container.SetProfileID(pprofile.ProfileID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, byte(i)})
+        container.SetStartTime(goldenStart)
+        container.SetEndTime(goldenTime)
+        container.Attributes().PutStr("profiler", "cpu")
+
+        profile := container.Profile()
+        // 0 is the empty string, as in pprof.
+        profile.StringTable().Append("", "samples", "count", "cpu", "nanoseconds",
+            "/usr/bin/checkout", "abc123", "main", "main.go", "handle", "handler.go", "thread", "worker")
+        profile.SetPeriod(10000000)
+        profile.PeriodType().SetType(3)
+        profile.PeriodType().SetUnit(4)
+        for _, st := range [][2]int64{{1, 2}, {3, 4}} {
+            vt := profile.SampleType().AppendEmpty()
+            vt.SetType(st[0])
+            vt.SetUnit(st[1])
+        }
+
+        mapping := profile.Mapping().AppendEmpty()
+        mapping.SetMemoryStart(0x400000)
+        mapping.SetMemoryLimit(0x800000)
+        mapping.SetFilename(5)
+        mapping.SetBuildID(6)
+        mapping.SetHasFunctions(true)
+
+        for _, fn := range [][3]int64{{7, 8, 10}, {9, 10, 40}} {
+            function := profile.Function().AppendEmpty()
+            function.SetName(fn[0])
+            function.SetSystemName(fn[0])
+            function.SetFilename(fn[1])
+            function.SetStartLine(fn[2])
+        }
+
+        for l := 0; l < 2; l++ {
+            location := profile.Location().AppendEmpty()
+            location.SetMappingIndex(0)
+            location.SetAddress(uint64(0x401000 + l*0x100))
+            line := location.Line().AppendEmpty()
+            line.SetFunctionIndex(uint64(l))
+            line.SetLine(int64(12 + l*30))
+        }
+
+        // The stack of the first sample is handle, main, of the second main.
+        profile.LocationIndices().FromRaw([]int64{1, 0})
+        profile.AttributeTable().PutStr("thread.name", "worker")
+        link := profile.LinkTable().AppendEmpty()
+        link.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+        link.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+
+        first := profile.Sample().AppendEmpty()
+        first.SetLocationsStartIndex(0)
+        first.SetLocationsLength(2)
+        first.Value().FromRaw([]int64{3, 30000000})
+        first.Attributes().Append(0)
+        first.SetLink(0)
+
+        second := profile.Sample().AppendEmpty()
+        second.SetLocationsStartIndex(1)
+        second.SetLocationsLength(1)
+        second.Value().FromRaw([]int64{1, 10000000})
+        second.TimestampsUnixNano().Append(uint64(goldenTime))
+        second.SetLink(1)
+        label := second.Label().AppendEmpty()
+        label.SetKey(11)
+        label.SetStr(12)
+    }
+    return pd
+}
+
+func TestConsumeProfiles(t *testing.T) {
+    conn := &recordingConn{}
+    exp := newTestExporter(t, conn)
+    require.NoError(t, exp.ConsumeProfiles(context.Background(), generateProfiles(1)))
+
+    locations := conn.rows("otel.otel_profile_locations")
+    functions := conn.rows("otel.otel_profile_functions")
+    mappings := conn.rows("otel.otel_profile_mappings")
+    require.Len(t, locations, 2)
+    require.Len(t, functions, 2)
+    require.Len(t, mappings, 1)
+
+    assert.Equal(t, "/usr/bin/checkout", mappings[0]["filename"])
+    assert.Equal(t, "abc123", mappings[0]["build_id"])
+    assert.Equal(t, "BUILD_ID_LINKER", mappings[0]["build_id_kind"])
+    assert.Equal(t, goldenTime.AsTime().UTC(), mappings[0]["last_seen"])
+    assert.Equal(t, "main", functions[0]["name"])
+    assert.Equal(t, "handle", functions[1]["name"])
+    assert.Equal(t, mappings[0]["mapping_id"], locations[0]["mapping_id"])
+    assert.Equal(t, []uint64{functions[0]["function_id"].(uint64)}, locations[0]["function_ids"])
+    assert.Equal(t, []int64{42}, locations[1]["lines"])
+
+    samples := conn.rows("otel.otel_profile_samples")
+    require.Len(t, samples, 4)
+    main, handle := locations[0]["location_id"].(uint64), locations[1]["location_id"].(uint64)
+
+    // One row per sample type of every sample.
+    assert.Equal(t, "samples", samples[0]["sample_type"])
+    assert.Equal(t, int64(3), samples[0]["value"])
+    assert.Equal(t, "nanoseconds", samples[1]["sample_unit"])
+    assert.Equal(t, int64(30000000), samples[1]["value"])
+    for _, row := range samples {
+        assert.Equal(t, "checkout", row["service_name"])
+        assert.Equal(t, "ebpf-profiler", row["scope_name"])
+        assert.Equal(t, "0102030405060708090a0b0c0d0e0f00", row["profile_id"])
+        assert.Equal(t, map[string]string{"profiler": "cpu"}, row["profile_attributes"])
+        assert.Equal(t, "cpu", row["period_type"])
+        assert.Equal(t, int64(10000000), row["period"])
+    }
+
+    assert.Equal(t, []uint64{handle, main}, samples[0]["location_ids"])
+    assert.Equal(t, map[string]string{"thread.name": "worker"}, samples[0]["attributes"])
+    assert.Equal(t, "0102030405060708090a0b0c0d0e0f10", samples[0]["trace_id"])
+    assert.Equal(t, goldenStart.AsTime().UTC(), samples[0]["timestamp"])
+
+    assert.Equal(t, []uint64{main}, samples[2]["location_ids"])
+    assert.Equal(t, map[string]string{"thread": "worker"}, samples[2]["attributes"])
+    assert.Empty(t, samples[2]["trace_id"])
+    assert.Equal(t, goldenTime.AsTime().UTC(), samples[2]["timestamp"])
+}
+
+func TestConsumeProfilesWritesSharedRowsOnce(t *testing.T) {
+    conn := &recordingConn{}
+    exp := newTestExporter(t, conn)
+    require.NoError(t, exp.ConsumeProfiles(context.Background(), generateProfiles(3)))
+
+    assert.Len(t, conn.rows("otel.otel_profile_samples"), 12)
+    assert.Len(t, conn.rows("otel.otel_profile_locations"), 2)
+    assert.Len(t, conn.rows("otel.otel_profile_functions"), 2)
+    assert.Len(t, conn.rows("otel.otel_profile_mappings"), 1)
+
+    // Ids depend only on the content, so a later payload references the
+    // same rows.
+    again := &recordingConn{}
+    exp = newTestExporter(t, again)
+    require.NoError(t, exp.ConsumeProfiles(context.Background(), generateProfiles(1)))
+    assert.Equal(t, conn.rows("otel.otel_profile_locations"), again.rows("otel.otel_profile_locations"))
+}
+
+func TestConsumeProfilesIgnoresBadIndexes(t *testing.T) {
+    pd := generateProfiles(1)
+    profile := pd.ResourceProfiles().At(0).ScopeProfiles().At(0).Profiles().At(0).Profile()
+    profile.Location().At(0).SetMappingIndex(7)
+    profile.Function().At(0).SetName(99)
+    profile.Sample().At(0).SetLocationsLength(5)
+
+    conn := &recordingConn{}
+    exp := newTestExporter(t, conn)
+    require.NoError(t, exp.ConsumeProfiles(context.Background(), pd))
+
+    assert.Equal(t, uint64(0), conn.rows("otel.otel_profile_locations")[0]["mapping_id"])
+    assert.Empty(t, conn.rows("otel.otel_profile_functions")[0]["name"])
+    assert.Len(t, conn.rows("otel.otel_profile_samples")[0]["location_ids"], 2)
+}
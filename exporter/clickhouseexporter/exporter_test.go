@@ -0,0 +1,531 @@
+// exporter/clickhouseexporter/exporter_test.go
+package clickhouseexporter
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "regexp"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    chproto "github.com/ClickHouse/ch-go/proto"
+    "github.com/ClickHouse/clickhouse-go/v2"
+    "github.com/ClickHouse/clickhouse-go/v2/lib/column"
+    "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+    "github.com/ClickHouse/clickhouse-go/v2/lib/proto"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.opentelemetry.io/collector/component/componentstatus"
+    "go.opentelemetry.io/collector/component/componenttest"
+    "go.opentelemetry.io/collector/pdata/pcommon"
+    "go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// standInSchema lists the column types of the tables the stand-in server
+// knows about, in the same order as the README DDL.
+var standInSchema = map[string][][2]string{
+    "otel.metrics": {
+        {"timestamp", "DateTime64(9)"},
+        {"metric_name", "LowCardinality(String)"},
+        {"metric_type", "Enum8('gauge' = 1, 'sum' = 2, 'histogram' = 3)"},
+        {"value", "Float64"},
+        {"labels", "Map(LowCardinality(String), String)"},
+        {"service_name", "LowCardinality(String)"},
+        {"host_name", "LowCardinality(String)"},
+        {"metric_description", "String"},
+        {"metric_unit", "LowCardinality(String)"},
+        {"resource_attributes", "Map(LowCardinality(String), String)"},
+        {"resource_schema_url", "String"},
+        {"scope_name", "String"},
+        {"scope_version", "String"},
+        {"scope_attributes", "Map(LowCardinality(String), String)"},
+        {"scope_schema_url", "String"},
+        {"start_time_unix", "DateTime64(9)"},
+        {"flags", "UInt32"},
+        {"is_monotonic", "Bool"},
+        {"temporality", "Enum8('unspecified' = 0, 'delta' = 1, 'cumulative' = 2)"},
+        {"exemplars.filtered_attributes", "Array(Map(LowCardinality(String), String))"},
+        {"exemplars.timestamp", "Array(DateTime64(9))"},
+        {"exemplars.value", "Array(Float64)"},
+        {"exemplars.span_id", "Array(String)"},
+        {"exemplars.trace_id", "Array(String)"},
+        {"labels_int", "Map(LowCardinality(String), Int64)"},
+        {"labels_float", "Map(LowCardinality(String), Float64)"},
+        {"labels_bool", "Map(LowCardinality(String), Bool)"},
+    },
+    "otel.metrics_histogram": {
+        {"timestamp", "DateTime64(9)"},
+        {"metric_name", "LowCardinality(String)"},
+        {"labels", "Map(LowCardinality(String), String)"},
+        {"service_name", "LowCardinality(String)"},
+        {"host_name", "LowCardinality(String)"},
+        {"count", "UInt64"},
+        {"sum", "Nullable(Float64)"},
+        {"min", "Nullable(Float64)"},
+        {"max", "Nullable(Float64)"},
+        {"bucket_counts", "Array(UInt64)"},
+        {"explicit_bounds", "Array(Float64)"},
+        {"exemplars.filtered_attributes", "Array(Map(LowCardinality(String), String))"},
+        {"exemplars.timestamp", "Array(DateTime64(9))"},
+        {"exemplars.value", "Array(Float64)"},
+        {"exemplars.span_id", "Array(String)"},
+        {"exemplars.trace_id", "Array(String)"},
+        {"metric_description", "String"},
+        {"metric_unit", "LowCardinality(String)"},
+        {"resource_attributes", "Map(LowCardinality(String), String)"},
+        {"resource_schema_url", "String"},
+        {"scope_name", "String"},
+        {"scope_version", "String"},
+        {"scope_attributes", "Map(LowCardinality(String), String)"},
+        {"scope_schema_url", "String"},
+        {"start_time_unix", "DateTime64(9)"},
+        {"flags", "UInt32"},
+        {"temporality", "Enum8('unspecified' = 0, 'delta' = 1, 'cumulative' = 2)"},
+        {"labels_int", "Map(LowCardinality(String), Int64)"},
+        {"labels_float", "Map(LowCardinality(String), Float64)"},
+        {"labels_bool", "Map(LowCardinality(String), Bool)"},
+    },
+    "otel.otel_traces": {
+        {"timestamp", "DateTime64(9)"},
+        {"trace_id", "String"},
+        {"span_id", "String"},
+        {"parent_span_id", "String"},
+        {"trace_state", "String"},
+        {"span_name", "LowCardinality(String)"},
+        {"span_kind", "LowCardinality(String)"},
+        {"service_name", "LowCardinality(String)"},
+        {"resource_attributes", "Map(LowCardinality(String), String)"},
+        {"scope_name", "String"},
+        {"scope_version", "String"},
+        {"span_attributes", "Map(LowCardinality(String), String)"},
+        {"duration", "Int64"},
+        {"status_code", "LowCardinality(String)"},
+        {"status_message", "String"},
+        {"events.timestamp", "Array(DateTime64(9))"},
+        {"events.name", "Array(LowCardinality(String))"},
+        {"events.attributes", "Array(Map(LowCardinality(String), String))"},
+        {"links.trace_id", "Array(String)"},
+        {"links.span_id", "Array(String)"},
+        {"links.trace_state", "Array(String)"},
+        {"links.attributes", "Array(Map(LowCardinality(String), String))"},
+    },
+    "otel.otel_logs": {
+        {"timestamp", "DateTime64(9)"},
+        {"observed_timestamp", "DateTime64(9)"},
+        {"trace_id", "String"},
+        {"span_id", "String"},
+        {"trace_flags", "UInt32"},
+        {"severity_text", "LowCardinality(String)"},
+        {"severity_number", "Int32"},
+        {"service_name", "LowCardinality(String)"},
+        {"body", "String"},
+        {"resource_attributes", "Map(LowCardinality(String), String)"},
+        {"scope_name", "String"},
+        {"scope_version", "String"},
+        {"scope_attributes", "Map(LowCardinality(String), String)"},
+        {"log_attributes", "Map(LowCardinality(String), String)"},
+    },
+    "otel.otel_profile_samples": {
+        {"timestamp", "DateTime64(9)"},
+        {"profile_id", "String"},
+        {"service_name", "LowCardinality(String)"},
+        {"resource_attributes", "Map(LowCardinality(String), String)"},
+        {"scope_name", "String"},
+        {"scope_version", "String"},
+        {"profile_attributes", "Map(LowCardinality(String), String)"},
+        {"sample_type", "LowCardinality(String)"},
+        {"sample_unit", "LowCardinality(String)"},
+        {"period_type", "LowCardinality(String)"},
+        {"period_unit", "LowCardinality(String)"},
+        {"period", "Int64"},
+        {"value", "Int64"},
+        {"location_ids", "Array(UInt64)"},
+        {"attributes", "Map(LowCardinality(String), String)"},
+        {"trace_id", "String"},
+        {"span_id", "String"},
+    },
+    "otel.otel_profile_locations": {
+        {"location_id", "UInt64"},
+        {"mapping_id", "UInt64"},
+        {"address", "UInt64"},
+        {"function_ids", "Array(UInt64)"},
+        {"lines", "Array(Int64)"},
+        {"line_columns", "Array(Int64)"},
+        {"is_folded", "Bool"},
+        {"last_seen", "DateTime"},
+    },
+    "otel.otel_profile_functions": {
+        {"function_id", "UInt64"},
+        {"name", "String"},
+        {"system_name", "String"},
+        {"filename", "String"},
+        {"start_line", "Int64"},
+        {"last_seen", "DateTime"},
+    },
+    "otel.otel_profile_mappings": {
+        {"mapping_id", "UInt64"},
+        {"memory_start", "UInt64"},
+        {"memory_limit", "UInt64"},
+        {"file_offset", "UInt64"},
+        {"filename", "String"},
+        {"build_id", "String"},
+        {"build_id_kind", "LowCardinality(String)"},
+        {"has_functions", "Bool"},
+        {"has_filenames", "Bool"},
+        {"has_line_numbers", "Bool"},
+        {"has_inline_frames", "Bool"},
+        {"last_seen", "DateTime"},
+    },
+}
+
+var insertTableRe = regexp.MustCompile(`(?s)INSERT INTO\s+(\S+)\s*\((.*)\)`)
+
+// standInConn is a local stand-in for a ClickHouse server. Batches are
+// encoded into real native blocks, so the exporter pays the same columnar
+// conversion cost as against a server, but nothing leaves the process.
+type standInConn struct {
+    dbConn
+
+    // extraColumns are the types of columns missing from standInSchema,
+    // such as promoted attributes.
+    extraColumns map[string]string
+
+    mu     sync.Mutex
+    blocks map[string][]*proto.Block
+}
+
+func newStandInConn() *standInConn {
+    return &standInConn{blocks: make(map[string][]*proto.Block)}
+}
+
+func (c *standInConn) PrepareBatch(_ context.Context, query string, _ ...driver.PrepareBatchOption) (driver.Batch, error) {
+    match := insertTableRe.FindStringSubmatch(query)
+    if match == nil {
+        return nil, fmt.Errorf("unsupported query: %s", query)
+    }
+    columns, ok := standInSchema[match[1]]
+    if !ok {
+        return nil, fmt.Errorf("unknown table %s", match[1])
+    }
+    types := make(map[string]string, len(columns)+len(c.extraColumns))
+    for _, col := range columns {
+        types[col[0]] = col[1]
+    }
+    for name, typ := range c.extraColumns {
+        types[name] = typ
+    }
+    // Like the server, build the block from the columns of the statement.
+    block := &proto.Block{Timezone: time.UTC}
+    for _, name := range strings.Split(match[2], ",") {
+        name = strings.TrimSpace(name)
+        typ, ok := types[name]
+        if !ok {
+            return nil, fmt.Errorf("no column %s in table %s", name, match[1])
+        }
+        if err := block.AddColumn(name, column.Type(typ)); err != nil {
+            return nil, err
+        }
+    }
+    return &standInBatch{conn: c, table: match[1], block: block}, nil
+}
+
+func (c *standInConn) Close() error {
+    return nil
+}
+
+// blockRows returns the row count of every block sent to table.
+func (c *standInConn) blockRows(table string) []int {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    rows := make([]int, 0, len(c.blocks[table]))
+    for _, block := range c.blocks[table] {
+        rows = append(rows, block.Rows())
+    }
+    return rows
+}
+
+// value returns the value of a column in a row of the first block sent to
+// table.
+func (c *standInConn) value(t *testing.T, table, columnName string, row int) any {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    require.NotEmpty(t, c.blocks[table], "no block sent to %s", table)
+    block := c.blocks[table][0]
+    for i, name := range block.ColumnsNames() {
+        if name == columnName {
+            return block.Columns[i].Row(row, false)
+        }
+    }
+    require.Failf(t, "unknown column", "%s has no column %s", table, columnName)
+    return nil
+}
+
+type standInBatch struct {
+    driver.Batch
+
+    conn  *standInConn
+    table string
+    block *proto.Block
+    sent  bool
+}
+
+func (b *standInBatch) Append(v ...any) error {
+    return b.block.Append(v...)
+}
+
+func (b *standInBatch) Send() error {
+    var buf chproto.Buffer
+    if err := b.block.Encode(&buf, proto.DBMS_TCP_PROTOCOL_VERSION); err != nil {
+        return err
+    }
+    b.sent = true
+    b.conn.mu.Lock()
+    defer b.conn.mu.Unlock()
+    b.conn.blocks[b.table] = append(b.conn.blocks[b.table], b.block)
+    return nil
+}
+
+func (b *standInBatch) Abort() error {
+    b.sent = true
+    return nil
+}
+
+func (b *standInBatch) IsSent() bool {
+    return b.sent
+}
+
+func (b *standInBatch) Rows() int {
+    return b.block.Rows()
+}
+
+func generateMetrics(resources, pointsPerMetric int) pmetric.Metrics {
+    md := pmetric.NewMetrics()
+    now := pcommon.NewTimestampFromTime(time.Now())
+    for i := 0; i < resources; i++ {
+        rm := md.ResourceMetrics().AppendEmpty()
+        rm.Resource().Attributes().PutStr("service.name", fmt.Sprintf("service-%d", i))
+        rm.Resource().Attributes().PutStr("host.name", "test-host")
+        metrics := rm.ScopeMetrics().AppendEmpty().Metrics()
+
+        gauge := metrics.AppendEmpty()
+        gauge.SetName("system.memory.usage")
+        gauge.SetEmptyGauge()
+        sum := metrics.AppendEmpty()
+        sum.SetName("http.requests")
+        sum.SetEmptySum().SetIsMonotonic(true)
+        histogram := metrics.AppendEmpty()
+        histogram.SetName("http.duration")
+        histogram.SetEmptyHistogram()
+
+        for j := 0; j < pointsPerMetric; j++ {
+            gp := gauge.Gauge().DataPoints().AppendEmpty()
+            gp.SetTimestamp(now)
+            gp.SetDoubleValue(float64(j))
+            gp.Attributes().PutStr("state", "used")
+
+            sp := sum.Sum().DataPoints().AppendEmpty()
+            sp.SetTimestamp(now)
+            sp.SetIntValue(int64(j))
+            sp.Attributes().PutStr("method", "GET")
+
+            hp := histogram.Histogram().DataPoints().AppendEmpty()
+            hp.SetTimestamp(now)
+            hp.SetCount(6)
+            hp.SetSum(42)
+            hp.SetMin(1)
+            hp.SetMax(20)
+            hp.ExplicitBounds().FromRaw([]float64{5, 10})
+            hp.BucketCounts().FromRaw([]uint64{2, 3, 1})
+        }
+    }
+    return md
+}
+
+// newTestExporter returns an exporter with the default configuration that
+// writes to conn.
+func newTestExporter(t testing.TB, conn dbConn) *clickhouseExporter {
+    exp, err := newClickHouseExporter(componenttest.NewNopTelemetrySettings(), createDefaultConfig().(*Config))
+    require.NoError(t, err)
+    exp.conn = conn
+    return exp
+}
+
+func TestConsumeMetricsSendsOneBlockPerTable(t *testing.T) {
+    conn := newStandInConn()
+    exp := newTestExporter(t, conn)
+
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateMetrics(3, 10)))
+
+    // Gauges and sums share otel.metrics; histograms have their own table.
+    assert.Equal(t, []int{60}, conn.blockRows("otel.metrics"))
+    assert.Equal(t, []int{30}, conn.blockRows("otel.metrics_histogram"))
+}
+
+func TestConsumeMetricsPreparesOneBatchAtATime(t *testing.T) {
+    conn := &recordingConn{}
+    exp := newTestExporter(t, conn)
+
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateMetrics(3, 10)))
+
+    // Each prepared batch holds a connection of the pool until it is sent.
+    assert.Equal(t, 1, conn.maxOpen)
+    assert.Zero(t, conn.open)
+    assert.Len(t, conn.rows("otel.metrics_histogram"), 30)
+}
+
+func TestConsumeMetricsRetryOnlyWritesFailedTables(t *testing.T) {
+    conn := &recordingConn{tableSendErrs: map[string]error{"otel.metrics_histogram": errors.New("connection reset")}}
+    exp := newTestExporter(t, conn)
+
+    md := generateMetrics(1, 5)
+    require.Error(t, exp.ConsumeMetrics(context.Background(), md))
+    assert.Len(t, conn.rows("otel.metrics"), 10)
+    assert.Empty(t, conn.rows("otel.metrics_histogram"))
+
+    // The retry only writes the table that failed.
+    conn.tableSendErrs = nil
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+    assert.Len(t, conn.rows("otel.metrics"), 10)
+    assert.Len(t, conn.rows("otel.metrics_histogram"), 5)
+
+    // Once fully written, the payload is forgotten and can be sent again.
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+    assert.Len(t, conn.rows("otel.metrics"), 20)
+    assert.Len(t, conn.rows("otel.metrics_histogram"), 10)
+}
+
+func TestConsumeMetricsPreservesOTLPFields(t *testing.T) {
+    start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+    now := start.Add(time.Minute)
+
+    md := pmetric.NewMetrics()
+    rm := md.ResourceMetrics().AppendEmpty()
+    rm.SetSchemaUrl("https://opentelemetry.io/schemas/1.26.0")
+    rm.Resource().Attributes().PutStr("service.name", "checkout")
+    rm.Resource().Attributes().PutStr("k8s.pod.name", "checkout-0")
+    sm := rm.ScopeMetrics().AppendEmpty()
+    sm.SetSchemaUrl("https://opentelemetry.io/schemas/1.25.0")
+    sm.Scope().SetName("otelhttp")
+    sm.Scope().SetVersion("0.56.0")
+    sm.Scope().Attributes().PutStr("library.language", "go")
+
+    sum := sm.Metrics().AppendEmpty()
+    sum.SetName("http.requests")
+    sum.SetDescription("Number of requests")
+    sum.SetUnit("{request}")
+    sum.SetEmptySum().SetIsMonotonic(true)
+    sum.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+    sp := sum.Sum().DataPoints().AppendEmpty()
+    sp.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+    sp.SetTimestamp(pcommon.NewTimestampFromTime(now))
+    sp.SetIntValue(42)
+    sp.SetFlags(pmetric.DefaultDataPointFlags.WithNoRecordedValue(true))
+    exemplar := sp.Exemplars().AppendEmpty()
+    exemplar.SetTimestamp(pcommon.NewTimestampFromTime(now))
+    exemplar.SetDoubleValue(1.5)
+    exemplar.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+
+    histogram := sm.Metrics().AppendEmpty()
+    histogram.SetName("http.duration")
+    histogram.SetEmptyHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+    hp := histogram.Histogram().DataPoints().AppendEmpty()
+    hp.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+    hp.SetTimestamp(pcommon.NewTimestampFromTime(now))
+
+    conn := newStandInConn()
+    exp := newTestExporter(t, conn)
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+
+    expected := map[string]any{
+        "metric_description":  "Number of requests",
+        "metric_unit":         "{request}",
+        "resource_attributes": map[string]string{"service.name": "checkout", "k8s.pod.name": "checkout-0"},
+        "resource_schema_url": "https://opentelemetry.io/schemas/1.26.0",
+        "scope_name":          "otelhttp",
+        "scope_version":       "0.56.0",
+        "scope_attributes":    map[string]string{"library.language": "go"},
+        "scope_schema_url":    "https://opentelemetry.io/schemas/1.25.0",
+        "start_time_unix":     start,
+        "flags":               uint32(1),
+        "is_monotonic":        true,
+        "temporality":         "cumulative",
+        "exemplars.value":     []float64{1.5},
+        "exemplars.trace_id":  []string{"0102030405060708090a0b0c0d0e0f10"},
+    }
+    for name, want := range expected {
+        assert.Equal(t, want, conn.value(t, "otel.metrics", name, 0), name)
+    }
+
+    assert.Equal(t, "delta", conn.value(t, "otel.metrics_histogram", "temporality", 0))
+    assert.Equal(t, "otelhttp", conn.value(t, "otel.metrics_histogram", "scope_name", 0))
+    assert.Equal(t, start, conn.value(t, "otel.metrics_histogram", "start_time_unix", 0))
+}
+
+func BenchmarkConsumeMetrics(b *testing.B) {
+    exp := newTestExporter(b, newStandInConn())
+    md := generateMetrics(10, 100)
+    rowsPerPayload := md.DataPointCount()
+
+    b.ReportAllocs()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        if err := exp.ConsumeMetrics(context.Background(), md); err != nil {
+            b.Fatal(err)
+        }
+    }
+    b.ReportMetric(float64(rowsPerPayload*b.N)/b.Elapsed().Seconds(), "rows/s")
+}
+
+func TestStartOpensAndChecksConnection(t *testing.T) {
+    conn := &recordingConn{}
+    exp := newTestExporter(t, nil)
+    exp.cfg.Endpoint = "localhost:9000"
+    exp.cfg.CreateSchema = true
+    exp.open = func(opts *clickhouse.Options) (dbConn, error) {
+        assert.Equal(t, []string{"localhost:9000"}, opts.Addr)
+        return conn, nil
+    }
+
+    require.NoError(t, exp.start(context.Background(), componenttest.NewNopHost()))
+    assert.Equal(t, 1, conn.pings)
+    assert.Equal(t, "CREATE DATABASE IF NOT EXISTS otel", conn.statements[0])
+
+    require.NoError(t, exp.Shutdown(context.Background()))
+    assert.Equal(t, 1, conn.closes)
+}
+
+func TestStartReportsRecoverableErrorWhenPingFails(t *testing.T) {
+    conn := &recordingConn{pingErr: errConnectionRefused}
+    exp := newTestExporter(t, nil)
+    exp.cfg.Endpoint = "localhost:9000"
+    exp.cfg.CreateSchema = true
+    exp.open = func(*clickhouse.Options) (dbConn, error) {
+        return conn, nil
+    }
+    host := &statusHost{Host: componenttest.NewNopHost()}
+
+    require.NoError(t, exp.start(context.Background(), host))
+    assert.Equal(t, 0, conn.closes)
+    assert.Empty(t, conn.statements)
+    require.Len(t, host.events, 1)
+    assert.Equal(t, componentstatus.StatusRecoverableError, host.events[0].Status())
+    assert.ErrorIs(t, host.events[0].Err(), errConnectionRefused)
+
+    // The schema is created by the first export once the server is back.
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateMetrics(1, 1)))
+    assert.NotEmpty(t, conn.statements)
+    require.Len(t, host.events, 2)
+    assert.Equal(t, componentstatus.StatusOK, host.events[1].Status())
+
+    statements := len(conn.statements)
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateMetrics(1, 1)))
+    assert.Len(t, conn.statements, statements)
+}
+
+func TestShutdownWithoutStart(t *testing.T) {
+    exp := newTestExporter(t, nil)
+    assert.NoError(t, exp.Shutdown(context.Background()))
+}
@@ -0,0 +1,140 @@
+// exporter/clickhouseexporter/exporter_traces.go
+package clickhouseexporter
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const insertTracesSQL = `
+    INSERT INTO %s (
+        timestamp,
+        trace_id,
+        span_id,
+        parent_span_id,
+        trace_state,
+        span_name,
+        span_kind,
+        service_name,
+        resource_attributes,
+        scope_name,
+        scope_version,
+        span_attributes,
+        duration,
+        status_code,
+        status_message,
+        events.timestamp,
+        events.name,
+        events.attributes,
+        links.trace_id,
+        links.span_id,
+        links.trace_state,
+        links.attributes
+    )
+`
+
+func (e *clickhouseExporter) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+    if err := e.ensureSchema(ctx); err != nil {
+        return err
+    }
+    spans := td.ResourceSpans()
+
+    token, err := e.deduplicationToken(func() ([]byte, error) {
+        return (&ptrace.ProtoMarshaler{}).MarshalTraces(td)
+    })
+    if err != nil {
+        return err
+    }
+    batches := newBatchSet(ctx, e.conn, e.telemetry, e.sent, token, e.cfg.InsertDeduplication)
+
+    for i := 0; i < spans.Len(); i++ {
+        rs := spans.At(i)
+        resource := rs.Resource()
+        resourceAttrs := attributesToMap(resource.Attributes())
+        queries := e.tenants.route(ctx, resource.Attributes())
+
+        var serviceName string
+        if serviceAttr, ok := resource.Attributes().Get("service.name"); ok {
+            serviceName = serviceAttr.Str()
+        }
+
+        ss := rs.ScopeSpans()
+        for j := 0; j < ss.Len(); j++ {
+            scope := ss.At(j).Scope()
+            scopeSpans := ss.At(j).Spans()
+
+            for k := 0; k < scopeSpans.Len(); k++ {
+                span := scopeSpans.At(k)
+                eventTimes, eventNames, eventAttrs := convertEvents(span.Events())
+                linkTraceIDs, linkSpanIDs, linkStates, linkAttrs := convertLinks(span.Links())
+
+                err := batches.append(queries.traces,
+                    span.StartTimestamp().AsTime().UTC(),
+                    span.TraceID().String(),
+                    span.SpanID().String(),
+                    span.ParentSpanID().String(),
+                    span.TraceState().AsRaw(),
+                    span.Name(),
+                    span.Kind().String(),
+                    serviceName,
+                    resourceAttrs,
+                    scope.Name(),
+                    scope.Version(),
+                    attributesToMap(span.Attributes()),
+                    span.EndTimestamp().AsTime().Sub(span.StartTimestamp().AsTime()).Nanoseconds(),
+                    span.Status().Code().String(),
+                    span.Status().Message(),
+                    eventTimes,
+                    eventNames,
+                    eventAttrs,
+                    linkTraceIDs,
+                    linkSpanIDs,
+                    linkStates,
+                    linkAttrs,
+                )
+                if err != nil {
+                    return e.fail(fmt.Errorf("failed to insert span %s: %w", span.Name(), err))
+                }
+            }
+        }
+    }
+
+    return e.finish(ctx, batches, signalTraces, func() int {
+        return (&ptrace.ProtoMarshaler{}).TracesSize(td)
+    })
+}
+
+// convertEvents splits span events into the parallel arrays backing the
+// events Nested column.
+func convertEvents(events ptrace.SpanEventSlice) ([]time.Time, []string, []map[string]string) {
+    times := make([]time.Time, 0, events.Len())
+    names := make([]string, 0, events.Len())
+    attrs := make([]map[string]string, 0, events.Len())
+    for i := 0; i < events.Len(); i++ {
+        event := events.At(i)
+        times = append(times, event.Timestamp().AsTime().UTC())
+        names = append(names, event.Name())
+        attrs = append(attrs, attributesToMap(event.Attributes()))
+    }
+    return times, names, attrs
+}
+
+// convertLinks splits span links into the parallel arrays backing the
+// links Nested column.
+func convertLinks(links ptrace.SpanLinkSlice) ([]string, []string, []string, []map[string]string) {
+    traceIDs := make([]string, 0, links.Len())
+    spanIDs := make([]string, 0, links.Len())
+    states := make([]string, 0, links.Len())
+    attrs := make([]map[string]string, 0, links.Len())
+    for i := 0; i < links.Len(); i++ {
+        link := links.At(i)
+        traceIDs = append(traceIDs, link.TraceID().String())
+        spanIDs = append(spanIDs, link.SpanID().String())
+        states = append(states, link.TraceState().AsRaw())
+        attrs = append(attrs, attributesToMap(link.Attributes()))
+    }
+    return traceIDs, spanIDs, states, attrs
+}
@@ -3,67 +3,148 @@ package clickhouseexporter
 
 import (
     "context"
-    "fmt"
-    "os"
+    "time"
 
     "go.opentelemetry.io/collector/component"
+    "go.opentelemetry.io/collector/config/configcompression"
+    "go.opentelemetry.io/collector/config/configretry"
+    "go.opentelemetry.io/collector/config/configtls"
+    "go.opentelemetry.io/collector/consumer"
     "go.opentelemetry.io/collector/exporter"
+    "go.opentelemetry.io/collector/exporter/exporterhelper"
+    "go.opentelemetry.io/collector/exporter/exporterhelper/exporterhelperprofiles"
+    "go.opentelemetry.io/collector/exporter/exporterprofiles"
 )
 
 const typeStr = "clickhouse"
 
-func NewFactory() exporter.Factory {
-    return exporter.NewFactory(
-        typeStr,
+func NewFactory() exporterprofiles.Factory {
+    return exporterprofiles.NewFactory(
+        component.MustNewType(typeStr),
         createDefaultConfig,
-        exporter.WithMetrics(createMetricsExporter, component.StabilityLevelBeta),
+        exporterprofiles.WithMetrics(createMetricsExporter, component.StabilityLevelBeta),
+        exporterprofiles.WithTraces(createTracesExporter, component.StabilityLevelAlpha),
+        exporterprofiles.WithLogs(createLogsExporter, component.StabilityLevelAlpha),
+        exporterprofiles.WithProfiles(createProfilesExporter, component.StabilityLevelDevelopment),
     )
 }
 
 func createDefaultConfig() component.Config {
     return &Config{
-        Endpoint: os.Getenv("CLICKHOUSE_ENDPOINT"),
-        Username: os.Getenv("CLICKHOUSE_USERNAME"),
-        Password: os.Getenv("CLICKHOUSE_PASSWORD"),
-        Database: os.Getenv("CLICKHOUSE_DATABASE"),
-        Secure:   true,
+        TimeoutConfig: exporterhelper.NewDefaultTimeoutConfig(),
+        BackOffConfig: configretry.NewDefaultBackOffConfig(),
+        QueueSettings: exporterhelper.NewDefaultQueueConfig(),
+        Protocol:           protocolNative,
+        ConnOpenStrategy:   connOpenInOrder,
+        Username:           "default",
+        Database:           "otel",
+        Secure:             true,
+        TLSSetting:         configtls.NewDefaultClientConfig(),
+        DialTimeout:        30 * time.Second,
+        MaxOpenConns:       10,
+        MaxIdleConns:       5,
+        ConnMaxLifetime:    time.Hour,
+        WaitForAsyncInsert: true,
+        Compression:        configcompression.TypeLz4,
+        Tables: TablesConfig{
+            Metrics:                     "metrics",
+            MetricsHistogram:            "metrics_histogram",
+            MetricsExponentialHistogram: "metrics_exponential_histogram",
+            MetricsSummary:              "metrics_summary",
+            Traces:                      "otel_traces",
+            Logs:                        "otel_logs",
+            ProfileSamples:              "otel_profile_samples",
+            ProfileLocations:            "otel_profile_locations",
+            ProfileFunctions:            "otel_profile_functions",
+            ProfileMappings:             "otel_profile_mappings",
+        },
+        TableEngine: TableEngine{Name: "MergeTree"},
+        TTL:         30 * 24 * time.Hour,
+        Attributes:  AttributesConfig{Mode: attributesModeString},
+        Cardinality: CardinalityConfig{Action: cardinalityActionDropLabels, HashBuckets: 64, Interval: time.Hour},
     }
 }
 
 func createMetricsExporter(
     ctx context.Context,
-    params exporter.CreateSettings,
+    set exporter.Settings,
     cfg component.Config,
 ) (exporter.Metrics, error) {
     oCfg := cfg.(*Config)
-    
-    // Validate required environment variables
-    if oCfg.Endpoint == "" {
-        oCfg.Endpoint = os.Getenv("CLICKHOUSE_ENDPOINT")
-    }
-    if oCfg.Username == "" {
-        oCfg.Username = os.Getenv("CLICKHOUSE_USERNAME")
-    }
-    if oCfg.Password == "" {
-        oCfg.Password = os.Getenv("CLICKHOUSE_PASSWORD")
-    }
-    if oCfg.Database == "" {
-        oCfg.Database = os.Getenv("CLICKHOUSE_DATABASE")
+    exp, err := newClickHouseExporter(set.TelemetrySettings, oCfg)
+    if err != nil {
+        return nil, err
     }
+    return exporterhelper.NewMetrics(ctx, set, cfg,
+        exp.ConsumeMetrics,
+        exporterhelper.WithStart(exp.start),
+        exporterhelper.WithShutdown(exp.Shutdown),
+        exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+        exporterhelper.WithTimeout(oCfg.TimeoutConfig),
+        exporterhelper.WithRetry(oCfg.BackOffConfig),
+        exporterhelper.WithQueue(oCfg.QueueSettings),
+    )
+}
 
-    // Final validation
-    if oCfg.Endpoint == "" {
-        return nil, fmt.Errorf("endpoint must be specified via config or CLICKHOUSE_ENDPOINT environment variable")
-    }
-    if oCfg.Username == "" {
-        return nil, fmt.Errorf("username must be specified via config or CLICKHOUSE_USERNAME environment variable")
-    }
-    if oCfg.Password == "" {
-        return nil, fmt.Errorf("password must be specified via config or CLICKHOUSE_PASSWORD environment variable")
+func createTracesExporter(
+    ctx context.Context,
+    set exporter.Settings,
+    cfg component.Config,
+) (exporter.Traces, error) {
+    oCfg := cfg.(*Config)
+    exp, err := newClickHouseExporter(set.TelemetrySettings, oCfg)
+    if err != nil {
+        return nil, err
     }
-    if oCfg.Database == "" {
-        return nil, fmt.Errorf("database must be specified via config or CLICKHOUSE_DATABASE environment variable")
+    return exporterhelper.NewTraces(ctx, set, cfg,
+        exp.ConsumeTraces,
+        exporterhelper.WithStart(exp.start),
+        exporterhelper.WithShutdown(exp.Shutdown),
+        exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+        exporterhelper.WithTimeout(oCfg.TimeoutConfig),
+        exporterhelper.WithRetry(oCfg.BackOffConfig),
+        exporterhelper.WithQueue(oCfg.QueueSettings),
+    )
+}
+
+func createLogsExporter(
+    ctx context.Context,
+    set exporter.Settings,
+    cfg component.Config,
+) (exporter.Logs, error) {
+    oCfg := cfg.(*Config)
+    exp, err := newClickHouseExporter(set.TelemetrySettings, oCfg)
+    if err != nil {
+        return nil, err
     }
+    return exporterhelper.NewLogs(ctx, set, cfg,
+        exp.ConsumeLogs,
+        exporterhelper.WithStart(exp.start),
+        exporterhelper.WithShutdown(exp.Shutdown),
+        exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+        exporterhelper.WithTimeout(oCfg.TimeoutConfig),
+        exporterhelper.WithRetry(oCfg.BackOffConfig),
+        exporterhelper.WithQueue(oCfg.QueueSettings),
+    )
+}
 
-    return NewClickHouseExporter(ctx)
+func createProfilesExporter(
+    ctx context.Context,
+    set exporter.Settings,
+    cfg component.Config,
+) (exporterprofiles.Profiles, error) {
+    oCfg := cfg.(*Config)
+    exp, err := newClickHouseExporter(set.TelemetrySettings, oCfg)
+    if err != nil {
+        return nil, err
+    }
+    return exporterhelperprofiles.NewProfilesExporter(ctx, set, cfg,
+        exp.ConsumeProfiles,
+        exporterhelper.WithStart(exp.start),
+        exporterhelper.WithShutdown(exp.Shutdown),
+        exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}),
+        exporterhelper.WithTimeout(oCfg.TimeoutConfig),
+        exporterhelper.WithRetry(oCfg.BackOffConfig),
+        exporterhelper.WithQueue(oCfg.QueueSettings),
+    )
 }
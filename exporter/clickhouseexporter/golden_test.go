@@ -0,0 +1,173 @@
+// exporter/clickhouseexporter/golden_test.go
+package clickhouseexporter
+
+import (
+    "context"
+    "encoding/json"
+    "flag"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.opentelemetry.io/collector/pdata/pcommon"
+    "go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+var (
+    goldenStart = pcommon.NewTimestampFromTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+    goldenTime  = pcommon.NewTimestampFromTime(time.Date(2024, 5, 1, 12, 0, 10, 500, time.UTC))
+)
+
+// newGoldenMetric returns a payload holding a single metric with the
+// resource and scope every golden test shares.
+func newGoldenMetric(name string) (pmetric.Metrics, pmetric.Metric) {
+    md := pmetric.NewMetrics()
+    rm := md.ResourceMetrics().AppendEmpty()
+    rm.SetSchemaUrl("https://opentelemetry.io/schemas/1.26.0")
+    rm.Resource().Attributes().PutStr("service.name", "checkout")
+    rm.Resource().Attributes().PutStr("host.name", "node-1")
+    sm := rm.ScopeMetrics().AppendEmpty()
+    sm.Scope().SetName("otelhttp")
+    sm.Scope().SetVersion("0.56.0")
+    sm.Scope().Attributes().PutStr("library", "net/http")
+    metric := sm.Metrics().AppendEmpty()
+    metric.SetName(name)
+    metric.SetDescription("golden " + name)
+    metric.SetUnit("1")
+    return md, metric
+}
+
+func goldenAttributes(attrs pcommon.Map, route string) {
+    attrs.PutStr("http.route", route)
+    attrs.PutInt("http.response.status_code", 200)
+}
+
+func TestGoldenRows(t *testing.T) {
+    tests := []struct {
+        name    string
+        table   string
+        metrics func() pmetric.Metrics
+    }{
+        {
+            name:  "gauge",
+            table: "otel.metrics",
+            metrics: func() pmetric.Metrics {
+                md, metric := newGoldenMetric("queue.depth")
+                dp := metric.SetEmptyGauge().DataPoints().AppendEmpty()
+                dp.SetTimestamp(goldenTime)
+                dp.SetIntValue(7)
+                goldenAttributes(dp.Attributes(), "/cart")
+                ex := dp.Exemplars().AppendEmpty()
+                ex.SetTimestamp(goldenTime)
+                ex.SetDoubleValue(9)
+                ex.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+                ex.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+                ex.FilteredAttributes().PutStr("user", "alice")
+                return md
+            },
+        },
+        {
+            name:  "sum",
+            table: "otel.metrics",
+            metrics: func() pmetric.Metrics {
+                md, metric := newGoldenMetric("http.requests")
+                sum := metric.SetEmptySum()
+                sum.SetIsMonotonic(true)
+                sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+                for i, route := range []string{"/cart", "/pay"} {
+                    dp := sum.DataPoints().AppendEmpty()
+                    dp.SetStartTimestamp(goldenStart)
+                    dp.SetTimestamp(goldenTime)
+                    dp.SetDoubleValue(float64(10 * (i + 1)))
+                    goldenAttributes(dp.Attributes(), route)
+                }
+                return md
+            },
+        },
+        {
+            name:  "histogram",
+            table: "otel.metrics_histogram",
+            metrics: func() pmetric.Metrics {
+                md, metric := newGoldenMetric("http.duration")
+                histogram := metric.SetEmptyHistogram()
+                histogram.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+                dp := histogram.DataPoints().AppendEmpty()
+                dp.SetStartTimestamp(goldenStart)
+                dp.SetTimestamp(goldenTime)
+                dp.SetCount(6)
+                dp.SetSum(42)
+                dp.SetMin(1)
+                dp.SetMax(20)
+                dp.ExplicitBounds().FromRaw([]float64{5, 10})
+                dp.BucketCounts().FromRaw([]uint64{2, 3, 1})
+                goldenAttributes(dp.Attributes(), "/cart")
+                return md
+            },
+        },
+        {
+            name:  "exponential_histogram",
+            table: "otel.metrics_exponential_histogram",
+            metrics: func() pmetric.Metrics {
+                md, metric := newGoldenMetric("rpc.duration")
+                histogram := metric.SetEmptyExponentialHistogram()
+                histogram.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+                dp := histogram.DataPoints().AppendEmpty()
+                dp.SetStartTimestamp(goldenStart)
+                dp.SetTimestamp(goldenTime)
+                dp.SetCount(5)
+                dp.SetSum(12.5)
+                dp.SetScale(2)
+                dp.SetZeroCount(1)
+                dp.Positive().SetOffset(3)
+                dp.Positive().BucketCounts().FromRaw([]uint64{1, 2})
+                dp.Negative().BucketCounts().FromRaw([]uint64{1})
+                goldenAttributes(dp.Attributes(), "/pay")
+                return md
+            },
+        },
+        {
+            name:  "summary",
+            table: "otel.metrics_summary",
+            metrics: func() pmetric.Metrics {
+                md, metric := newGoldenMetric("gc.pause")
+                dp := metric.SetEmptySummary().DataPoints().AppendEmpty()
+                dp.SetStartTimestamp(goldenStart)
+                dp.SetTimestamp(goldenTime)
+                dp.SetCount(4)
+                dp.SetSum(0.8)
+                for _, q := range [][2]float64{{0.5, 0.1}, {0.99, 0.4}} {
+                    qv := dp.QuantileValues().AppendEmpty()
+                    qv.SetQuantile(q[0])
+                    qv.SetValue(q[1])
+                }
+                goldenAttributes(dp.Attributes(), "/cart")
+                return md
+            },
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            conn := &recordingConn{}
+            exp := newTestExporter(t, conn)
+            require.NoError(t, exp.ConsumeMetrics(context.Background(), tt.metrics()))
+
+            got, err := json.MarshalIndent(conn.rows(tt.table), "", "  ")
+            require.NoError(t, err)
+            got = append(got, '\n')
+
+            path := filepath.Join("testdata", "golden", tt.name+".json")
+            if *updateGolden {
+                require.NoError(t, os.WriteFile(path, got, 0o600))
+            }
+            want, err := os.ReadFile(path)
+            require.NoError(t, err)
+            assert.JSONEq(t, string(want), string(got))
+        })
+    }
+}
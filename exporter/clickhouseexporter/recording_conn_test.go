@@ -0,0 +1,177 @@
+// exporter/clickhouseexporter/recording_conn_test.go
+package clickhouseexporter
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "sync"
+
+    "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// recordingConn is an in-process fake of the ClickHouse client. It records
+// the statements run against it and the rows of every batch it receives,
+// and can be told to fail any step.
+type recordingConn struct {
+    // version is returned as the applied schema version.
+    version uint32
+
+    pingErr       error
+    prepareErr    error
+    sendErr       error
+    // tableSendErrs fail the batches sent to the given tables.
+    tableSendErrs map[string]error
+
+    mu         sync.Mutex
+    pings      int
+    closes     int
+    statements []string
+    inserts    []recordedInsert
+    // open counts the batches prepared and not yet sent or aborted, and
+    // maxOpen the most of them open at once.
+    open    int
+    maxOpen int
+}
+
+// recordedInsert is one batch sent to a table.
+type recordedInsert struct {
+    table   string
+    columns []string
+    rows    [][]any
+}
+
+func (c *recordingConn) PrepareBatch(_ context.Context, query string, _ ...driver.PrepareBatchOption) (driver.Batch, error) {
+    if c.prepareErr != nil {
+        return nil, c.prepareErr
+    }
+    match := insertTableRe.FindStringSubmatch(query)
+    if match == nil {
+        return nil, fmt.Errorf("unsupported query: %s", query)
+    }
+    var columns []string
+    for _, name := range strings.Split(match[2], ",") {
+        columns = append(columns, strings.TrimSpace(name))
+    }
+    c.mu.Lock()
+    c.open++
+    c.maxOpen = max(c.maxOpen, c.open)
+    c.mu.Unlock()
+    return &recordingBatch{conn: c, insert: recordedInsert{table: match[1], columns: columns}}, nil
+}
+
+func (c *recordingConn) Exec(_ context.Context, query string, _ ...any) error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.statements = append(c.statements, query)
+    return nil
+}
+
+func (c *recordingConn) QueryRow(context.Context, string, ...any) driver.Row {
+    return versionRow(c.version)
+}
+
+func (c *recordingConn) Ping(context.Context) error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.pings++
+    return c.pingErr
+}
+
+func (c *recordingConn) Close() error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.closes++
+    return nil
+}
+
+// rows returns the rows sent to table as maps from column to value, in the
+// order they were sent.
+func (c *recordingConn) rows(table string) []map[string]any {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    var rows []map[string]any
+    for _, insert := range c.inserts {
+        if insert.table != table {
+            continue
+        }
+        for _, values := range insert.rows {
+            row := make(map[string]any, len(values))
+            for i, value := range values {
+                row[insert.columns[i]] = value
+            }
+            rows = append(rows, row)
+        }
+    }
+    return rows
+}
+
+type recordingBatch struct {
+    driver.Batch
+
+    conn   *recordingConn
+    insert recordedInsert
+    sent   bool
+}
+
+func (b *recordingBatch) Append(v ...any) error {
+    // Like the client, reject rows that do not match the statement.
+    if len(v) != len(b.insert.columns) {
+        return fmt.Errorf("expected %d arguments, got %d", len(b.insert.columns), len(v))
+    }
+    b.insert.rows = append(b.insert.rows, v)
+    return nil
+}
+
+func (b *recordingBatch) Send() error {
+    b.close()
+    if b.conn.sendErr != nil {
+        return b.conn.sendErr
+    }
+    b.conn.mu.Lock()
+    defer b.conn.mu.Unlock()
+    if err := b.conn.tableSendErrs[b.insert.table]; err != nil {
+        return err
+    }
+    b.conn.inserts = append(b.conn.inserts, b.insert)
+    return nil
+}
+
+func (b *recordingBatch) Abort() error {
+    b.close()
+    return nil
+}
+
+// close marks the batch as sent, which releases its connection.
+func (b *recordingBatch) close() {
+    if b.sent {
+        return
+    }
+    b.sent = true
+    b.conn.mu.Lock()
+    b.conn.open--
+    b.conn.mu.Unlock()
+}
+
+func (b *recordingBatch) IsSent() bool {
+    return b.sent
+}
+
+func (b *recordingBatch) Rows() int {
+    return len(b.insert.rows)
+}
+
+type versionRow uint32
+
+func (r versionRow) Err() error {
+    return nil
+}
+
+func (r versionRow) Scan(dest ...any) error {
+    *dest[0].(*uint32) = uint32(r)
+    return nil
+}
+
+func (r versionRow) ScanStruct(any) error {
+    return nil
+}
@@ -0,0 +1,150 @@
+// exporter/clickhouseexporter/rollups.go
+package clickhouseexporter
+
+import (
+    "fmt"
+    "strings"
+    "time"
+)
+
+// rollupSuffix names a rollup interval in its largest whole unit, e.g. 1m,
+// 1h or 1d.
+func rollupSuffix(interval time.Duration) string {
+    switch {
+    case interval%(24*time.Hour) == 0:
+        return fmt.Sprintf("%dd", interval/(24*time.Hour))
+    case interval%time.Hour == 0:
+        return fmt.Sprintf("%dh", interval/time.Hour)
+    default:
+        return fmt.Sprintf("%dm", interval/time.Minute)
+    }
+}
+
+// aggregatingEngineClause returns the AggregatingMergeTree flavour of the
+// configured engine, keeping its parameters.
+func (s schema) aggregatingEngineClause() string {
+    name := strings.TrimSuffix(s.engine.Name, "MergeTree") + "AggregatingMergeTree"
+    return fmt.Sprintf("%s(%s)", name, s.engine.Params)
+}
+
+// sortedMap returns the entries of a map column in key order. Maps keep the
+// order in which their entries were written, which is not stable.
+func sortedMap(column string) string {
+    return fmt.Sprintf("arraySort(arrayZip(mapKeys(%[1]s), mapValues(%[1]s)))", column)
+}
+
+// rollupStatements renders the rollup table of gauge and sum data points
+// at one interval, the materialized view that feeds it from the metrics
+// table, and a view computing counter increases and rates from it.
+//
+// Every bucket keeps the count, sum, min and max of the values, and the
+// last value and start time of the series. Increases of cumulative
+// counters are derived from consecutive buckets: a counter whose start time
+// changed, or whose value went down, was reset and its whole value counts
+// as the increase.
+func (s schema) rollupStatements(interval time.Duration) []string {
+    suffix := rollupSuffix(interval)
+    metrics := s.table(s.tables.Metrics)
+    rollup := s.table(s.tables.Metrics + "_rollup_" + suffix)
+    seconds := int64(interval / time.Second)
+
+    rollupTTL := s
+    rollupTTL.ttl = s.rollupTTL
+
+    table := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s%s
+(
+    bucket DateTime,
+    metric_name LowCardinality(String),
+    metric_type Enum8('gauge' = 1, 'sum' = 2, 'histogram' = 3),
+    temporality Enum8('unspecified' = 0, 'delta' = 1, 'cumulative' = 2),
+    service_name LowCardinality(String),
+    labels_hash UInt64,
+    labels SimpleAggregateFunction(any, Map(LowCardinality(String), String)),
+    labels_int SimpleAggregateFunction(any, Map(LowCardinality(String), Int64)),
+    labels_float SimpleAggregateFunction(any, Map(LowCardinality(String), Float64)),
+    labels_bool SimpleAggregateFunction(any, Map(LowCardinality(String), Bool)),
+    count SimpleAggregateFunction(sum, UInt64),
+    sum SimpleAggregateFunction(sum, Float64),
+    min SimpleAggregateFunction(min, Float64),
+    max SimpleAggregateFunction(max, Float64),
+    last AggregateFunction(argMax, Float64, DateTime64(9)),
+    last_start_time AggregateFunction(argMax, DateTime64(9), DateTime64(9))
+)
+ENGINE = %s
+PARTITION BY toYYYYMM(bucket)
+ORDER BY (metric_name, service_name, labels_hash, metric_type, temporality, bucket)%s`,
+        rollup, s.onCluster(), s.aggregatingEngineClause(), rollupTTL.ttlClause("bucket"))
+
+    view := fmt.Sprintf(`CREATE MATERIALIZED VIEW IF NOT EXISTS %s_mv%s
+TO %s
+AS SELECT
+    toStartOfInterval(timestamp, INTERVAL %d SECOND) AS bucket,
+    metric_name,
+    metric_type,
+    temporality,
+    service_name,
+    cityHash64(%s, %s, %s, %s) AS labels_hash,
+    any(labels) AS labels,
+    any(labels_int) AS labels_int,
+    any(labels_float) AS labels_float,
+    any(labels_bool) AS labels_bool,
+    count() AS count,
+    sum(value) AS sum,
+    min(value) AS min,
+    max(value) AS max,
+    argMaxState(value, timestamp) AS last,
+    argMaxState(start_time_unix, timestamp) AS last_start_time
+FROM %s
+GROUP BY bucket, metric_name, metric_type, temporality, service_name, labels_hash`,
+        rollup, s.onCluster(), rollup, seconds,
+        sortedMap("labels"), sortedMap("labels_int"), sortedMap("labels_float"), sortedMap("labels_bool"),
+        metrics)
+
+    rates := fmt.Sprintf(`CREATE VIEW IF NOT EXISTS %s%s
+AS SELECT
+    bucket,
+    metric_name,
+    service_name,
+    labels,
+    labels_int,
+    labels_float,
+    labels_bool,
+    multiIf(
+        temporality = 'delta', delta,
+        prev_bucket = toDateTime(0), if(start_time >= bucket, value, 0),
+        start_time = prev_start_time AND value >= prev_value, value - prev_value,
+        value
+    ) AS increase,
+    increase / %d AS rate
+FROM
+(
+    SELECT
+        *,
+        lagInFrame(bucket, 1, toDateTime(0)) OVER series AS prev_bucket,
+        lagInFrame(value) OVER series AS prev_value,
+        lagInFrame(start_time) OVER series AS prev_start_time
+    FROM
+    (
+        SELECT
+            bucket,
+            metric_name,
+            service_name,
+            labels_hash,
+            temporality,
+            any(labels) AS labels,
+            any(labels_int) AS labels_int,
+            any(labels_float) AS labels_float,
+            any(labels_bool) AS labels_bool,
+            sum(sum) AS delta,
+            argMaxMerge(last) AS value,
+            argMaxMerge(last_start_time) AS start_time
+        FROM %s
+        WHERE metric_type = 'sum'
+        GROUP BY bucket, metric_name, service_name, labels_hash, temporality
+    )
+    WINDOW series AS (PARTITION BY metric_name, service_name, labels_hash, temporality ORDER BY bucket ROWS BETWEEN 1 PRECEDING AND CURRENT ROW)
+)`,
+        s.table(s.tables.Metrics+"_rate_"+suffix), s.onCluster(), seconds, rollup)
+
+    return []string{table, view, rates}
+}
@@ -0,0 +1,63 @@
+// exporter/clickhouseexporter/rollups_test.go
+package clickhouseexporter
+
+import (
+    "context"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestRollupSuffix(t *testing.T) {
+    assert.Equal(t, "1m", rollupSuffix(time.Minute))
+    assert.Equal(t, "15m", rollupSuffix(15*time.Minute))
+    assert.Equal(t, "1h", rollupSuffix(time.Hour))
+    assert.Equal(t, "1d", rollupSuffix(24*time.Hour))
+}
+
+func TestRollupStatements(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.ClusterName = "main"
+    cfg.TableEngine = TableEngine{Name: "ReplicatedMergeTree", Params: "'/clickhouse/tables/{shard}/{table}', '{replica}'"}
+    cfg.Rollups.TTL = 365 * 24 * time.Hour
+
+    stmts := newSchema(cfg).rollupStatements(time.Hour)
+    require.Len(t, stmts, 3)
+
+    assert.True(t, strings.HasPrefix(stmts[0], "CREATE TABLE IF NOT EXISTS otel.metrics_rollup_1h ON CLUSTER main\n"))
+    assert.Contains(t, stmts[0], "ENGINE = ReplicatedAggregatingMergeTree('/clickhouse/tables/{shard}/{table}', '{replica}')\n")
+    assert.True(t, strings.HasSuffix(stmts[0], "TTL bucket + INTERVAL 365 DAY"))
+
+    assert.True(t, strings.HasPrefix(stmts[1], "CREATE MATERIALIZED VIEW IF NOT EXISTS otel.metrics_rollup_1h_mv ON CLUSTER main\nTO otel.metrics_rollup_1h\n"))
+    assert.Contains(t, stmts[1], "toStartOfInterval(timestamp, INTERVAL 3600 SECOND) AS bucket")
+    assert.Contains(t, stmts[1], "FROM otel.metrics\n")
+
+    assert.True(t, strings.HasPrefix(stmts[2], "CREATE VIEW IF NOT EXISTS otel.metrics_rate_1h ON CLUSTER main\n"))
+    assert.Contains(t, stmts[2], "increase / 3600 AS rate")
+    assert.Contains(t, stmts[2], "FROM otel.metrics_rollup_1h\n")
+}
+
+func TestRollupStatementsWithoutTTL(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+
+    stmts := newSchema(cfg).rollupStatements(time.Minute)
+
+    // The TTL of the raw metrics does not apply to rollups.
+    assert.Contains(t, stmts[0], "ENGINE = AggregatingMergeTree()\n")
+    assert.NotContains(t, stmts[0], "TTL")
+}
+
+func TestCreateSchemaCreatesRollups(t *testing.T) {
+    conn := &recordingConn{version: migrations[len(migrations)-1].version}
+    exp := newTestExporter(t, conn)
+    exp.cfg.Rollups.Intervals = []time.Duration{time.Minute, time.Hour}
+
+    require.NoError(t, exp.createSchema(context.Background()))
+
+    require.Len(t, conn.statements, 2+2*3)
+    assert.Contains(t, conn.statements[2], "otel.metrics_rollup_1m")
+    assert.Contains(t, conn.statements[5], "otel.metrics_rollup_1h")
+}
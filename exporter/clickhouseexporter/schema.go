@@ -0,0 +1,524 @@
+// exporter/clickhouseexporter/schema.go
+package clickhouseexporter
+
+import (
+    "context"
+    "fmt"
+    "slices"
+    "strings"
+    "time"
+
+    "go.uber.org/zap"
+)
+
+// migrationsTable records which schema migrations have been applied to the
+// database.
+const migrationsTable = "otel_schema_migrations"
+
+// migration is one versioned step of the schema. Released migrations are
+// never edited: schema changes are added as new migrations so that existing
+// deployments are upgraded in place when the exporter is updated. Every
+// statement must be idempotent, because several collectors may start at the
+// same time.
+type migration struct {
+    version     uint32
+    description string
+    statements  func(s schema) []string
+}
+
+var migrations = []migration{
+    {
+        version:     1,
+        description: "create tables",
+        statements: func(s schema) []string {
+            return []string{
+                s.createTable(s.tables.Metrics, metricsColumns, "toYYYYMM(timestamp)", "(metric_name, timestamp, service_name)"),
+                s.createTable(s.tables.MetricsHistogram, histogramColumns, "toYYYYMM(timestamp)", "(metric_name, timestamp, service_name)"),
+                s.createTable(s.tables.MetricsExponentialHistogram, exponentialHistogramColumns, "toYYYYMM(timestamp)", "(metric_name, timestamp, service_name)"),
+                s.createTable(s.tables.MetricsSummary, summaryColumns, "toYYYYMM(timestamp)", "(metric_name, timestamp, service_name)"),
+                s.createTable(s.tables.Traces, tracesColumns, "toDate(timestamp)", "(service_name, span_name, toUnixTimestamp(timestamp), trace_id)"),
+                s.createTable(s.tables.Logs, logsColumns, "toDate(timestamp)", "(service_name, severity_text, toUnixTimestamp(timestamp))"),
+            }
+        },
+    },
+    {
+        version:     2,
+        description: "add resource, scope and exemplar columns to metric tables",
+        statements: func(s schema) []string {
+            return []string{
+                s.addColumns(s.tables.Metrics, slices.Concat(metricMetaColumns, []string{
+                    "is_monotonic Bool",
+                    temporalityColumn,
+                    "exemplars.filtered_attributes Array(Map(LowCardinality(String), String))",
+                    "exemplars.timestamp Array(DateTime64(9))",
+                    "exemplars.value Array(Float64)",
+                    "exemplars.span_id Array(String)",
+                    "exemplars.trace_id Array(String)",
+                })),
+                s.addColumns(s.tables.MetricsHistogram, slices.Concat(metricMetaColumns, []string{temporalityColumn})),
+                s.addColumns(s.tables.MetricsExponentialHistogram, slices.Concat(metricMetaColumns, []string{temporalityColumn})),
+                s.addColumns(s.tables.MetricsSummary, metricMetaColumns),
+            }
+        },
+    },
+    {
+        version:     3,
+        description: "add typed attribute columns to metric tables",
+        statements: func(s schema) []string {
+            return []string{
+                s.addColumns(s.tables.Metrics, typedLabelsColumns),
+                s.addColumns(s.tables.MetricsHistogram, typedLabelsColumns),
+                s.addColumns(s.tables.MetricsExponentialHistogram, typedLabelsColumns),
+                s.addColumns(s.tables.MetricsSummary, typedLabelsColumns),
+            }
+        },
+    },
+    {
+        version:     4,
+        description: "create profile tables",
+        statements: func(s schema) []string {
+            return []string{
+                s.createTable(s.tables.ProfileSamples, profileSamplesColumns, "toDate(timestamp)", "(service_name, sample_type, toUnixTimestamp(timestamp))"),
+                s.createLookupTable(s.tables.ProfileLocations, profileLocationsColumns, "location_id"),
+                s.createLookupTable(s.tables.ProfileFunctions, profileFunctionsColumns, "function_id"),
+                s.createLookupTable(s.tables.ProfileMappings, profileMappingsColumns, "mapping_id"),
+            }
+        },
+    },
+}
+
+// typedLabelsColumns hold the integer, double and boolean attributes in the
+// typed attributes mode.
+var typedLabelsColumns = []string{
+    "labels_int Map(LowCardinality(String), Int64)",
+    "labels_float Map(LowCardinality(String), Float64)",
+    "labels_bool Map(LowCardinality(String), Bool)",
+}
+
+// metricMetaColumns keep the metric, resource and scope fields of every data
+// point, so that the rows can be converted back to OTLP.
+var metricMetaColumns = []string{
+    "metric_description String",
+    "metric_unit LowCardinality(String)",
+    "resource_attributes Map(LowCardinality(String), String)",
+    "resource_schema_url String",
+    "scope_name String",
+    "scope_version String",
+    "scope_attributes Map(LowCardinality(String), String)",
+    "scope_schema_url String",
+    "start_time_unix DateTime64(9)",
+    "flags UInt32",
+}
+
+const temporalityColumn = "temporality Enum8('unspecified' = 0, 'delta' = 1, 'cumulative' = 2)"
+
+const metricsColumns = `
+    timestamp DateTime64(9),
+    metric_name LowCardinality(String),
+    metric_type Enum8('gauge' = 1, 'sum' = 2, 'histogram' = 3),
+    value Float64,
+    labels Map(LowCardinality(String), String),
+    service_name LowCardinality(String),
+    host_name LowCardinality(String)`
+
+const histogramColumns = `
+    timestamp DateTime64(9),
+    metric_name LowCardinality(String),
+    labels Map(LowCardinality(String), String),
+    service_name LowCardinality(String),
+    host_name LowCardinality(String),
+    count UInt64,
+    sum Nullable(Float64),
+    min Nullable(Float64),
+    max Nullable(Float64),
+    bucket_counts Array(UInt64),
+    explicit_bounds Array(Float64),
+    exemplars Nested
+    (
+        filtered_attributes Map(LowCardinality(String), String),
+        timestamp DateTime64(9),
+        value Float64,
+        span_id String,
+        trace_id String
+    )`
+
+const exponentialHistogramColumns = `
+    timestamp DateTime64(9),
+    metric_name LowCardinality(String),
+    labels Map(LowCardinality(String), String),
+    service_name LowCardinality(String),
+    host_name LowCardinality(String),
+    count UInt64,
+    sum Nullable(Float64),
+    min Nullable(Float64),
+    max Nullable(Float64),
+    scale Int32,
+    zero_count UInt64,
+    positive_offset Int32,
+    positive_bucket_counts Array(UInt64),
+    negative_offset Int32,
+    negative_bucket_counts Array(UInt64),
+    exemplars Nested
+    (
+        filtered_attributes Map(LowCardinality(String), String),
+        timestamp DateTime64(9),
+        value Float64,
+        span_id String,
+        trace_id String
+    )`
+
+const summaryColumns = `
+    timestamp DateTime64(9),
+    metric_name LowCardinality(String),
+    labels Map(LowCardinality(String), String),
+    service_name LowCardinality(String),
+    host_name LowCardinality(String),
+    count UInt64,
+    sum Float64,
+    quantiles Nested
+    (
+        quantile Float64,
+        value Float64
+    )`
+
+const tracesColumns = `
+    timestamp DateTime64(9),
+    trace_id String,
+    span_id String,
+    parent_span_id String,
+    trace_state String,
+    span_name LowCardinality(String),
+    span_kind LowCardinality(String),
+    service_name LowCardinality(String),
+    resource_attributes Map(LowCardinality(String), String),
+    scope_name String,
+    scope_version String,
+    span_attributes Map(LowCardinality(String), String),
+    duration Int64,
+    status_code LowCardinality(String),
+    status_message String,
+    events Nested
+    (
+        timestamp DateTime64(9),
+        name LowCardinality(String),
+        attributes Map(LowCardinality(String), String)
+    ),
+    links Nested
+    (
+        trace_id String,
+        span_id String,
+        trace_state String,
+        attributes Map(LowCardinality(String), String)
+    ),
+    INDEX idx_trace_id trace_id TYPE bloom_filter(0.001) GRANULARITY 1`
+
+const logsColumns = `
+    timestamp DateTime64(9),
+    observed_timestamp DateTime64(9),
+    trace_id String,
+    span_id String,
+    trace_flags UInt32,
+    severity_text LowCardinality(String),
+    severity_number Int32,
+    service_name LowCardinality(String),
+    body String,
+    resource_attributes Map(LowCardinality(String), String),
+    scope_name String,
+    scope_version String,
+    scope_attributes Map(LowCardinality(String), String),
+    log_attributes Map(LowCardinality(String), String),
+    INDEX idx_trace_id trace_id TYPE bloom_filter(0.001) GRANULARITY 1,
+    INDEX idx_body body TYPE tokenbf_v1(32768, 3, 0) GRANULARITY 1`
+
+// profileSamplesColumns hold one row per sample and sample type. The stack
+// is kept as the ids of its locations, leaf first.
+const profileSamplesColumns = `
+    timestamp DateTime64(9),
+    profile_id String,
+    service_name LowCardinality(String),
+    resource_attributes Map(LowCardinality(String), String),
+    scope_name String,
+    scope_version String,
+    profile_attributes Map(LowCardinality(String), String),
+    sample_type LowCardinality(String),
+    sample_unit LowCardinality(String),
+    period_type LowCardinality(String),
+    period_unit LowCardinality(String),
+    period Int64,
+    value Int64,
+    location_ids Array(UInt64),
+    attributes Map(LowCardinality(String), String),
+    trace_id String,
+    span_id String`
+
+// profileLocationsColumns hold the locations of profile stacks. A location
+// with inlined functions has one function per line, innermost first.
+const profileLocationsColumns = `
+    location_id UInt64,
+    mapping_id UInt64,
+    address UInt64,
+    function_ids Array(UInt64),
+    lines Array(Int64),
+    line_columns Array(Int64),
+    is_folded Bool,
+    last_seen DateTime`
+
+const profileFunctionsColumns = `
+    function_id UInt64,
+    name String,
+    system_name String,
+    filename String,
+    start_line Int64,
+    last_seen DateTime`
+
+const profileMappingsColumns = `
+    mapping_id UInt64,
+    memory_start UInt64,
+    memory_limit UInt64,
+    file_offset UInt64,
+    filename String,
+    build_id String,
+    build_id_kind LowCardinality(String),
+    has_functions Bool,
+    has_filenames Bool,
+    has_line_numbers Bool,
+    has_inline_frames Bool,
+    last_seen DateTime`
+
+// schema renders DDL for the configured database, table names, engine,
+// cluster, partitioning and TTL.
+type schema struct {
+    database    string
+    tables      TablesConfig
+    cluster     string
+    engine      TableEngine
+    partitionBy string
+    ttl         time.Duration
+    promoted    []PromotedAttribute
+    rollups     []time.Duration
+    rollupTTL   time.Duration
+    deduplicate bool
+    codecs      CodecsConfig
+}
+
+func newSchema(cfg *Config) schema {
+    return schema{
+        database:    cfg.Database,
+        tables:      cfg.Tables,
+        cluster:     cfg.ClusterName,
+        engine:      cfg.TableEngine,
+        partitionBy: cfg.PartitionBy,
+        ttl:         cfg.TTL,
+        promoted:    cfg.Attributes.Promoted,
+        rollups:     cfg.Rollups.Intervals,
+        rollupTTL:   cfg.Rollups.TTL,
+        deduplicate: cfg.InsertDeduplication,
+        codecs:      cfg.Codecs,
+    }
+}
+
+// table returns the fully qualified name of a table.
+func (s schema) table(name string) string {
+    return s.database + "." + name
+}
+
+// forTenant returns the schema of the database of a tenant.
+func (s schema) forTenant(tenant string) schema {
+    s.database += "_" + tenant
+    return s
+}
+
+func (s schema) onCluster() string {
+    if s.cluster == "" {
+        return ""
+    }
+    return " ON CLUSTER " + s.cluster
+}
+
+func (s schema) engineClause() string {
+    return fmt.Sprintf("%s(%s)", s.engine.Name, s.engine.Params)
+}
+
+// ttlClause expresses the TTL in the largest whole unit so that the DDL
+// stays readable.
+func (s schema) ttlClause(timeExpr string) string {
+    if s.ttl <= 0 {
+        return ""
+    }
+    switch {
+    case s.ttl%(24*time.Hour) == 0:
+        return fmt.Sprintf("\nTTL %s + INTERVAL %d DAY", timeExpr, s.ttl/(24*time.Hour))
+    case s.ttl%time.Hour == 0:
+        return fmt.Sprintf("\nTTL %s + INTERVAL %d HOUR", timeExpr, s.ttl/time.Hour)
+    case s.ttl%time.Minute == 0:
+        return fmt.Sprintf("\nTTL %s + INTERVAL %d MINUTE", timeExpr, s.ttl/time.Minute)
+    default:
+        return fmt.Sprintf("\nTTL %s + INTERVAL %d SECOND", timeExpr, s.ttl/time.Second)
+    }
+}
+
+// createTable renders a CREATE TABLE statement. The configured partition
+// expression, if any, replaces the table's default one.
+func (s schema) createTable(name, columns, partitionBy, orderBy string) string {
+    if s.partitionBy != "" {
+        partitionBy = s.partitionBy
+    }
+    return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s%s
+(%s
+)
+ENGINE = %s
+PARTITION BY %s
+ORDER BY %s%s`,
+        s.table(name), s.onCluster(), columns, s.engineClause(), partitionBy, orderBy, s.ttlClause("toDateTime(timestamp)"))
+}
+
+// createLookupTable renders the DDL of a table keyed by a content hash, such
+// as the profile locations. Every payload writes the rows it references
+// again, and the ReplacingMergeTree engine keeps the one seen last. Rows
+// that were not seen for the TTL are removed.
+func (s schema) createLookupTable(name, columns, key string) string {
+    return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s%s
+(%s
+)
+ENGINE = %s
+ORDER BY %s%s`,
+        s.table(name), s.onCluster(), columns, s.replacingEngineClause("last_seen"), key, s.ttlClause("last_seen"))
+}
+
+// replacingEngineClause returns the ReplacingMergeTree variant of the
+// configured engine with the given version column.
+func (s schema) replacingEngineClause(version string) string {
+    name := strings.TrimSuffix(s.engine.Name, "MergeTree") + "ReplacingMergeTree"
+    if s.engine.Params == "" {
+        return fmt.Sprintf("%s(%s)", name, version)
+    }
+    return fmt.Sprintf("%s(%s, %s)", name, s.engine.Params, version)
+}
+
+// addColumns renders an ALTER TABLE statement adding the columns that do not
+// exist yet.
+func (s schema) addColumns(name string, columns []string) string {
+    clauses := make([]string, 0, len(columns))
+    for _, column := range columns {
+        clauses = append(clauses, "\n    ADD COLUMN IF NOT EXISTS "+column)
+    }
+    return fmt.Sprintf("ALTER TABLE %s%s%s", s.table(name), s.onCluster(), strings.Join(clauses, ","))
+}
+
+// deduplicationWindow is the number of recent inserts whose tokens a plain
+// MergeTree table remembers. Replicated tables keep 1000 by default.
+const deduplicationWindow = 1000
+
+// deduplicationStatements turn on insert deduplication for the tables of a
+// plain MergeTree engine, which do not deduplicate by default.
+func (s schema) deduplicationStatements() []string {
+    if !s.deduplicate || s.engine.Name != "MergeTree" {
+        return nil
+    }
+    tables := []string{
+        s.tables.Metrics, s.tables.MetricsHistogram, s.tables.MetricsExponentialHistogram, s.tables.MetricsSummary,
+        s.tables.Traces, s.tables.Logs,
+        s.tables.ProfileSamples, s.tables.ProfileLocations, s.tables.ProfileFunctions, s.tables.ProfileMappings,
+    }
+    statements := make([]string, 0, len(tables))
+    for _, name := range tables {
+        statements = append(statements, fmt.Sprintf("ALTER TABLE %s%s MODIFY SETTING non_replicated_deduplication_window = %d",
+            s.table(name), s.onCluster(), deduplicationWindow))
+    }
+    return statements
+}
+
+// createSchema creates the configured database and the database of every
+// tenant, and brings their tables up to date.
+func (e *clickhouseExporter) createSchema(ctx context.Context) error {
+    for _, s := range tenantSchemas(e.cfg) {
+        if err := e.applySchema(ctx, s); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// applySchema creates a database and brings its tables up to date by
+// applying every migration newer than the recorded schema version.
+func (e *clickhouseExporter) applySchema(ctx context.Context, s schema) error {
+    if err := e.conn.Exec(ctx, fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s%s", s.database, s.onCluster())); err != nil {
+        return fmt.Errorf("failed to create database %s: %w", s.database, err)
+    }
+
+    err := e.conn.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s%s
+(
+    version UInt32,
+    description String,
+    applied_at DateTime DEFAULT now()
+)
+ENGINE = %s
+ORDER BY version`, s.table(migrationsTable), s.onCluster(), s.engineClause()))
+    if err != nil {
+        return fmt.Errorf("failed to create migrations table: %w", err)
+    }
+
+    var current uint32
+    row := e.conn.QueryRow(ctx, fmt.Sprintf("SELECT max(version) FROM %s", s.table(migrationsTable)))
+    if err := row.Scan(&current); err != nil {
+        return fmt.Errorf("failed to read schema version: %w", err)
+    }
+
+    for _, m := range migrations {
+        if m.version <= current {
+            continue
+        }
+        for _, stmt := range m.statements(s) {
+            if err := e.conn.Exec(ctx, stmt); err != nil {
+                return fmt.Errorf("failed to apply schema migration %d (%s): %w", m.version, m.description, err)
+            }
+        }
+        err := e.conn.Exec(ctx,
+            fmt.Sprintf("INSERT INTO %s (version, description) VALUES (?, ?)", s.table(migrationsTable)),
+            m.version, m.description,
+        )
+        if err != nil {
+            return fmt.Errorf("failed to record schema migration %d: %w", m.version, err)
+        }
+        e.logger.Info("Applied ClickHouse schema migration",
+            zap.String("database", s.database),
+            zap.Uint32("version", m.version),
+            zap.String("description", m.description),
+        )
+    }
+
+    // Promoted attribute columns depend on the configuration rather than on
+    // the schema version, so they are checked on every start.
+    if len(s.promoted) > 0 {
+        columns := promotedColumnDefinitions(s.promoted)
+        for _, name := range []string{s.tables.Metrics, s.tables.MetricsHistogram, s.tables.MetricsExponentialHistogram, s.tables.MetricsSummary} {
+            if err := e.conn.Exec(ctx, s.addColumns(name, columns)); err != nil {
+                return fmt.Errorf("failed to add promoted attribute columns to %s: %w", name, err)
+            }
+        }
+    }
+
+    for _, stmt := range s.deduplicationStatements() {
+        if err := e.conn.Exec(ctx, stmt); err != nil {
+            return fmt.Errorf("failed to enable insert deduplication: %w", err)
+        }
+    }
+
+    for _, stmt := range s.codecStatements() {
+        if err := e.conn.Exec(ctx, stmt); err != nil {
+            return fmt.Errorf("failed to set column codecs: %w", err)
+        }
+    }
+
+    // Rollups are configuration too. They are created after the migrations,
+    // which add the columns their views read.
+    for _, interval := range s.rollups {
+        for _, stmt := range s.rollupStatements(interval) {
+            if err := e.conn.Exec(ctx, stmt); err != nil {
+                return fmt.Errorf("failed to create %s rollup: %w", rollupSuffix(interval), err)
+            }
+        }
+    }
+
+    return nil
+}
@@ -0,0 +1,141 @@
+// exporter/clickhouseexporter/schema_test.go
+package clickhouseexporter
+
+import (
+    "context"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+)
+
+func TestCreateSchema(t *testing.T) {
+    conn := &recordingConn{}
+    exp := newTestExporter(t, conn)
+
+    require.NoError(t, exp.createSchema(context.Background()))
+
+    // Every migration runs its statements and records its version.
+    expected := 2
+    for _, m := range migrations {
+        expected += len(m.statements(newSchema(exp.cfg))) + 1
+    }
+    require.Len(t, conn.statements, expected)
+    assert.Equal(t, "CREATE DATABASE IF NOT EXISTS otel", conn.statements[0])
+    assert.Contains(t, conn.statements[1], "CREATE TABLE IF NOT EXISTS otel.otel_schema_migrations")
+    assert.Contains(t, conn.statements[2], "CREATE TABLE IF NOT EXISTS otel.metrics\n")
+    assert.Contains(t, conn.statements[len(conn.statements)-1], "INSERT INTO otel.otel_schema_migrations")
+}
+
+func TestCreateSchemaSkipsAppliedMigrations(t *testing.T) {
+    conn := &recordingConn{version: migrations[len(migrations)-1].version}
+    exp := newTestExporter(t, conn)
+
+    require.NoError(t, exp.createSchema(context.Background()))
+
+    // Only the database and the migrations table are checked.
+    assert.Len(t, conn.statements, 2)
+}
+
+func TestCreateSchemaAddsPromotedColumns(t *testing.T) {
+    conn := &recordingConn{version: migrations[len(migrations)-1].version}
+    exp := newTestExporter(t, conn)
+    exp.cfg.Attributes.Promoted = []PromotedAttribute{{Key: "http.request.method"}}
+
+    require.NoError(t, exp.createSchema(context.Background()))
+
+    // One ALTER per metric table, after the migrations.
+    require.Len(t, conn.statements, 2+4)
+    assert.Equal(t, "ALTER TABLE otel.metrics\n    ADD COLUMN IF NOT EXISTS attr_http_request_method LowCardinality(String)", conn.statements[2])
+    assert.Contains(t, conn.statements[5], "ALTER TABLE otel.metrics_summary\n")
+}
+
+func TestMigrationVersionsAreOrdered(t *testing.T) {
+    for i := 1; i < len(migrations); i++ {
+        assert.Greater(t, migrations[i].version, migrations[i-1].version)
+    }
+}
+
+func TestCreateTableDDL(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Database = "telemetry"
+    cfg.ClusterName = "main"
+    cfg.TableEngine = TableEngine{Name: "ReplicatedMergeTree", Params: "'/clickhouse/tables/{shard}/{table}', '{replica}'"}
+    cfg.TTL = 12 * time.Hour
+    cfg.PartitionBy = "toStartOfHour(timestamp)"
+
+    ddl := newSchema(cfg).createTable("metrics", metricsColumns, "toYYYYMM(timestamp)", "(metric_name, timestamp)")
+
+    assert.True(t, strings.HasPrefix(ddl, "CREATE TABLE IF NOT EXISTS telemetry.metrics ON CLUSTER main\n"))
+    assert.Contains(t, ddl, "ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{table}', '{replica}')\n")
+    assert.Contains(t, ddl, "PARTITION BY toStartOfHour(timestamp)\n")
+    assert.True(t, strings.HasSuffix(ddl, "TTL toDateTime(timestamp) + INTERVAL 12 HOUR"))
+}
+
+func TestCreateTableDDLWithoutTTL(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.TTL = 0
+
+    ddl := newSchema(cfg).createTable("metrics", metricsColumns, "toYYYYMM(timestamp)", "(metric_name, timestamp)")
+
+    assert.Contains(t, ddl, "ENGINE = MergeTree()\n")
+    assert.Contains(t, ddl, "PARTITION BY toYYYYMM(timestamp)\n")
+    assert.NotContains(t, ddl, "TTL")
+}
+
+func TestAddColumnsDDL(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.ClusterName = "main"
+
+    ddl := newSchema(cfg).addColumns("metrics_summary", []string{"scope_name String", "flags UInt32"})
+
+    assert.Equal(t, "ALTER TABLE otel.metrics_summary ON CLUSTER main\n    ADD COLUMN IF NOT EXISTS scope_name String,\n    ADD COLUMN IF NOT EXISTS flags UInt32", ddl)
+}
+
+func TestCreateSchemaSetsCodecs(t *testing.T) {
+    conn := &recordingConn{version: migrations[len(migrations)-1].version}
+    exp := newTestExporter(t, conn)
+    require.NoError(t, exp.createSchema(context.Background()))
+    for _, stmt := range conn.statements {
+        assert.NotContains(t, stmt, "CODEC")
+    }
+
+    exp.cfg.Codecs = CodecsConfig{Timestamp: "DoubleDelta", Value: "Gorilla", ZSTDLevel: 3}
+    require.NoError(t, exp.createSchema(context.Background()))
+    assert.Contains(t, conn.statements, `ALTER TABLE otel.metrics
+    MODIFY COLUMN timestamp CODEC(DoubleDelta, ZSTD(3)),
+    MODIFY COLUMN start_time_unix CODEC(DoubleDelta, ZSTD(3)),
+    MODIFY COLUMN value CODEC(Gorilla, ZSTD(3))`)
+    assert.Contains(t, conn.statements, `ALTER TABLE otel.otel_logs
+    MODIFY COLUMN timestamp CODEC(DoubleDelta, ZSTD(3)),
+    MODIFY COLUMN observed_timestamp CODEC(DoubleDelta, ZSTD(3))`)
+
+    // A ZSTD level alone applies to the value columns too.
+    conn.statements = nil
+    exp.cfg.Codecs = CodecsConfig{Timestamp: "Delta", ZSTDLevel: 1}
+    require.NoError(t, exp.createSchema(context.Background()))
+    assert.Contains(t, conn.statements, `ALTER TABLE otel.metrics_summary
+    MODIFY COLUMN timestamp CODEC(Delta, ZSTD(1)),
+    MODIFY COLUMN start_time_unix CODEC(Delta, ZSTD(1)),
+    MODIFY COLUMN sum CODEC(ZSTD(1))`)
+}
+
+func TestCreateSchemaEnablesDeduplication(t *testing.T) {
+    conn := &recordingConn{version: migrations[len(migrations)-1].version}
+    exp := newTestExporter(t, conn)
+    exp.cfg.InsertDeduplication = true
+
+    require.NoError(t, exp.createSchema(context.Background()))
+    assert.Contains(t, conn.statements, "ALTER TABLE otel.metrics MODIFY SETTING non_replicated_deduplication_window = 1000")
+    assert.Contains(t, conn.statements, "ALTER TABLE otel.otel_logs MODIFY SETTING non_replicated_deduplication_window = 1000")
+
+    // Replicated tables deduplicate inserts by default.
+    conn.statements = nil
+    exp.cfg.TableEngine = TableEngine{Name: "ReplicatedMergeTree"}
+    require.NoError(t, exp.createSchema(context.Background()))
+    for _, stmt := range conn.statements {
+        assert.NotContains(t, stmt, "non_replicated_deduplication_window")
+    }
+}
@@ -0,0 +1,160 @@
+// exporter/clickhouseexporter/telemetry.go
+package clickhouseexporter
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "time"
+
+    "go.opentelemetry.io/collector/component"
+    "go.opentelemetry.io/collector/component/componentstatus"
+    "go.opentelemetry.io/collector/consumer/consumererror"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/metric"
+)
+
+const scopeName = "github.com/open-telemetry/opentelemetry-collector-contrib/exporter/clickhouseexporter"
+
+// telemetry records the internal metrics of the exporter. Insert
+// measurements carry the table they refer to, payload sizes the signal.
+type telemetry struct {
+    rowsWritten        metric.Int64Counter
+    bytesWritten       metric.Int64Counter
+    insertErrors       metric.Int64Counter
+    insertDuration     metric.Float64Histogram
+    batchRows          metric.Int64Histogram
+    // cardinalityLimited and offendingKeys carry the cardinality action.
+    cardinalityLimited metric.Int64Counter
+    offendingKeys      metric.Int64Counter
+}
+
+func newTelemetry(set component.TelemetrySettings) (*telemetry, error) {
+    meter := set.MeterProvider.Meter(scopeName)
+
+    var t telemetry
+    var err, errs error
+    t.rowsWritten, err = meter.Int64Counter(
+        "otelcol_exporter_clickhouse_rows_written",
+        metric.WithDescription("Number of rows successfully inserted into ClickHouse."),
+        metric.WithUnit("{rows}"),
+    )
+    errs = errors.Join(errs, err)
+    t.bytesWritten, err = meter.Int64Counter(
+        "otelcol_exporter_clickhouse_bytes_written",
+        metric.WithDescription("OTLP encoded size of the payloads successfully inserted into ClickHouse."),
+        metric.WithUnit("By"),
+    )
+    errs = errors.Join(errs, err)
+    t.insertErrors, err = meter.Int64Counter(
+        "otelcol_exporter_clickhouse_insert_errors",
+        metric.WithDescription("Number of inserts into ClickHouse that failed."),
+        metric.WithUnit("{inserts}"),
+    )
+    errs = errors.Join(errs, err)
+    t.insertDuration, err = meter.Float64Histogram(
+        "otelcol_exporter_clickhouse_insert_duration",
+        metric.WithDescription("Duration of inserts into ClickHouse, including failed ones."),
+        metric.WithUnit("s"),
+    )
+    errs = errors.Join(errs, err)
+    t.batchRows, err = meter.Int64Histogram(
+        "otelcol_exporter_clickhouse_batch_rows",
+        metric.WithDescription("Number of rows in each block sent to ClickHouse."),
+        metric.WithUnit("{rows}"),
+    )
+    errs = errors.Join(errs, err)
+    t.cardinalityLimited, err = meter.Int64Counter(
+        "otelcol_exporter_clickhouse_cardinality_limited_points",
+        metric.WithDescription("Number of data points whose labels were dropped or hashed, or that were rejected, by the cardinality limits."),
+        metric.WithUnit("{points}"),
+    )
+    errs = errors.Join(errs, err)
+    t.offendingKeys, err = meter.Int64Counter(
+        "otelcol_exporter_clickhouse_cardinality_offending_keys",
+        metric.WithDescription("Number of label keys dropped or hashed from the data points of a metric over its cardinality limit."),
+        metric.WithUnit("{keys}"),
+    )
+    errs = errors.Join(errs, err)
+    return &t, errs
+}
+
+func tableAttribute(table string) metric.MeasurementOption {
+    return metric.WithAttributeSet(attribute.NewSet(attribute.String("table", table)))
+}
+
+// recordInsert records one insert of rows into table that took duration.
+func (t *telemetry) recordInsert(ctx context.Context, table string, rows int, duration time.Duration, err error) {
+    attrs := tableAttribute(table)
+    t.insertDuration.Record(ctx, duration.Seconds(), attrs)
+    if err != nil {
+        t.insertErrors.Add(ctx, 1, attrs)
+        return
+    }
+    t.rowsWritten.Add(ctx, int64(rows), attrs)
+    t.batchRows.Record(ctx, int64(rows), attrs)
+}
+
+// recordPrepareError records an insert into table that could not even be
+// prepared, such as when the server is unreachable.
+func (t *telemetry) recordPrepareError(ctx context.Context, table string) {
+    t.insertErrors.Add(ctx, 1, tableAttribute(table))
+}
+
+// recordBytes records the OTLP size of a payload written to ClickHouse.
+func (t *telemetry) recordBytes(ctx context.Context, signal string, size int) {
+    t.bytesWritten.Add(ctx, int64(size), metric.WithAttributeSet(attribute.NewSet(attribute.String("signal", signal))))
+}
+
+func actionAttribute(action string) metric.MeasurementOption {
+    return metric.WithAttributeSet(attribute.NewSet(attribute.String("action", action)))
+}
+
+// recordCardinalityLimited records a data point changed or rejected by the
+// cardinality limits.
+func (t *telemetry) recordCardinalityLimited(ctx context.Context, action string) {
+    t.cardinalityLimited.Add(ctx, 1, actionAttribute(action))
+}
+
+// recordOffendingKey records a label key becoming an offending key.
+func (t *telemetry) recordOffendingKey(ctx context.Context, action string) {
+    t.offendingKeys.Add(ctx, 1, actionAttribute(action))
+}
+
+// statusReporter reports the health of the connection to ClickHouse to the
+// host. Only changes are reported, so a steady stream of failing exports
+// results in a single StatusRecoverableError event.
+type statusReporter struct {
+    mu     sync.Mutex
+    host   component.Host
+    failed bool
+}
+
+func (s *statusReporter) setHost(host component.Host) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.host = host
+}
+
+// report reports the outcome of an export or a connection attempt. Errors
+// that are permanent are caused by the data and say nothing about the
+// health of the server, so they are ignored.
+func (s *statusReporter) report(err error) {
+    if err != nil && consumererror.IsPermanent(err) {
+        return
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.host == nil {
+        return
+    }
+    switch {
+    case err != nil && !s.failed:
+        s.failed = true
+        componentstatus.ReportStatus(s.host, componentstatus.NewRecoverableErrorEvent(err))
+    case err == nil && s.failed:
+        s.failed = false
+        componentstatus.ReportStatus(s.host, componentstatus.NewEvent(componentstatus.StatusOK))
+    }
+}
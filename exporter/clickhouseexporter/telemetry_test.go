@@ -0,0 +1,134 @@
+// exporter/clickhouseexporter/telemetry_test.go
+package clickhouseexporter
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "github.com/ClickHouse/clickhouse-go/v2"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.opentelemetry.io/collector/component"
+    "go.opentelemetry.io/collector/component/componentstatus"
+    "go.opentelemetry.io/collector/component/componenttest"
+    "go.opentelemetry.io/collector/pdata/pmetric"
+    "go.opentelemetry.io/otel/attribute"
+    sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+    "go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// errConnectionRefused is returned by a server that cannot be reached.
+var errConnectionRefused = errors.New("dial tcp 127.0.0.1:9000: connect: connection refused")
+
+// statusHost records the status events reported by the exporter.
+type statusHost struct {
+    component.Host
+    events []*componentstatus.Event
+}
+
+func (h *statusHost) Report(event *componentstatus.Event) {
+    h.events = append(h.events, event)
+}
+
+func newTelemetryTestExporter(t *testing.T) (*clickhouseExporter, *sdkmetric.ManualReader) {
+    reader := sdkmetric.NewManualReader()
+    set := componenttest.NewNopTelemetrySettings()
+    set.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+    exp, err := newClickHouseExporter(set, createDefaultConfig().(*Config))
+    require.NoError(t, err)
+    return exp, reader
+}
+
+// collectSums returns the value of every data point of the integer sum or
+// histogram metric name, keyed by the value of its attribute key.
+func collectSums(t *testing.T, reader *sdkmetric.ManualReader, name string, key attribute.Key) map[string]int64 {
+    var rm metricdata.ResourceMetrics
+    require.NoError(t, reader.Collect(context.Background(), &rm))
+    values := make(map[string]int64)
+    for _, sm := range rm.ScopeMetrics {
+        for _, m := range sm.Metrics {
+            if m.Name != name {
+                continue
+            }
+            switch data := m.Data.(type) {
+            case metricdata.Sum[int64]:
+                for _, dp := range data.DataPoints {
+                    v, _ := dp.Attributes.Value(key)
+                    values[v.AsString()] += dp.Value
+                }
+            case metricdata.Histogram[int64]:
+                for _, dp := range data.DataPoints {
+                    v, _ := dp.Attributes.Value(key)
+                    values[v.AsString()] += dp.Sum
+                }
+            case metricdata.Histogram[float64]:
+                for _, dp := range data.DataPoints {
+                    v, _ := dp.Attributes.Value(key)
+                    values[v.AsString()] += int64(dp.Count)
+                }
+            }
+        }
+    }
+    return values
+}
+
+func TestTelemetryRecordsInserts(t *testing.T) {
+    exp, reader := newTelemetryTestExporter(t)
+    exp.conn = newStandInConn()
+
+    md := generateMetrics(2, 5)
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+
+    assert.Equal(t, map[string]int64{"otel.metrics": 20, "otel.metrics_histogram": 10},
+        collectSums(t, reader, "otelcol_exporter_clickhouse_rows_written", "table"))
+    assert.Equal(t, map[string]int64{"otel.metrics": 20, "otel.metrics_histogram": 10},
+        collectSums(t, reader, "otelcol_exporter_clickhouse_batch_rows", "table"))
+    assert.Equal(t, map[string]int64{"otel.metrics": 1, "otel.metrics_histogram": 1},
+        collectSums(t, reader, "otelcol_exporter_clickhouse_insert_duration", "table"))
+    assert.Equal(t, map[string]int64{"metrics": int64((&pmetric.ProtoMarshaler{}).MetricsSize(md))},
+        collectSums(t, reader, "otelcol_exporter_clickhouse_bytes_written", "signal"))
+    assert.Empty(t, collectSums(t, reader, "otelcol_exporter_clickhouse_insert_errors", "table"))
+}
+
+func TestTelemetryRecordsErrors(t *testing.T) {
+    exp, reader := newTelemetryTestExporter(t)
+    exp.conn = &recordingConn{prepareErr: errConnectionRefused}
+
+    require.Error(t, exp.ConsumeMetrics(context.Background(), generateMetrics(1, 1)))
+
+    // Every table of the payload is tried.
+    assert.Equal(t, map[string]int64{"otel.metrics": 1, "otel.metrics_histogram": 1},
+        collectSums(t, reader, "otelcol_exporter_clickhouse_insert_errors", "table"))
+    assert.Empty(t, collectSums(t, reader, "otelcol_exporter_clickhouse_rows_written", "table"))
+    assert.Empty(t, collectSums(t, reader, "otelcol_exporter_clickhouse_bytes_written", "signal"))
+}
+
+func TestStatusReportsUnreachableServer(t *testing.T) {
+    exp, _ := newTelemetryTestExporter(t)
+    host := &statusHost{Host: componenttest.NewNopHost()}
+    exp.status.setHost(host)
+
+    exp.conn = &recordingConn{prepareErr: errConnectionRefused}
+    for i := 0; i < 3; i++ {
+        require.Error(t, exp.ConsumeMetrics(context.Background(), generateMetrics(1, 1)))
+    }
+    exp.conn = newStandInConn()
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateMetrics(1, 1)))
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), generateMetrics(1, 1)))
+
+    // Only the transitions are reported.
+    require.Len(t, host.events, 2)
+    assert.Equal(t, componentstatus.StatusRecoverableError, host.events[0].Status())
+    assert.ErrorContains(t, host.events[0].Err(), "connection refused")
+    assert.Equal(t, componentstatus.StatusOK, host.events[1].Status())
+}
+
+func TestStatusIgnoresPermanentErrors(t *testing.T) {
+    var status statusReporter
+    host := &statusHost{Host: componenttest.NewNopHost()}
+    status.setHost(host)
+
+    status.report(classifyError(&clickhouse.Exception{Code: 60}))
+    assert.Empty(t, host.events)
+}
@@ -0,0 +1,81 @@
+// exporter/clickhouseexporter/tenancy.go
+package clickhouseexporter
+
+import (
+    "context"
+
+    "go.opentelemetry.io/collector/client"
+    "go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// tenantRouter picks the insert statements of the tenant of each resource.
+// Tenants are written to databases of their own, so the rows of two
+// tenants never share a batch.
+type tenantRouter struct {
+    resourceAttribute string
+    metadataKey       string
+    tenants           map[string]insertQueries
+    fallback          insertQueries
+}
+
+func newTenantRouter(cfg *Config, attributes attributeConverter) tenantRouter {
+    base := newSchema(cfg)
+    r := tenantRouter{
+        resourceAttribute: cfg.Tenancy.ResourceAttribute,
+        metadataKey:       cfg.Tenancy.MetadataKey,
+        fallback:          newInsertQueries(base, attributes),
+    }
+    if !cfg.Tenancy.enabled() {
+        return r
+    }
+    r.tenants = make(map[string]insertQueries, len(cfg.Tenancy.Allowed))
+    for _, tenant := range cfg.Tenancy.Allowed {
+        queries := newInsertQueries(base.forTenant(tenant), attributes)
+        queries.tenant = tenant
+        r.tenants[tenant] = queries
+    }
+    if cfg.Tenancy.Default != "" {
+        r.fallback = r.tenants[cfg.Tenancy.Default]
+    }
+    return r
+}
+
+// route returns the insert statements of the tenant named by the resource
+// attributes or, failing that, by the client metadata of ctx. A resource
+// naming a tenant that is not allowed goes to the default tenant.
+func (r tenantRouter) route(ctx context.Context, resource pcommon.Map) insertQueries {
+    if r.tenants == nil {
+        return r.fallback
+    }
+    if r.resourceAttribute != "" {
+        if tenant, ok := resource.Get(r.resourceAttribute); ok {
+            return r.lookup(tenant.AsString())
+        }
+    }
+    if r.metadataKey != "" {
+        if values := client.FromContext(ctx).Metadata.Get(r.metadataKey); len(values) > 0 {
+            return r.lookup(values[0])
+        }
+    }
+    return r.fallback
+}
+
+func (r tenantRouter) lookup(tenant string) insertQueries {
+    if queries, ok := r.tenants[tenant]; ok {
+        return queries
+    }
+    return r.fallback
+}
+
+// tenantSchemas returns the schema of every database written to: the
+// configured one and one per allowed tenant.
+func tenantSchemas(cfg *Config) []schema {
+    base := newSchema(cfg)
+    schemas := []schema{base}
+    if cfg.Tenancy.enabled() {
+        for _, tenant := range cfg.Tenancy.Allowed {
+            schemas = append(schemas, base.forTenant(tenant))
+        }
+    }
+    return schemas
+}
@@ -0,0 +1,114 @@
+// exporter/clickhouseexporter/tenancy_test.go
+package clickhouseexporter
+
+import (
+    "context"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.opentelemetry.io/collector/client"
+    "go.opentelemetry.io/collector/component/componenttest"
+    "go.opentelemetry.io/collector/pdata/plog"
+)
+
+// newTenancyTestExporter returns an exporter routing the tenants team_a and
+// team_b by the tenant.id resource attribute and the x-tenant metadata.
+func newTenancyTestExporter(t *testing.T, conn dbConn, fallback string) *clickhouseExporter {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = "localhost:9000"
+    cfg.Tenancy = TenancyConfig{
+        ResourceAttribute: "tenant.id",
+        MetadataKey:       "x-tenant",
+        Allowed:           []string{"team_a", "team_b"},
+        Default:           fallback,
+    }
+    require.NoError(t, cfg.Validate())
+    exp, err := newClickHouseExporter(componenttest.NewNopTelemetrySettings(), cfg)
+    require.NoError(t, err)
+    exp.conn = conn
+    return exp
+}
+
+// generateTenantLogs returns one log record per tenant, in a resource of
+// its own. An empty tenant leaves out the tenant.id attribute.
+func generateTenantLogs(tenants ...string) plog.Logs {
+    ld := plog.NewLogs()
+    for _, tenant := range tenants {
+        rl := ld.ResourceLogs().AppendEmpty()
+        if tenant != "" {
+            rl.Resource().Attributes().PutStr("tenant.id", tenant)
+        }
+        rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr(tenant)
+    }
+    return ld
+}
+
+func bodies(rows []map[string]any) []any {
+    var values []any
+    for _, row := range rows {
+        values = append(values, row["body"])
+    }
+    return values
+}
+
+func TestTenancyRoutesByResourceAttribute(t *testing.T) {
+    conn := &recordingConn{}
+    exp := newTenancyTestExporter(t, conn, "")
+    require.NoError(t, exp.ConsumeLogs(context.Background(), generateTenantLogs("team_a", "team_b", "team_a", "team_c", "")))
+
+    assert.Equal(t, []any{"team_a", "team_a"}, bodies(conn.rows("otel_team_a.otel_logs")))
+    assert.Equal(t, []any{"team_b"}, bodies(conn.rows("otel_team_b.otel_logs")))
+    // Tenants that are not allowed, and resources naming none, fall back to
+    // the configured database.
+    assert.Equal(t, []any{"team_c", ""}, bodies(conn.rows("otel.otel_logs")))
+
+    // Every insert holds the rows of a single tenant.
+    assert.Len(t, conn.inserts, 3)
+}
+
+func TestTenancyRoutesByClientMetadata(t *testing.T) {
+    conn := &recordingConn{}
+    exp := newTenancyTestExporter(t, conn, "")
+    ctx := client.NewContext(context.Background(), client.Info{
+        Metadata: client.NewMetadata(map[string][]string{"x-tenant": {"team_b"}}),
+    })
+    require.NoError(t, exp.ConsumeLogs(ctx, generateTenantLogs("team_a", "")))
+
+    // The resource attribute takes precedence over the metadata.
+    assert.Equal(t, []any{"team_a"}, bodies(conn.rows("otel_team_a.otel_logs")))
+    assert.Equal(t, []any{""}, bodies(conn.rows("otel_team_b.otel_logs")))
+    assert.Empty(t, conn.rows("otel.otel_logs"))
+}
+
+func TestTenancyDefaultTenant(t *testing.T) {
+    conn := &recordingConn{}
+    exp := newTenancyTestExporter(t, conn, "team_b")
+    require.NoError(t, exp.ConsumeLogs(context.Background(), generateTenantLogs("team_c", "")))
+
+    assert.Equal(t, []any{"team_c", ""}, bodies(conn.rows("otel_team_b.otel_logs")))
+    assert.Empty(t, conn.rows("otel.otel_logs"))
+}
+
+func TestTenancyRoutesMetrics(t *testing.T) {
+    conn := &recordingConn{}
+    exp := newTenancyTestExporter(t, conn, "")
+    md := generateMetrics(1, 1)
+    md.ResourceMetrics().At(0).Resource().Attributes().PutStr("tenant.id", "team_a")
+    require.NoError(t, exp.ConsumeMetrics(context.Background(), md))
+
+    assert.NotEmpty(t, conn.rows("otel_team_a.metrics"))
+    assert.NotEmpty(t, conn.rows("otel_team_a.metrics_histogram"))
+    assert.Empty(t, conn.rows("otel.metrics"))
+}
+
+func TestTenancyCreatesTenantDatabases(t *testing.T) {
+    conn := &recordingConn{}
+    exp := newTenancyTestExporter(t, conn, "")
+    require.NoError(t, exp.createSchema(context.Background()))
+
+    for _, database := range []string{"otel", "otel_team_a", "otel_team_b"} {
+        assert.Contains(t, conn.statements, "CREATE DATABASE IF NOT EXISTS "+database)
+        assert.Contains(t, conn.statements, "INSERT INTO "+database+".otel_schema_migrations (version, description) VALUES (?, ?)")
+    }
+}
@@ -8,8 +8,8 @@ import (
 	"time"
 )
 
-// Config defines a configuration for batching requests based on a timeout and a minimum number of items.
-// MaxSizeItems defines batch splitting functionality if it's more than zero.
+// Config defines a configuration for batching requests based on a timeout and a minimum number of items or bytes.
+// MaxSizeItems and MaxSizeBytes define batch splitting functionality if they are more than zero.
 // Experimental: This API is at the early stage of development and may change without backward compatibility
 // until https://github.com/open-telemetry/opentelemetry-collector/issues/8122 is resolved.
 type Config struct {
@@ -23,7 +23,7 @@ type Config struct {
 	MaxSizeConfig `mapstructure:",squash"`
 }
 
-// MinSizeConfig defines the configuration for the minimum number of items in a batch.
+// MinSizeConfig defines the configuration for the minimum number of items or bytes in a batch.
 // Experimental: This API is at the early stage of development and may change without backward compatibility
 // until https://github.com/open-telemetry/opentelemetry-collector/issues/8122 is resolved.
 type MinSizeConfig struct {
@@ -31,9 +31,15 @@ type MinSizeConfig struct {
 	// sent regardless of the timeout. There is no guarantee that the batch size always greater than this value.
 	// This option requires the Request to implement RequestItemsCounter interface. Otherwise, it will be ignored.
 	MinSizeItems int `mapstructure:"min_size_items"`
+
+	// MinSizeBytes is the size in bytes, serialized as OTLP protobuf, at which the batch should be sent regardless of
+	// the timeout, if it is reached before MinSizeItems. Setting this value to zero disables the size in bytes
+	// threshold. This option requires the Request to implement RequestBytesSizer interface. Otherwise, it will be
+	// ignored.
+	MinSizeBytes int `mapstructure:"min_size_bytes"`
 }
 
-// MaxSizeConfig defines the configuration for the maximum number of items in a batch.
+// MaxSizeConfig defines the configuration for the maximum number of items or bytes in a batch.
 // Experimental: This API is at the early stage of development and may change without backward compatibility
 // until https://github.com/open-telemetry/opentelemetry-collector/issues/8122 is resolved.
 type MaxSizeConfig struct {
@@ -41,6 +47,12 @@ type MaxSizeConfig struct {
 	// If the batch size exceeds this value, it will be broken up into smaller batches if possible.
 	// Setting this value to zero disables the maximum size limit.
 	MaxSizeItems int `mapstructure:"max_size_items"`
+
+	// MaxSizeBytes is the maximum size in bytes of the batch, serialized as OTLP protobuf. If the batch size exceeds
+	// this value, it will be broken up into smaller batches, each as close to this size as the items allow. A single
+	// item larger than this value is sent in a batch of its own.
+	// Setting this value to zero disables the maximum size in bytes limit.
+	MaxSizeBytes int `mapstructure:"max_size_bytes"`
 }
 
 func (c Config) Validate() error {
@@ -53,6 +65,15 @@ func (c Config) Validate() error {
 	if c.MaxSizeItems != 0 && c.MaxSizeItems < c.MinSizeItems {
 		return errors.New("max_size_items must be greater than or equal to min_size_items")
 	}
+	if c.MinSizeBytes < 0 {
+		return errors.New("min_size_bytes must be greater than or equal to zero")
+	}
+	if c.MaxSizeBytes < 0 {
+		return errors.New("max_size_bytes must be greater than or equal to zero")
+	}
+	if c.MaxSizeBytes != 0 && c.MaxSizeBytes < c.MinSizeBytes {
+		return errors.New("max_size_bytes must be greater than or equal to min_size_bytes")
+	}
 	if c.FlushTimeout <= 0 {
 		return errors.New("timeout must be greater than zero")
 	}
@@ -0,0 +1,119 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package exporterhelper // import "go.opentelemetry.io/collector/exporter/exporterhelper"
+
+import (
+	"math/bits"
+
+	"go.opentelemetry.io/collector/exporter/exporterbatcher"
+)
+
+// maxNestingDepth is the number of nested messages holding an item: resource metrics, scope metrics, metric and
+// metric data for a data point.
+const maxNestingDepth = 4
+
+// protoFieldSize returns the size of a length-delimited protobuf field holding size bytes. Every field on the path of
+// an OTLP item has a field number below 16, and so a tag of a single byte.
+func protoFieldSize(size int) int {
+	return 1 + protoVarintSize(uint64(size)) + size
+}
+
+func protoVarintSize(v uint64) int {
+	return (bits.Len64(v|1) + 6) / 7
+}
+
+// sizeTracker tracks the protobuf size of a request built by appending items to nested messages, without measuring
+// the request again for every item. A message changes size as items are added to it, and so does the length of the
+// field holding it in its parent, hence the body of the messages still open is kept apart.
+type sizeTracker struct {
+	// closed is the size of the top-level fields that are complete.
+	closed int
+	// bodies is the size of the open messages, outermost first, without the field of their open child.
+	bodies [maxNestingDepth]int
+	depth  int
+}
+
+// open opens a message with the given size without its items, at depth. Deeper messages are complete.
+func (t *sizeTracker) open(depth, size int) {
+	t.closeTo(depth)
+	t.bodies[depth] = size
+	t.depth = depth + 1
+}
+
+// add adds an item of the given size to the innermost open message.
+func (t *sizeTracker) add(size int) {
+	t.bodies[t.depth-1] += protoFieldSize(size)
+}
+
+func (t *sizeTracker) closeTo(depth int) {
+	for t.depth > depth {
+		t.depth--
+		field := protoFieldSize(t.bodies[t.depth])
+		if t.depth == 0 {
+			t.closed += field
+		} else {
+			t.bodies[t.depth-1] += field
+		}
+	}
+}
+
+// size returns the size of the request.
+func (t sizeTracker) size() int {
+	t.closeTo(0)
+	return t.closed
+}
+
+// bytesSplitter packs items, in order, into requests of at most MaxSizeBytes, and of at most MaxSizeItems items when
+// set. Callers walk the messages of the requests to split, declaring each message on the path of the next items with
+// enter, and add each item after checking with fits that it does not need a new request.
+type bytesSplitter struct {
+	cfg     exporterbatcher.MaxSizeConfig
+	tracker sizeTracker
+	items   int
+	// headers holds the size of the messages on the path of the next items, without their items. The opened first
+	// ones also are in the request being built.
+	headers [maxNestingDepth]int
+	opened  int
+}
+
+// enter declares the message at depth on the path of the next items, with its size without its items.
+func (s *bytesSplitter) enter(depth, size int) {
+	s.headers[depth] = size
+	s.opened = min(s.opened, depth)
+}
+
+// fits reports whether an item of the given size, held by the messages up to depth, can be added to the request
+// being built. An empty request takes any item, even one larger than the limit.
+func (s *bytesSplitter) fits(depth, size int) bool {
+	if s.items == 0 {
+		return true
+	}
+	if s.cfg.MaxSizeItems > 0 && s.items >= s.cfg.MaxSizeItems {
+		return false
+	}
+	t := s.tracker
+	for d := s.opened; d < depth; d++ {
+		t.open(d, s.headers[d])
+	}
+	t.add(size)
+	return t.size() <= s.cfg.MaxSizeBytes
+}
+
+// add accounts for an item of the given size, held by the messages up to depth. open is called for each of these
+// messages missing from the request being built, outermost first, to add it.
+func (s *bytesSplitter) add(depth, size int, open func(depth int)) {
+	for ; s.opened < depth; s.opened++ {
+		open(s.opened)
+		s.tracker.open(s.opened, s.headers[s.opened])
+	}
+	s.tracker.add(size)
+	s.items++
+}
+
+// reset starts a new request.
+func (s *bytesSplitter) reset() {
+	s.tracker = sizeTracker{}
+	s.items = 0
+	s.opened = 0
+}
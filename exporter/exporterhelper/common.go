@@ -39,6 +39,12 @@ func WithRetry(config configretry.BackOffConfig) Option {
 	return internal.WithRetry(config)
 }
 
+// WithCircuitBreaker enables the circuit breaker for an exporter, which holds the requests while the backend keeps
+// failing. The default CircuitBreakerConfig is to disable the circuit breaker.
+func WithCircuitBreaker(config CircuitBreakerConfig) Option {
+	return internal.WithCircuitBreaker(config)
+}
+
 // WithQueue overrides the default QueueConfig for an exporter.
 // The default QueueConfig is to disable queueing.
 // This option cannot be used with the new exporter helpers New[Traces|Metrics|Logs]RequestExporter.
@@ -34,6 +34,7 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.opentelemetry.io/collector/component/componentstatus v0.112.0 // indirect
 	go.opentelemetry.io/collector/config/configtelemetry v0.112.0 // indirect
 	go.opentelemetry.io/collector/extension v0.112.0 // indirect
 	go.opentelemetry.io/collector/extension/experimental/storage v0.112.0 // indirect
@@ -95,3 +96,5 @@ replace go.opentelemetry.io/collector/pipeline => ../../../pipeline
 replace go.opentelemetry.io/collector/exporter/exportertest => ../../exportertest
 
 replace go.opentelemetry.io/collector/consumer/consumererror => ../../../consumer/consumererror
+
+replace go.opentelemetry.io/collector/component/componentstatus => ../../../component/componentstatus
@@ -23,7 +23,19 @@ func (req *profilesRequest) Merge(_ context.Context, r2 exporterhelper.Request)
 }
 
 // MergeSplit splits and/or merges the profiles into multiple requests based on the MaxSizeConfig.
-func (req *profilesRequest) MergeSplit(_ context.Context, cfg exporterbatcher.MaxSizeConfig, r2 exporterhelper.Request) ([]exporterhelper.Request, error) {
+func (req *profilesRequest) MergeSplit(ctx context.Context, cfg exporterbatcher.MaxSizeConfig, r2 exporterhelper.Request) ([]exporterhelper.Request, error) {
+	// Profiles are not split by size in bytes. Without a maximum number of items, they are only merged.
+	if cfg.MaxSizeItems == 0 {
+		if r2 == nil {
+			return []exporterhelper.Request{req}, nil
+		}
+		merged, err := req.Merge(ctx, r2)
+		if err != nil {
+			return nil, err
+		}
+		return []exporterhelper.Request{merged}, nil
+	}
+
 	var (
 		res          []exporterhelper.Request
 		destReq      *profilesRequest
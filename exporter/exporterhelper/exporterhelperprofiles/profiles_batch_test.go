@@ -134,6 +134,16 @@ func TestMergeSplitProfilesInvalidInput(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestMergeSplitProfilesMaxSizeBytes(t *testing.T) {
+	r1 := &profilesRequest{pd: testdata.GenerateProfiles(2)}
+	r2 := &profilesRequest{pd: testdata.GenerateProfiles(3)}
+	res, err := r1.MergeSplit(context.Background(), exporterbatcher.MaxSizeConfig{MaxSizeBytes: 10}, r2)
+	require.NoError(t, err)
+	// Profiles are merged, but not split by size in bytes.
+	require.Len(t, res, 1)
+	assert.Equal(t, 5, res[0].ItemsCount())
+}
+
 func TestExtractProfiles(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		ld := testdata.GenerateProfiles(10)
@@ -47,11 +47,12 @@ type BaseExporter struct {
 	// Chain of senders that the exporter helper applies before passing the data to the actual exporter.
 	// The data is handled by each sender in the respective order starting from the queueSender.
 	// Most of the senders are optional, and initialized with a no-op path-through sender.
-	BatchSender   RequestSender
-	QueueSender   RequestSender
-	ObsrepSender  RequestSender
-	RetrySender   RequestSender
-	TimeoutSender *TimeoutSender // TimeoutSender is always initialized.
+	BatchSender          RequestSender
+	QueueSender          RequestSender
+	ObsrepSender         RequestSender
+	CircuitBreakerSender RequestSender
+	RetrySender          RequestSender
+	TimeoutSender        *TimeoutSender // TimeoutSender is always initialized.
 
 	ConsumerOptions []consumer.Option
 
@@ -69,11 +70,12 @@ func NewBaseExporter(set exporter.Settings, signal pipeline.Signal, osf ObsrepSe
 	be := &BaseExporter{
 		Signal: signal,
 
-		BatchSender:   &BaseRequestSender{},
-		QueueSender:   &BaseRequestSender{},
-		ObsrepSender:  osf(obsReport),
-		RetrySender:   &BaseRequestSender{},
-		TimeoutSender: &TimeoutSender{cfg: NewDefaultTimeoutConfig()},
+		BatchSender:          &BaseRequestSender{},
+		QueueSender:          &BaseRequestSender{},
+		ObsrepSender:         osf(obsReport),
+		CircuitBreakerSender: &BaseRequestSender{},
+		RetrySender:          &BaseRequestSender{},
+		TimeoutSender:        &TimeoutSender{cfg: NewDefaultTimeoutConfig()},
 
 		Set:    set,
 		Obsrep: obsReport,
@@ -94,7 +96,7 @@ func NewBaseExporter(set exporter.Settings, signal pipeline.Signal, osf ObsrepSe
 				ExporterSettings: be.Set,
 			},
 			be.queueCfg)
-		be.QueueSender = NewQueueSender(q, be.Set, be.queueCfg.NumConsumers, be.ExportFailureMessage, be.Obsrep)
+		be.QueueSender = NewQueueSender(q, be.Set, be.queueCfg, be.ExportFailureMessage, be.Obsrep)
 		for _, op := range options {
 			err = multierr.Append(err, op(be))
 		}
@@ -109,6 +111,20 @@ func NewBaseExporter(set exporter.Settings, signal pipeline.Signal, osf ObsrepSe
 		return nil, err
 	}
 
+	// If both are enabled, the retry sender reports every failed attempt to the circuit breaker.
+	if cb, ok := be.CircuitBreakerSender.(*circuitBreakerSender); ok {
+		if rs, ok := be.RetrySender.(*retrySender); ok {
+			rs.circuitBreaker = cb
+			cb.attemptsReported = true
+		}
+	}
+
+	// If the adaptive concurrency is enabled, every attempt to send data adjusts the number of queue consumers. Only
+	// the export itself is measured, not the time spent in the batcher or waiting for a retry.
+	if qs, ok := be.QueueSender.(*QueueSender); ok && qs.adaptive {
+		be.TimeoutSender.onAttempt = qs.consumers.OnAttempt
+	}
+
 	be.connectSenders()
 
 	if bs, ok := be.BatchSender.(*BatchSender); ok {
@@ -137,7 +153,8 @@ func (be *BaseExporter) Send(ctx context.Context, req internal.Request) error {
 func (be *BaseExporter) connectSenders() {
 	be.QueueSender.SetNextSender(be.BatchSender)
 	be.BatchSender.SetNextSender(be.ObsrepSender)
-	be.ObsrepSender.SetNextSender(be.RetrySender)
+	be.ObsrepSender.SetNextSender(be.CircuitBreakerSender)
+	be.CircuitBreakerSender.SetNextSender(be.RetrySender)
 	be.RetrySender.SetNextSender(be.TimeoutSender)
 }
 
@@ -147,6 +164,11 @@ func (be *BaseExporter) Start(ctx context.Context, host component.Host) error {
 		return err
 	}
 
+	// If no error then start the CircuitBreakerSender.
+	if err := be.CircuitBreakerSender.Start(ctx, host); err != nil {
+		return err
+	}
+
 	// If no error then start the BatchSender.
 	if err := be.BatchSender.Start(ctx, host); err != nil {
 		return err
@@ -158,8 +180,10 @@ func (be *BaseExporter) Start(ctx context.Context, host component.Host) error {
 
 func (be *BaseExporter) Shutdown(ctx context.Context) error {
 	return multierr.Combine(
-		// First shutdown the retry sender, so the queue sender can flush the queue without retries.
+		// First shutdown the retry and circuit breaker senders, so the queue sender can flush the queue without
+		// retries or waiting for the circuit to close.
 		be.RetrySender.Shutdown(ctx),
+		be.CircuitBreakerSender.Shutdown(ctx),
 		// Then shutdown the batch sender
 		be.BatchSender.Shutdown(ctx),
 		// Then shutdown the queue sender.
@@ -208,6 +232,18 @@ func WithRetry(config configretry.BackOffConfig) Option {
 	}
 }
 
+// WithCircuitBreaker enables the circuit breaker for an exporter, which holds the requests while the backend keeps
+// failing. The default CircuitBreakerConfig is to disable the circuit breaker.
+func WithCircuitBreaker(config CircuitBreakerConfig) Option {
+	return func(o *BaseExporter) error {
+		if !config.Enabled {
+			return nil
+		}
+		o.CircuitBreakerSender = newCircuitBreakerSender(config, o.Set, o.Obsrep)
+		return nil
+	}
+}
+
 // WithQueue overrides the default QueueConfig for an exporter.
 // The default QueueConfig is to disable queueing.
 // This option cannot be used with the new exporter helpers New[Traces|Metrics|Logs]RequestExporter.
@@ -221,14 +257,21 @@ func WithQueue(config QueueConfig) Option {
 			return nil
 		}
 		o.queueCfg = exporterqueue.Config{
-			Enabled:      config.Enabled,
-			NumConsumers: config.NumConsumers,
-			QueueSize:    config.QueueSize,
+			Enabled:             config.Enabled,
+			NumConsumers:        config.NumConsumers,
+			QueueSize:           config.QueueSize,
+			AdaptiveConcurrency: config.AdaptiveConcurrency,
 		}
-		o.queueFactory = exporterqueue.NewPersistentQueueFactory[internal.Request](config.StorageID, exporterqueue.PersistentQueueSettings[internal.Request]{
+		persistentSettings := exporterqueue.PersistentQueueSettings[internal.Request]{
 			Marshaler:   o.Marshaler,
 			Unmarshaler: o.Unmarshaler,
-		})
+		}
+		if config.SpilloverStorageID != nil {
+			o.queueFactory = exporterqueue.NewSpilloverQueueFactory[internal.Request](config.SpilloverStorageID,
+				exporterqueue.SpilloverQueueSettings[internal.Request]{PersistentQueueSettings: persistentSettings})
+			return nil
+		}
+		o.queueFactory = exporterqueue.NewPersistentQueueFactory[internal.Request](config.StorageID, persistentSettings)
 		return nil
 	}
 }
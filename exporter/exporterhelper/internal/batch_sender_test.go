@@ -83,6 +83,28 @@ func TestBatchSender_Merge(t *testing.T) {
 	}
 }
 
+func TestBatchSender_MinSizeBytes(t *testing.T) {
+	cfg := exporterbatcher.NewDefaultConfig()
+	cfg.MinSizeItems = 1000
+	cfg.MinSizeBytes = 100
+	cfg.FlushTimeout = time.Second
+	be := queueBatchExporter(t, WithBatcher(cfg))
+
+	require.NoError(t, be.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		require.NoError(t, be.Shutdown(context.Background()))
+	})
+
+	sink := newFakeRequestSink()
+	require.NoError(t, be.Send(context.Background(), &fakeRequest{items: 1, bytes: 60, sink: sink}))
+	require.NoError(t, be.Send(context.Background(), &fakeRequest{items: 1, bytes: 60, sink: sink}))
+
+	// the requests should be merged and sent by reaching the minimum size in bytes, well before the timeout.
+	assert.Eventually(t, func() bool {
+		return sink.requestsCount.Load() == 1 && sink.itemsCount.Load() == 2
+	}, 500*time.Millisecond, 10*time.Millisecond)
+}
+
 func TestBatchSender_BatchExportError(t *testing.T) {
 	cfg := exporterbatcher.NewDefaultConfig()
 	cfg.MinSizeItems = 10
@@ -0,0 +1,328 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package internal // import "go.opentelemetry.io/collector/exporter/exporterhelper/internal"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/internal"
+	"go.opentelemetry.io/collector/exporter/internal/experr"
+)
+
+var (
+	errCircuitOpen = errors.New("circuit breaker is open")
+	// errRetryDeadline is returned by acquire when the retries of a held request have run out of time.
+	errRetryDeadline = errors.New("retry deadline exceeded")
+)
+
+// CircuitBreakerConfig defines configuration for the circuit breaker, which stops sending data to a backend that keeps
+// failing, and lets a few probe requests through once in a while to detect its recovery.
+type CircuitBreakerConfig struct {
+	// Enabled indicates whether to use the circuit breaker.
+	Enabled bool `mapstructure:"enabled"`
+	// FailureThreshold is the number of consecutive failed attempts to send data that opens the circuit.
+	// Permanent errors are not counted.
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// OpenDuration is the time the circuit stays open, holding the requests, before letting probe requests through.
+	OpenDuration time.Duration `mapstructure:"open_duration"`
+	// HalfOpenProbes is the number of probe requests let through once the circuit is no longer open. The circuit
+	// closes once all of them succeed, and opens again on the first failure.
+	HalfOpenProbes int `mapstructure:"half_open_probes"`
+}
+
+// NewDefaultCircuitBreakerConfig returns the default config for CircuitBreakerConfig.
+func NewDefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		Enabled:          false,
+		FailureThreshold: 5,
+		OpenDuration:     30 * time.Second,
+		HalfOpenProbes:   1,
+	}
+}
+
+// Validate checks if the CircuitBreakerConfig configuration is valid
+func (cfg *CircuitBreakerConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.FailureThreshold <= 0 {
+		return errors.New("'failure_threshold' must be positive")
+	}
+	if cfg.OpenDuration <= 0 {
+		return errors.New("'open_duration' must be positive")
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		return errors.New("'half_open_probes' must be positive")
+	}
+	return nil
+}
+
+type circuitState int64
+
+const (
+	circuitClosed circuitState = iota
+	circuitHalfOpen
+	circuitOpen
+)
+
+// circuitOpenError is returned by the retry sender when it stops retrying a request because the circuit opened.
+// It holds what is left to send of the request, and the time by which it must be sent, zero if it can be retried
+// forever.
+type circuitOpenError struct {
+	err           error
+	req           internal.Request
+	retryDeadline time.Time
+}
+
+func (e circuitOpenError) Error() string {
+	return errCircuitOpen.Error() + ": " + e.err.Error()
+}
+
+func (e circuitOpenError) Unwrap() error {
+	return e.err
+}
+
+// circuitBreakerSender holds the requests while the circuit is open, instead of sending them to a backend that keeps
+// failing. Once OpenDuration elapses, the circuit is half-open and only lets HalfOpenProbes requests through.
+type circuitBreakerSender struct {
+	BaseRequestSender
+	cfg            CircuitBreakerConfig
+	traceAttribute attribute.KeyValue
+	logger         *zap.Logger
+	obsrep         *ObsReport
+	host           component.Host
+	stopCh         chan struct{}
+
+	// attemptsReported indicates that the retry sender reports every failed attempt, so the outcome of a request is
+	// not recorded again.
+	attemptsReported bool
+
+	// mu guards everything declared below.
+	mu sync.Mutex
+	// changed is closed, and replaced, when the state changes or a probe finishes.
+	changed   chan struct{}
+	state     circuitState
+	failures  int
+	successes int
+	probes    int
+	openUntil time.Time
+	lastErr   error
+}
+
+func newCircuitBreakerSender(cfg CircuitBreakerConfig, set exporter.Settings, obsrep *ObsReport) *circuitBreakerSender {
+	return &circuitBreakerSender{
+		cfg:            cfg,
+		traceAttribute: attribute.String(ExporterKey, set.ID.String()),
+		logger:         set.Logger,
+		obsrep:         obsrep,
+		stopCh:         make(chan struct{}),
+		changed:        make(chan struct{}),
+	}
+}
+
+func (cb *circuitBreakerSender) Start(_ context.Context, host component.Host) error {
+	cb.host = host
+	return cb.obsrep.TelemetryBuilder.InitExporterCircuitBreakerState(func() int64 {
+		cb.mu.Lock()
+		defer cb.mu.Unlock()
+		return int64(cb.state)
+	}, metric.WithAttributeSet(attribute.NewSet(cb.traceAttribute, attribute.String(DataTypeKey, cb.obsrep.Signal.String()))))
+}
+
+func (cb *circuitBreakerSender) Shutdown(context.Context) error {
+	close(cb.stopCh)
+	return nil
+}
+
+// send implements the requestSender interface
+func (cb *circuitBreakerSender) Send(ctx context.Context, req internal.Request) error {
+	// retryTimer fires when the retries of a held request run out of time.
+	var retryTimer *time.Timer
+	var retryExpired <-chan time.Time
+	defer func() { stopTimer(retryTimer) }()
+	var lastErr error
+	for {
+		probe, err := cb.acquire(ctx, retryExpired)
+		if errors.Is(err, errRetryDeadline) {
+			return fmt.Errorf("no more retries left: %w", lastErr)
+		}
+		if err != nil {
+			return err
+		}
+		err = cb.NextSender.Send(ctx, req)
+		var openErr circuitOpenError
+		switch {
+		case err == nil:
+			cb.onSuccess(probe)
+		case errors.As(err, &openErr):
+			// The circuit opened while the request was retried, hold what is left of it until the circuit is no
+			// longer open. The time it is held counts towards the MaxElapsedTime of its retries.
+			req = openErr.req
+			lastErr = openErr.err
+			if retryTimer == nil && !openErr.retryDeadline.IsZero() {
+				retryTimer = time.NewTimer(time.Until(openErr.retryDeadline))
+				retryExpired = retryTimer.C
+				ctx = contextWithRetryDeadline(ctx, openErr.retryDeadline)
+			}
+			cb.release(probe)
+			continue
+		case !cb.attemptsReported && !consumererror.IsPermanent(err):
+			cb.onFailure(err)
+		}
+		cb.release(probe)
+		return err
+	}
+}
+
+// acquire blocks while the circuit is open, or while it is half-open and all the probes are in flight. It returns
+// whether the request is a probe, or errRetryDeadline once retryExpired fires.
+func (cb *circuitBreakerSender) acquire(ctx context.Context, retryExpired <-chan time.Time) (bool, error) {
+	for {
+		cb.mu.Lock()
+		if cb.state == circuitOpen && !time.Now().Before(cb.openUntil) {
+			cb.setState(circuitHalfOpen)
+		}
+		var timer *time.Timer
+		switch cb.state {
+		case circuitClosed:
+			cb.mu.Unlock()
+			return false, nil
+		case circuitHalfOpen:
+			if cb.probes < cb.cfg.HalfOpenProbes {
+				cb.probes++
+				cb.mu.Unlock()
+				return true, nil
+			}
+		case circuitOpen:
+			timer = time.NewTimer(time.Until(cb.openUntil))
+		}
+		changed := cb.changed
+		cb.mu.Unlock()
+
+		var timerCh <-chan time.Time
+		if timer != nil {
+			timerCh = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return false, fmt.Errorf("request is cancelled or timed out: %w", errCircuitOpen)
+		case <-cb.stopCh:
+			stopTimer(timer)
+			return false, experr.NewShutdownErr(errCircuitOpen)
+		case <-retryExpired:
+			stopTimer(timer)
+			return false, errRetryDeadline
+		case <-changed:
+			stopTimer(timer)
+		case <-timerCh:
+		}
+	}
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// release frees the slot of a probe.
+func (cb *circuitBreakerSender) release(probe bool) {
+	if !probe {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.probes--
+	cb.notify()
+}
+
+// onSuccess records a successful request. The circuit closes once all the probes succeed.
+func (cb *circuitBreakerSender) onSuccess(probe bool) {
+	cb.mu.Lock()
+	var event *componentstatus.Event
+	switch cb.state {
+	case circuitClosed:
+		cb.failures = 0
+	case circuitHalfOpen:
+		if probe {
+			cb.successes++
+			if cb.successes >= cb.cfg.HalfOpenProbes {
+				event = cb.setState(circuitClosed)
+			}
+		}
+	}
+	cb.mu.Unlock()
+	cb.reportStatus(event)
+}
+
+// onFailure records a failed attempt to send a request, and returns whether the circuit is open.
+func (cb *circuitBreakerSender) onFailure(err error) bool {
+	cb.mu.Lock()
+	var event *componentstatus.Event
+	cb.lastErr = err
+	switch cb.state {
+	case circuitClosed:
+		cb.failures++
+		if cb.failures >= cb.cfg.FailureThreshold {
+			event = cb.setState(circuitOpen)
+		}
+	case circuitHalfOpen:
+		event = cb.setState(circuitOpen)
+	}
+	open := cb.state == circuitOpen
+	cb.mu.Unlock()
+	cb.reportStatus(event)
+	return open
+}
+
+// setState moves the circuit to the given state, and returns the status event to report, if any. The caller must
+// hold the mutex.
+func (cb *circuitBreakerSender) setState(state circuitState) *componentstatus.Event {
+	cb.state = state
+	cb.failures = 0
+	cb.successes = 0
+	cb.notify()
+
+	switch state {
+	case circuitOpen:
+		cb.openUntil = time.Now().Add(cb.cfg.OpenDuration)
+		cb.obsrep.TelemetryBuilder.ExporterCircuitBreakerOpened.Add(context.Background(), 1,
+			metric.WithAttributeSet(attribute.NewSet(cb.traceAttribute)))
+		cb.logger.Warn("Exporting keeps failing, the circuit breaker opened. Requests are held until it closes.",
+			zap.Error(cb.lastErr), zap.Duration("open_duration", cb.cfg.OpenDuration))
+		return componentstatus.NewRecoverableErrorEvent(fmt.Errorf("%w: %w", errCircuitOpen, cb.lastErr))
+	case circuitHalfOpen:
+		cb.logger.Info("The circuit breaker is half-open, sending probe requests.",
+			zap.Int("half_open_probes", cb.cfg.HalfOpenProbes))
+		return nil
+	default:
+		cb.logger.Info("The circuit breaker closed.")
+		return componentstatus.NewEvent(componentstatus.StatusOK)
+	}
+}
+
+// notify wakes up the requests waiting for the state to change. The caller must hold the mutex.
+func (cb *circuitBreakerSender) notify() {
+	close(cb.changed)
+	cb.changed = make(chan struct{})
+}
+
+func (cb *circuitBreakerSender) reportStatus(event *componentstatus.Event) {
+	if event != nil && cb.host != nil {
+		componentstatus.ReportStatus(cb.host, event)
+	}
+}
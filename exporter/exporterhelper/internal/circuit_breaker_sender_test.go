@@ -0,0 +1,242 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componentstatus"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/config/configretry"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/internal/experr"
+)
+
+func TestCircuitBreakerConfig_Validate(t *testing.T) {
+	cfg := NewDefaultCircuitBreakerConfig()
+	require.NoError(t, cfg.Validate())
+
+	cfg.FailureThreshold = 0
+	require.NoError(t, cfg.Validate(), "disabled circuit breaker is not validated")
+
+	cfg.Enabled = true
+	require.EqualError(t, cfg.Validate(), "'failure_threshold' must be positive")
+
+	cfg = NewDefaultCircuitBreakerConfig()
+	cfg.Enabled = true
+	cfg.OpenDuration = 0
+	require.EqualError(t, cfg.Validate(), "'open_duration' must be positive")
+
+	cfg = NewDefaultCircuitBreakerConfig()
+	cfg.Enabled = true
+	cfg.HalfOpenProbes = 0
+	require.EqualError(t, cfg.Validate(), "'half_open_probes' must be positive")
+}
+
+func TestCircuitBreaker_OpensAfterFailures(t *testing.T) {
+	tt, err := componenttest.SetupTelemetry(defaultID)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, tt.Shutdown(context.Background())) })
+
+	cbCfg := newTestCircuitBreakerConfig(2, time.Hour)
+	set := exporter.Settings{ID: defaultID, TelemetrySettings: tt.TelemetrySettings(), BuildInfo: component.NewDefaultBuildInfo()}
+	be, err := NewBaseExporter(set, defaultSignal, newNoopObsrepSender, WithCircuitBreaker(cbCfg))
+	require.NoError(t, err)
+	host := &statusRecorderHost{Host: componenttest.NewNopHost()}
+	require.NoError(t, be.Start(context.Background(), host))
+	t.Cleanup(func() {
+		assert.NoError(t, be.Shutdown(context.Background()))
+	})
+
+	// Permanent errors are not counted.
+	require.Error(t, be.Send(context.Background(), newMockRequest(1, consumererror.NewPermanent(errors.New("bad data")))))
+	require.Error(t, be.Send(context.Background(), newMockRequest(1, errors.New("transient error"))))
+	require.NoError(t, tt.CheckExporterMetricGauge("otelcol_exporter_circuit_breaker_state", int64(circuitClosed),
+		attribute.String(DataTypeKey, defaultSignal.String())))
+	assert.Empty(t, host.getEvents())
+
+	require.Error(t, be.Send(context.Background(), newMockRequest(1, errors.New("transient error"))))
+	require.NoError(t, tt.CheckExporterMetricGauge("otelcol_exporter_circuit_breaker_state", int64(circuitOpen),
+		attribute.String(DataTypeKey, defaultSignal.String())))
+	events := host.getEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, componentstatus.StatusRecoverableError, events[0].Status())
+	require.ErrorIs(t, events[0].Err(), errCircuitOpen)
+
+	// The circuit is open, the request is held until the context is done.
+	mockR := newMockRequest(1, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, be.Send(ctx, mockR), errCircuitOpen)
+	mockR.checkNumRequests(t, 0)
+}
+
+func TestCircuitBreaker_HalfOpenProbeCloses(t *testing.T) {
+	be, err := NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender,
+		WithCircuitBreaker(newTestCircuitBreakerConfig(1, 20*time.Millisecond)))
+	require.NoError(t, err)
+	host := &statusRecorderHost{Host: componenttest.NewNopHost()}
+	require.NoError(t, be.Start(context.Background(), host))
+	t.Cleanup(func() {
+		assert.NoError(t, be.Shutdown(context.Background()))
+	})
+
+	require.Error(t, be.Send(context.Background(), newMockRequest(1, errors.New("transient error"))))
+	cb := be.CircuitBreakerSender.(*circuitBreakerSender)
+	assert.Equal(t, circuitOpen, cb.getState())
+
+	start := time.Now()
+	mockR := newMockRequest(1, nil)
+	require.NoError(t, be.Send(context.Background(), mockR))
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+	mockR.checkNumRequests(t, 1)
+	assert.Equal(t, circuitClosed, cb.getState())
+
+	events := host.getEvents()
+	require.Len(t, events, 2)
+	assert.Equal(t, componentstatus.StatusRecoverableError, events[0].Status())
+	assert.Equal(t, componentstatus.StatusOK, events[1].Status())
+}
+
+func TestCircuitBreaker_HalfOpenProbeFailureReopens(t *testing.T) {
+	cbCfg := newTestCircuitBreakerConfig(3, 10*time.Millisecond)
+	cbCfg.HalfOpenProbes = 2
+	be, err := NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender, WithCircuitBreaker(cbCfg))
+	require.NoError(t, err)
+	require.NoError(t, be.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		assert.NoError(t, be.Shutdown(context.Background()))
+	})
+
+	for i := 0; i < 3; i++ {
+		require.Error(t, be.Send(context.Background(), newMockRequest(1, errors.New("transient error"))))
+	}
+	cb := be.CircuitBreakerSender.(*circuitBreakerSender)
+	assert.Equal(t, circuitOpen, cb.getState())
+
+	// A single failed probe opens the circuit again.
+	require.Error(t, be.Send(context.Background(), newMockRequest(1, errors.New("transient error"))))
+	assert.Equal(t, circuitOpen, cb.getState())
+
+	// Both probes have to succeed to close the circuit.
+	require.NoError(t, be.Send(context.Background(), newMockRequest(1, nil)))
+	assert.Equal(t, circuitHalfOpen, cb.getState())
+	require.NoError(t, be.Send(context.Background(), newMockRequest(1, nil)))
+	assert.Equal(t, circuitClosed, cb.getState())
+}
+
+func TestCircuitBreaker_WithRetry(t *testing.T) {
+	rCfg := configretry.NewDefaultBackOffConfig()
+	rCfg.InitialInterval = time.Hour
+	be, err := NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender, WithRetry(rCfg),
+		WithCircuitBreaker(newTestCircuitBreakerConfig(1, 20*time.Millisecond)))
+	require.NoError(t, err)
+	require.NoError(t, be.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		assert.NoError(t, be.Shutdown(context.Background()))
+	})
+
+	// The first attempt opens the circuit, so what is left of the request is sent by the half-open probe instead of
+	// waiting for the retry interval.
+	start := time.Now()
+	mockR := newMockRequest(2, errors.New("transient error"))
+	require.NoError(t, be.Send(context.Background(), mockR))
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 10*time.Millisecond)
+	assert.Less(t, elapsed, time.Minute)
+	mockR.checkNumRequests(t, 2)
+	assert.Equal(t, circuitClosed, be.CircuitBreakerSender.(*circuitBreakerSender).getState())
+}
+
+func TestCircuitBreaker_RetryMaxElapsedTime(t *testing.T) {
+	rCfg := configretry.NewDefaultBackOffConfig()
+	rCfg.InitialInterval = time.Millisecond
+	rCfg.MaxElapsedTime = 100 * time.Millisecond
+	be, err := NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender, WithRetry(rCfg),
+		WithCircuitBreaker(newTestCircuitBreakerConfig(1, 20*time.Millisecond)))
+	require.NoError(t, err)
+	require.NoError(t, be.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		assert.NoError(t, be.Shutdown(context.Background()))
+	})
+
+	// The circuit keeps opening, letting a probe through and opening again, while the request is held. The request
+	// is dropped once its retries run out of time, instead of being retried from scratch after every hold.
+	start := time.Now()
+	mockR := newErrorRequest()
+	err = be.Send(context.Background(), mockR)
+	elapsed := time.Since(start)
+	require.ErrorContains(t, err, "no more retries left")
+	assert.GreaterOrEqual(t, elapsed, 100*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+	assert.Greater(t, mockR.(*mockErrorRequest).getNumRequests(), 1)
+}
+
+func TestCircuitBreaker_ShutdownWhileOpen(t *testing.T) {
+	be, err := NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender,
+		WithCircuitBreaker(newTestCircuitBreakerConfig(1, time.Hour)))
+	require.NoError(t, err)
+	require.NoError(t, be.Start(context.Background(), componenttest.NewNopHost()))
+
+	require.Error(t, be.Send(context.Background(), newMockRequest(1, errors.New("transient error"))))
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- be.Send(context.Background(), newMockRequest(1, nil))
+	}()
+	require.NoError(t, be.Shutdown(context.Background()))
+	err = <-errCh
+	require.ErrorIs(t, err, errCircuitOpen)
+	assert.True(t, experr.IsShutdownErr(err))
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	be, err := NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender,
+		WithCircuitBreaker(NewDefaultCircuitBreakerConfig()))
+	require.NoError(t, err)
+	assert.IsType(t, &BaseRequestSender{}, be.CircuitBreakerSender)
+}
+
+func newTestCircuitBreakerConfig(failureThreshold int, openDuration time.Duration) CircuitBreakerConfig {
+	cfg := NewDefaultCircuitBreakerConfig()
+	cfg.Enabled = true
+	cfg.FailureThreshold = failureThreshold
+	cfg.OpenDuration = openDuration
+	return cfg
+}
+
+func (cb *circuitBreakerSender) getState() circuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// statusRecorderHost records the status events reported by the components.
+type statusRecorderHost struct {
+	component.Host
+	mu     sync.Mutex
+	events []*componentstatus.Event
+}
+
+func (h *statusRecorderHost) Report(event *componentstatus.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, event)
+}
+
+func (h *statusRecorderHost) getEvents() []*componentstatus.Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]*componentstatus.Event(nil), h.events...)
+}
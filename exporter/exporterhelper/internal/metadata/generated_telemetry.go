@@ -30,6 +30,8 @@ func Tracer(settings component.TelemetrySettings) trace.Tracer {
 // as defined in metadata and user config.
 type TelemetryBuilder struct {
 	meter                             metric.Meter
+	ExporterCircuitBreakerOpened      metric.Int64Counter
+	ExporterCircuitBreakerState       metric.Int64ObservableGauge
 	ExporterEnqueueFailedLogRecords   metric.Int64Counter
 	ExporterEnqueueFailedMetricPoints metric.Int64Counter
 	ExporterEnqueueFailedSpans        metric.Int64Counter
@@ -55,6 +57,24 @@ func (tbof telemetryBuilderOptionFunc) apply(mb *TelemetryBuilder) {
 	tbof(mb)
 }
 
+// InitExporterCircuitBreakerState configures the ExporterCircuitBreakerState metric.
+func (builder *TelemetryBuilder) InitExporterCircuitBreakerState(cb func() int64, opts ...metric.ObserveOption) error {
+	var err error
+	builder.ExporterCircuitBreakerState, err = builder.meters[configtelemetry.LevelBasic].Int64ObservableGauge(
+		"otelcol_exporter_circuit_breaker_state",
+		metric.WithDescription("Current state of the circuit breaker, 0 for closed, 1 for half-open and 2 for open"),
+		metric.WithUnit("{state}"),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = builder.meters[configtelemetry.LevelBasic].RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(builder.ExporterCircuitBreakerState, cb(), opts...)
+		return nil
+	}, builder.ExporterCircuitBreakerState)
+	return err
+}
+
 // InitExporterQueueCapacity configures the ExporterQueueCapacity metric.
 func (builder *TelemetryBuilder) InitExporterQueueCapacity(cb func() int64, opts ...metric.ObserveOption) error {
 	var err error
@@ -100,6 +120,12 @@ func NewTelemetryBuilder(settings component.TelemetrySettings, options ...Teleme
 	}
 	builder.meters[configtelemetry.LevelBasic] = LeveledMeter(settings, configtelemetry.LevelBasic)
 	var err, errs error
+	builder.ExporterCircuitBreakerOpened, err = builder.meters[configtelemetry.LevelBasic].Int64Counter(
+		"otelcol_exporter_circuit_breaker_opened",
+		metric.WithDescription("Number of times the circuit breaker opened."),
+		metric.WithUnit("{transitions}"),
+	)
+	errs = errors.Join(errs, err)
 	builder.ExporterEnqueueFailedLogRecords, err = builder.meters[configtelemetry.LevelBasic].Int64Counter(
 		"otelcol_exporter_enqueue_failed_log_records",
 		metric.WithDescription("Number of log records failed to be added to the sending queue."),
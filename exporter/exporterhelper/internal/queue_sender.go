@@ -35,6 +35,11 @@ type QueueConfig struct {
 	// StorageID if not empty, enables the persistent storage and uses the component specified
 	// as a storage extension for the persistent queue
 	StorageID *component.ID `mapstructure:"storage"`
+	// SpilloverStorageID if not empty, keeps the batches in memory and writes them to the component specified as a
+	// storage extension only when the memory is full or on shutdown. It cannot be set together with StorageID.
+	SpilloverStorageID *component.ID `mapstructure:"spillover_storage"`
+	// AdaptiveConcurrency adjusts the number of batches sent concurrently, starting from NumConsumers.
+	AdaptiveConcurrency exporterqueue.AdaptiveConcurrencyConfig `mapstructure:"adaptive_concurrency"`
 }
 
 // NewDefaultQueueConfig returns the default config for QueueConfig.
@@ -45,7 +50,8 @@ func NewDefaultQueueConfig() QueueConfig {
 		// By default, batches are 8192 spans, for a total of up to 8 million spans in the queue
 		// This can be estimated at 1-4 GB worth of maximum memory usage
 		// This default is probably still too high, and may be adjusted further down in a future release
-		QueueSize: defaultQueueSize,
+		QueueSize:           defaultQueueSize,
+		AdaptiveConcurrency: exporterqueue.NewDefaultAdaptiveConcurrencyConfig(),
 	}
 }
 
@@ -63,13 +69,21 @@ func (qCfg *QueueConfig) Validate() error {
 		return errors.New("number of queue consumers must be positive")
 	}
 
-	return nil
+	if qCfg.StorageID != nil && qCfg.SpilloverStorageID != nil {
+		return errors.New("storage and spillover storage cannot be both set")
+	}
+
+	return qCfg.AdaptiveConcurrency.Validate()
 }
 
 type QueueSender struct {
 	BaseRequestSender
-	queue          exporterqueue.Queue[internal.Request]
-	numConsumers   int
+	queue exporterqueue.Queue[internal.Request]
+	// numConsumers is the highest number of requests consumed concurrently.
+	numConsumers int
+	// adaptive indicates that the number of requests consumed concurrently follows the outcome of the attempts to
+	// send them, reported to consumers.OnAttempt.
+	adaptive       bool
 	traceAttribute attribute.KeyValue
 	consumers      *queue.Consumers[internal.Request]
 
@@ -77,11 +91,11 @@ type QueueSender struct {
 	exporterID component.ID
 }
 
-func NewQueueSender(q exporterqueue.Queue[internal.Request], set exporter.Settings, numConsumers int,
+func NewQueueSender(q exporterqueue.Queue[internal.Request], set exporter.Settings, cfg exporterqueue.Config,
 	exportFailureMessage string, obsrep *ObsReport) *QueueSender {
 	qs := &QueueSender{
 		queue:          q,
-		numConsumers:   numConsumers,
+		numConsumers:   cfg.NumConsumers,
 		traceAttribute: attribute.String(ExporterKey, set.ID.String()),
 		obsrep:         obsrep,
 		exporterID:     set.ID,
@@ -94,7 +108,19 @@ func NewQueueSender(q exporterqueue.Queue[internal.Request], set exporter.Settin
 		}
 		return err
 	}
-	qs.consumers = queue.NewQueueConsumers[internal.Request](q, numConsumers, consumeFunc)
+	if cfg.AdaptiveConcurrency.Enabled {
+		qs.numConsumers = cfg.AdaptiveConcurrency.MaxConsumers
+		qs.adaptive = true
+		qs.consumers = queue.NewAdaptiveQueueConsumers[internal.Request](q, cfg.NumConsumers, queue.AdaptiveConcurrencySettings{
+			MinConsumers: cfg.AdaptiveConcurrency.MinConsumers,
+			MaxConsumers: cfg.AdaptiveConcurrency.MaxConsumers,
+			IsThrottled: func(err error) bool {
+				return errors.As(err, &throttleRetry{})
+			},
+		}, consumeFunc)
+		return qs
+	}
+	qs.consumers = queue.NewQueueConsumers[internal.Request](q, cfg.NumConsumers, consumeFunc)
 	return qs
 }
 
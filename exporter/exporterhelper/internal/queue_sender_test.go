@@ -6,6 +6,7 @@ package internal
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/config/configretry"
 	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterbatcher"
 	"go.opentelemetry.io/collector/exporter/exporterqueue"
 	"go.opentelemetry.io/collector/exporter/exportertest"
 	"go.opentelemetry.io/collector/exporter/internal"
@@ -128,6 +130,24 @@ func TestQueuedRetryHappyPath(t *testing.T) {
 				WithRetry(configretry.NewDefaultBackOffConfig()),
 			},
 		},
+		{
+			name: "WithQueue/AdaptiveConcurrency",
+			queueOptions: []Option{
+				WithMarshaler(mockRequestMarshaler),
+				WithUnmarshaler(mockRequestUnmarshaler(&mockRequest{})),
+				WithQueue(QueueConfig{
+					Enabled:      true,
+					QueueSize:    10,
+					NumConsumers: 1,
+					AdaptiveConcurrency: exporterqueue.AdaptiveConcurrencyConfig{
+						Enabled:      true,
+						MinConsumers: 1,
+						MaxConsumers: 4,
+					},
+				}),
+				WithRetry(configretry.NewDefaultBackOffConfig()),
+			},
+		},
 		{
 			name: "WithRequestQueue/MemoryQueueFactory",
 			queueOptions: []Option{
@@ -261,6 +281,17 @@ func TestQueueConfig_Validate(t *testing.T) {
 
 	require.EqualError(t, qCfg.Validate(), "number of queue consumers must be positive")
 
+	qCfg = NewDefaultQueueConfig()
+	storageID := component.MustNewID("file_storage")
+	qCfg.StorageID = &storageID
+	qCfg.SpilloverStorageID = &storageID
+	require.EqualError(t, qCfg.Validate(), "storage and spillover storage cannot be both set")
+
+	qCfg = NewDefaultQueueConfig()
+	qCfg.AdaptiveConcurrency.Enabled = true
+	qCfg.AdaptiveConcurrency.MaxConsumers = 0
+	require.EqualError(t, qCfg.Validate(), "maximum number of consumers must not be lower than the minimum")
+
 	// Confirm Validate doesn't return error with invalid config when feature is disabled
 	qCfg.Enabled = false
 	assert.NoError(t, qCfg.Validate())
@@ -429,6 +460,140 @@ func TestQueuedRetryPersistentEnabled_NoDataLossOnShutdown(t *testing.T) {
 	replacedReq.checkNumRequests(t, 1)
 }
 
+func TestQueuedRetrySpilloverQueue_NoDataLossOnShutdown(t *testing.T) {
+	qCfg := exporterqueue.NewDefaultConfig()
+	qCfg.NumConsumers = 1
+	storageID := component.MustNewIDWithName("file_storage", "storage")
+	newFactory := func(req internal.Request) exporterqueue.Factory[internal.Request] {
+		return exporterqueue.NewSpilloverQueueFactory[internal.Request](&storageID,
+			exporterqueue.SpilloverQueueSettings[internal.Request]{
+				PersistentQueueSettings: exporterqueue.PersistentQueueSettings[internal.Request]{
+					Marshaler:   mockRequestMarshaler,
+					Unmarshaler: mockRequestUnmarshaler(req),
+				},
+			})
+	}
+
+	rCfg := configretry.NewDefaultBackOffConfig()
+	rCfg.InitialInterval = time.Millisecond
+	rCfg.MaxElapsedTime = 0 // retry infinitely so shutdown can be triggered
+
+	mockReq := newErrorRequest()
+	be, err := NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender, WithRetry(rCfg),
+		WithRequestQueue(qCfg, newFactory(mockReq)))
+	require.NoError(t, err)
+
+	var extensions = map[component.ID]component.Component{
+		storageID: queue.NewMockStorageExtension(nil),
+	}
+	host := &MockHost{Ext: extensions}
+
+	require.NoError(t, be.Start(context.Background(), host))
+
+	// The request is kept in memory, and retried until the shutdown.
+	require.NoError(t, be.Send(context.Background(), mockReq))
+	assert.Eventually(t, func() bool {
+		return be.QueueSender.(*QueueSender).queue.Size() == 0
+	}, time.Second, 1*time.Millisecond)
+
+	// shuts down the exporter, the request being retried should be written to the storage.
+	require.NoError(t, be.Shutdown(context.Background()))
+
+	// start the exporter again replacing the preserved mockRequest in the unmarshaler with a new one that doesn't fail.
+	replacedReq := newMockRequest(1, nil)
+	be, err = NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender, WithRetry(rCfg),
+		WithRequestQueue(qCfg, newFactory(replacedReq)))
+	require.NoError(t, err)
+	require.NoError(t, be.Start(context.Background(), host))
+	t.Cleanup(func() { require.NoError(t, be.Shutdown(context.Background())) })
+
+	// wait for the item to be consumed from the queue
+	replacedReq.checkNumRequests(t, 1)
+}
+
+func TestQueuedRetrySpilloverQueueConfig(t *testing.T) {
+	qCfg := NewDefaultQueueConfig()
+	qCfg.NumConsumers = 1
+	storageID := component.MustNewIDWithName("file_storage", "storage")
+	qCfg.SpilloverStorageID = &storageID
+	rCfg := configretry.NewDefaultBackOffConfig()
+	rCfg.InitialInterval = time.Millisecond
+	rCfg.MaxElapsedTime = 0 // retry infinitely so shutdown can be triggered
+
+	mockReq := newErrorRequest()
+	be, err := NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender, WithMarshaler(mockRequestMarshaler),
+		WithUnmarshaler(mockRequestUnmarshaler(mockReq)), WithRetry(rCfg), WithQueue(qCfg))
+	require.NoError(t, err)
+
+	var extensions = map[component.ID]component.Component{
+		storageID: queue.NewMockStorageExtension(nil),
+	}
+	host := &MockHost{Ext: extensions}
+	require.NoError(t, be.Start(context.Background(), host))
+
+	// The request is kept in memory, so nothing is written to the storage until the shutdown.
+	require.NoError(t, be.Send(context.Background(), mockReq))
+	assert.Eventually(t, func() bool {
+		return be.QueueSender.(*QueueSender).queue.Size() == 0
+	}, time.Second, 1*time.Millisecond)
+	require.NoError(t, be.Shutdown(context.Background()))
+
+	replacedReq := newMockRequest(1, nil)
+	be, err = NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender, WithMarshaler(mockRequestMarshaler),
+		WithUnmarshaler(mockRequestUnmarshaler(replacedReq)), WithRetry(rCfg), WithQueue(qCfg))
+	require.NoError(t, err)
+	require.NoError(t, be.Start(context.Background(), host))
+	t.Cleanup(func() { require.NoError(t, be.Shutdown(context.Background())) })
+	replacedReq.checkNumRequests(t, 1)
+}
+
+func TestQueueSenderAdaptiveConcurrency(t *testing.T) {
+	qCfg := exporterqueue.NewDefaultConfig()
+	qCfg.NumConsumers = 1
+	qCfg.AdaptiveConcurrency = exporterqueue.AdaptiveConcurrencyConfig{Enabled: true, MinConsumers: 1, MaxConsumers: 4}
+	queueFactory := exporterqueue.NewMemoryQueueFactory[internal.Request]()
+
+	// The batch sender can merge as many requests as the consumers can send at most.
+	be, err := NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender,
+		WithRequestQueue(qCfg, queueFactory), WithBatcher(exporterbatcher.NewDefaultConfig()))
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, be.BatchSender.(*BatchSender).concurrencyLimit)
+
+	rCfg := configretry.NewDefaultBackOffConfig()
+	rCfg.InitialInterval = time.Millisecond
+	be, err = NewBaseExporter(defaultSettings, defaultSignal, newNoopObsrepSender, WithRetry(rCfg),
+		WithRequestQueue(qCfg, queueFactory))
+	require.NoError(t, err)
+	onAttempt := be.TimeoutSender.onAttempt
+	require.NotNil(t, onAttempt)
+	var mu sync.Mutex
+	var attemptErrs []error
+	be.TimeoutSender.onAttempt = func(rtt time.Duration, err error) {
+		mu.Lock()
+		attemptErrs = append(attemptErrs, err)
+		mu.Unlock()
+		onAttempt(rtt, err)
+	}
+	require.NoError(t, be.Start(context.Background(), componenttest.NewNopHost()))
+	t.Cleanup(func() {
+		assert.NoError(t, be.Shutdown(context.Background()))
+	})
+
+	// Every attempt is reported, including the throttled one absorbed by the retry sender.
+	mockR := newMockRequest(2, NewThrottleRetry(errors.New("throttled"), time.Millisecond))
+	require.NoError(t, be.Send(context.Background(), mockR))
+	mockR.checkNumRequests(t, 2)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(attemptErrs) == 2
+	}, time.Second, time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.ErrorAs(t, attemptErrs[0], &throttleRetry{})
+	assert.NoError(t, attemptErrs[1])
+}
+
 func TestQueueSenderNoStartShutdown(t *testing.T) {
 	queue := queue.NewBoundedMemoryQueue[internal.Request](queue.MemoryQueueSettings[internal.Request]{})
 	set := exportertest.NewNopSettings()
@@ -437,6 +602,6 @@ func TestQueueSenderNoStartShutdown(t *testing.T) {
 		ExporterCreateSettings: exportertest.NewNopSettings(),
 	})
 	require.NoError(t, err)
-	qs := NewQueueSender(queue, set, 1, "", obsrep)
+	qs := NewQueueSender(queue, set, exporterqueue.Config{NumConsumers: 1}, "", obsrep)
 	assert.NoError(t, qs.Shutdown(context.Background()))
 }
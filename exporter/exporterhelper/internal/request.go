@@ -30,6 +30,7 @@ func newFakeRequestSink() *fakeRequestSink {
 
 type fakeRequest struct {
 	items     int
+	bytes     int
 	exportErr error
 	mergeErr  error
 	delay     time.Duration
@@ -56,6 +57,10 @@ func (r *fakeRequest) ItemsCount() int {
 	return r.items
 }
 
+func (r *fakeRequest) BytesSize() int {
+	return r.bytes
+}
+
 func (r *fakeRequest) Merge(_ context.Context,
 	r2 internal.Request) (internal.Request, error) {
 	if r == nil {
@@ -67,6 +72,7 @@ func (r *fakeRequest) Merge(_ context.Context,
 	}
 	return &fakeRequest{
 		items:     r.items + fr2.items,
+		bytes:     r.bytes + fr2.bytes,
 		sink:      r.sink,
 		exportErr: fr2.exportErr,
 		delay:     r.delay + fr2.delay,
@@ -43,12 +43,23 @@ func NewThrottleRetry(err error, delay time.Duration) error {
 	}
 }
 
+// retryDeadlineKey is the context key of the time by which a request held by the circuit breaker must be sent, so the
+// retries of the request keep the MaxElapsedTime of its first attempt.
+type retryDeadlineKey struct{}
+
+func contextWithRetryDeadline(ctx context.Context, deadline time.Time) context.Context {
+	return context.WithValue(ctx, retryDeadlineKey{}, deadline)
+}
+
 type retrySender struct {
 	BaseRequestSender
 	traceAttribute attribute.KeyValue
 	cfg            configretry.BackOffConfig
 	stopCh         chan struct{}
 	logger         *zap.Logger
+	// circuitBreaker, if set, is told about every failed attempt, and the request is given back to it once the
+	// circuit opens.
+	circuitBreaker *circuitBreakerSender
 }
 
 func newRetrySender(config configretry.BackOffConfig, set exporter.Settings) *retrySender {
@@ -67,6 +78,17 @@ func (rs *retrySender) Shutdown(context.Context) error {
 
 // send implements the requestSender interface
 func (rs *retrySender) Send(ctx context.Context, req internal.Request) error {
+	// A request held by the circuit breaker only has the rest of its MaxElapsedTime left.
+	maxElapsedTime := rs.cfg.MaxElapsedTime
+	retryDeadline, resumed := ctx.Value(retryDeadlineKey{}).(time.Time)
+	switch {
+	case resumed:
+		// Zero would retry forever, the request is attempted once more instead.
+		maxElapsedTime = max(time.Until(retryDeadline), time.Nanosecond)
+	case maxElapsedTime > 0:
+		retryDeadline = time.Now().Add(maxElapsedTime)
+	}
+
 	// Do not use NewExponentialBackOff since it calls Reset and the code here must
 	// call Reset after changing the InitialInterval (this saves an unnecessary call to Now).
 	expBackoff := backoff.ExponentialBackOff{
@@ -74,7 +96,7 @@ func (rs *retrySender) Send(ctx context.Context, req internal.Request) error {
 		RandomizationFactor: rs.cfg.RandomizationFactor,
 		Multiplier:          rs.cfg.Multiplier,
 		MaxInterval:         rs.cfg.MaxInterval,
-		MaxElapsedTime:      rs.cfg.MaxElapsedTime,
+		MaxElapsedTime:      maxElapsedTime,
 		Stop:                backoff.Stop,
 		Clock:               backoff.SystemClock,
 	}
@@ -100,6 +122,10 @@ func (rs *retrySender) Send(ctx context.Context, req internal.Request) error {
 			req = errReq.OnError(err)
 		}
 
+		if rs.circuitBreaker != nil && rs.circuitBreaker.onFailure(err) {
+			return circuitOpenError{err: err, req: req, retryDeadline: retryDeadline}
+		}
+
 		backoffDelay := expBackoff.NextBackOff()
 		if backoffDelay == backoff.Stop {
 			return fmt.Errorf("no more retries left: %w", err)
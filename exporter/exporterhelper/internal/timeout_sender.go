@@ -37,9 +37,22 @@ func NewDefaultTimeoutConfig() TimeoutConfig {
 type TimeoutSender struct {
 	BaseRequestSender
 	cfg TimeoutConfig
+	// onAttempt, if set, is told about the outcome and the duration of every attempt to send data. The retry sender
+	// sends every attempt through the TimeoutSender, so the failed attempts that are retried are reported as well.
+	onAttempt func(rtt time.Duration, err error)
 }
 
 func (ts *TimeoutSender) Send(ctx context.Context, req internal.Request) error {
+	if ts.onAttempt == nil {
+		return ts.export(ctx, req)
+	}
+	start := time.Now()
+	err := ts.export(ctx, req)
+	ts.onAttempt(time.Since(start), err)
+	return err
+}
+
+func (ts *TimeoutSender) export(ctx context.Context, req internal.Request) error {
 	// TODO: Remove this by avoiding to create the timeout sender if timeout is 0.
 	if ts.cfg.Timeout == 0 {
 		return req.Export(ctx)
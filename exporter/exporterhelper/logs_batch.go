@@ -24,6 +24,10 @@ func (req *logsRequest) Merge(_ context.Context, r2 Request) (Request, error) {
 // MergeSplit splits and/or merges the provided logs request and the current request into one or more requests
 // conforming with the MaxSizeConfig.
 func (req *logsRequest) MergeSplit(_ context.Context, cfg exporterbatcher.MaxSizeConfig, r2 Request) ([]Request, error) {
+	if cfg.MaxSizeBytes > 0 {
+		return req.mergeSplitBytes(cfg, r2)
+	}
+
 	var (
 		res          []Request
 		destReq      *logsRequest
@@ -73,6 +77,83 @@ func (req *logsRequest) MergeSplit(_ context.Context, cfg exporterbatcher.MaxSiz
 	return res, nil
 }
 
+// mergeSplitBytes merges the provided logs request and the current request, and splits them into requests of at most
+// MaxSizeBytes bytes and MaxSizeItems log records.
+func (req *logsRequest) mergeSplitBytes(cfg exporterbatcher.MaxSizeConfig, r2 Request) ([]Request, error) {
+	srcs := []*logsRequest{req}
+	if r2 != nil {
+		lr2, ok := r2.(*logsRequest)
+		if !ok {
+			return nil, errors.New("invalid input type")
+		}
+		srcs = append(srcs, lr2)
+	}
+	items, size := 0, 0
+	for _, src := range srcs {
+		items += src.ld.LogRecordCount()
+		size += logsMarshaler.LogsSize(src.ld)
+	}
+	if size <= cfg.MaxSizeBytes && (cfg.MaxSizeItems == 0 || items <= cfg.MaxSizeItems) {
+		for _, src := range srcs[1:] {
+			src.ld.ResourceLogs().MoveAndAppendTo(req.ld.ResourceLogs())
+		}
+		return []Request{req}, nil
+	}
+
+	var (
+		res      []Request
+		splitter = bytesSplitter{cfg: cfg}
+		dest     = plog.NewLogs()
+		srcRL    plog.ResourceLogs
+		srcSL    plog.ScopeLogs
+		destRL   plog.ResourceLogs
+		destSL   plog.ScopeLogs
+	)
+	open := func(depth int) {
+		switch depth {
+		case 0:
+			destRL = dest.ResourceLogs().AppendEmpty()
+			destRL.SetSchemaUrl(srcRL.SchemaUrl())
+			srcRL.Resource().CopyTo(destRL.Resource())
+		case 1:
+			destSL = destRL.ScopeLogs().AppendEmpty()
+			destSL.SetSchemaUrl(srcSL.SchemaUrl())
+			srcSL.Scope().CopyTo(destSL.Scope())
+		}
+	}
+	for _, src := range srcs {
+		for i := 0; i < src.ld.ResourceLogs().Len(); i++ {
+			srcRL = src.ld.ResourceLogs().At(i)
+			rlSize := logsMarshaler.ResourceLogsSize(srcRL)
+			for j := 0; j < srcRL.ScopeLogs().Len(); j++ {
+				rlSize -= protoFieldSize(logsMarshaler.ScopeLogsSize(srcRL.ScopeLogs().At(j)))
+			}
+			splitter.enter(0, rlSize)
+			for j := 0; j < srcRL.ScopeLogs().Len(); j++ {
+				srcSL = srcRL.ScopeLogs().At(j)
+				records := srcSL.LogRecords()
+				slSize := logsMarshaler.ScopeLogsSize(srcSL)
+				sizes := make([]int, records.Len())
+				for k := range sizes {
+					sizes[k] = logsMarshaler.LogRecordSize(records.At(k))
+					slSize -= protoFieldSize(sizes[k])
+				}
+				splitter.enter(1, slSize)
+				for k, size := range sizes {
+					if !splitter.fits(2, size) {
+						res = append(res, &logsRequest{ld: dest, pusher: req.pusher})
+						dest = plog.NewLogs()
+						splitter.reset()
+					}
+					splitter.add(2, size, open)
+					records.At(k).MoveTo(destSL.LogRecords().AppendEmpty())
+				}
+			}
+		}
+	}
+	return append(res, &logsRequest{ld: dest, pusher: req.pusher}), nil
+}
+
 // extractLogs extracts logs from the input logs and returns a new logs with the specified number of log records.
 func extractLogs(srcLogs plog.Logs, count int) plog.Logs {
 	destLogs := plog.NewLogs()
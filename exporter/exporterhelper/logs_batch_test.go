@@ -5,6 +5,7 @@ package exporterhelper
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -144,3 +145,85 @@ func TestExtractLogs(t *testing.T) {
 		assert.Equal(t, 10-i, ld.LogRecordCount())
 	}
 }
+
+func TestMergeSplitLogsBytes(t *testing.T) {
+	// Log records of the same size, so that the size of requests of n of them is known.
+	generate := func(n int) plog.Logs {
+		ld := plog.NewLogs()
+		records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+		for i := 0; i < n; i++ {
+			records.AppendEmpty().Body().SetStr(fmt.Sprintf("log record %03d", i))
+		}
+		return ld
+	}
+	sizeOf := func(n int) int {
+		return logsMarshaler.LogsSize(generate(n))
+	}
+	tests := []struct {
+		name     string
+		cfg      exporterbatcher.MaxSizeConfig
+		expected []int
+	}{
+		{
+			name:     "split_at_limit",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(3)},
+			expected: []int{3, 3, 3, 1},
+		},
+		{
+			name:     "split_below_limit",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(3) - 1},
+			expected: []int{2, 2, 2, 2, 2},
+		},
+		{
+			name:     "items_limit_reached_first",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(5), MaxSizeItems: 4},
+			expected: []int{4, 4, 2},
+		},
+		{
+			name:     "no_split",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(10)},
+			expected: []int{10},
+		},
+		{
+			name:     "log_records_larger_than_limit",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: 1},
+			expected: []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &logsRequest{ld: generate(10)}
+			res, err := req.MergeSplit(context.Background(), tt.cfg, nil)
+			require.NoError(t, err)
+			var counts []int
+			for i, r := range res {
+				ld := r.(*logsRequest).ld
+				counts = append(counts, ld.LogRecordCount())
+				if i < len(res)-1 && ld.LogRecordCount() > 1 {
+					// Requests are filled up to the byte.
+					assert.Equal(t, sizeOf(ld.LogRecordCount()), r.(RequestBytesSizer).BytesSize())
+				}
+			}
+			assert.Equal(t, tt.expected, counts)
+		})
+	}
+}
+
+func TestMergeSplitLogsBytesLimits(t *testing.T) {
+	total := 2 * logsMarshaler.LogsSize(testdata.GenerateLogs(10))
+	for maxBytes := 100; maxBytes <= total; maxBytes += 97 {
+		r1 := &logsRequest{ld: testdata.GenerateLogs(10)}
+		r2 := &logsRequest{ld: testdata.GenerateLogs(10)}
+		res, err := r1.MergeSplit(context.Background(), exporterbatcher.MaxSizeConfig{MaxSizeBytes: maxBytes}, r2)
+		require.NoError(t, err)
+		count := 0
+		for _, r := range res {
+			ld := r.(*logsRequest).ld
+			count += ld.LogRecordCount()
+			if ld.LogRecordCount() > 1 {
+				assert.LessOrEqual(t, logsMarshaler.LogsSize(ld), maxBytes)
+			}
+		}
+		assert.Equal(t, 2*testdata.GenerateLogs(10).LogRecordCount(), count)
+	}
+}
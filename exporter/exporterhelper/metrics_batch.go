@@ -24,6 +24,10 @@ func (req *metricsRequest) Merge(_ context.Context, r2 Request) (Request, error)
 // MergeSplit splits and/or merges the provided metrics request and the current request into one or more requests
 // conforming with the MaxSizeConfig.
 func (req *metricsRequest) MergeSplit(_ context.Context, cfg exporterbatcher.MaxSizeConfig, r2 Request) ([]Request, error) {
+	if cfg.MaxSizeBytes > 0 {
+		return req.mergeSplitBytes(cfg, r2)
+	}
+
 	var (
 		res          []Request
 		destReq      *metricsRequest
@@ -74,6 +78,186 @@ func (req *metricsRequest) MergeSplit(_ context.Context, cfg exporterbatcher.Max
 	return res, nil
 }
 
+// mergeSplitBytes merges the provided metrics request and the current request, and splits them into requests of at
+// most MaxSizeBytes bytes and MaxSizeItems data points.
+func (req *metricsRequest) mergeSplitBytes(cfg exporterbatcher.MaxSizeConfig, r2 Request) ([]Request, error) {
+	srcs := []*metricsRequest{req}
+	if r2 != nil {
+		mr2, ok := r2.(*metricsRequest)
+		if !ok {
+			return nil, errors.New("invalid input type")
+		}
+		srcs = append(srcs, mr2)
+	}
+	items, size := 0, 0
+	for _, src := range srcs {
+		items += src.md.DataPointCount()
+		size += metricsMarshaler.MetricsSize(src.md)
+	}
+	if size <= cfg.MaxSizeBytes && (cfg.MaxSizeItems == 0 || items <= cfg.MaxSizeItems) {
+		for _, src := range srcs[1:] {
+			src.md.ResourceMetrics().MoveAndAppendTo(req.md.ResourceMetrics())
+		}
+		return []Request{req}, nil
+	}
+
+	var (
+		res        []Request
+		splitter   = bytesSplitter{cfg: cfg}
+		dest       = pmetric.NewMetrics()
+		srcRM      pmetric.ResourceMetrics
+		srcSM      pmetric.ScopeMetrics
+		srcMetric  pmetric.Metric
+		destRM     pmetric.ResourceMetrics
+		destSM     pmetric.ScopeMetrics
+		destMetric pmetric.Metric
+	)
+	open := func(depth int) {
+		switch depth {
+		case 0:
+			destRM = dest.ResourceMetrics().AppendEmpty()
+			destRM.SetSchemaUrl(srcRM.SchemaUrl())
+			srcRM.Resource().CopyTo(destRM.Resource())
+		case 1:
+			destSM = destRM.ScopeMetrics().AppendEmpty()
+			destSM.SetSchemaUrl(srcSM.SchemaUrl())
+			srcSM.Scope().CopyTo(destSM.Scope())
+		case 2:
+			destMetric = destSM.Metrics().AppendEmpty()
+			destMetric.SetName(srcMetric.Name())
+			destMetric.SetDescription(srcMetric.Description())
+			destMetric.SetUnit(srcMetric.Unit())
+			srcMetric.Metadata().CopyTo(destMetric.Metadata())
+		case 3:
+			copyMetricData(srcMetric, destMetric)
+		}
+	}
+	for _, src := range srcs {
+		for i := 0; i < src.md.ResourceMetrics().Len(); i++ {
+			srcRM = src.md.ResourceMetrics().At(i)
+			rmSize := metricsMarshaler.ResourceMetricsSize(srcRM)
+			for j := 0; j < srcRM.ScopeMetrics().Len(); j++ {
+				rmSize -= protoFieldSize(metricsMarshaler.ScopeMetricsSize(srcRM.ScopeMetrics().At(j)))
+			}
+			splitter.enter(0, rmSize)
+			for j := 0; j < srcRM.ScopeMetrics().Len(); j++ {
+				srcSM = srcRM.ScopeMetrics().At(j)
+				smSize := metricsMarshaler.ScopeMetricsSize(srcSM)
+				for k := 0; k < srcSM.Metrics().Len(); k++ {
+					smSize -= protoFieldSize(metricsMarshaler.MetricSize(srcSM.Metrics().At(k)))
+				}
+				splitter.enter(1, smSize)
+				for k := 0; k < srcSM.Metrics().Len(); k++ {
+					srcMetric = srcSM.Metrics().At(k)
+					sizes := dataPointSizes(srcMetric)
+					dataHeader := metricDataHeaderSize(srcMetric)
+					dataSize := dataHeader
+					for _, size := range sizes {
+						dataSize += protoFieldSize(size)
+					}
+					splitter.enter(2, metricsMarshaler.MetricSize(srcMetric)-protoFieldSize(dataSize))
+					splitter.enter(3, dataHeader)
+					for l, size := range sizes {
+						if !splitter.fits(4, size) {
+							res = append(res, &metricsRequest{md: dest, pusher: req.pusher})
+							dest = pmetric.NewMetrics()
+							splitter.reset()
+						}
+						splitter.add(4, size, open)
+						moveDataPoint(srcMetric, l, destMetric)
+					}
+				}
+			}
+		}
+	}
+	return append(res, &metricsRequest{md: dest, pusher: req.pusher}), nil
+}
+
+// dataPointSizes returns the size of each data point of the metric.
+func dataPointSizes(m pmetric.Metric) []int {
+	var sizes []int
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		for i := 0; i < m.Gauge().DataPoints().Len(); i++ {
+			sizes = append(sizes, metricsMarshaler.NumberDataPointSize(m.Gauge().DataPoints().At(i)))
+		}
+	case pmetric.MetricTypeSum:
+		for i := 0; i < m.Sum().DataPoints().Len(); i++ {
+			sizes = append(sizes, metricsMarshaler.NumberDataPointSize(m.Sum().DataPoints().At(i)))
+		}
+	case pmetric.MetricTypeHistogram:
+		for i := 0; i < m.Histogram().DataPoints().Len(); i++ {
+			sizes = append(sizes, metricsMarshaler.HistogramDataPointSize(m.Histogram().DataPoints().At(i)))
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		for i := 0; i < m.ExponentialHistogram().DataPoints().Len(); i++ {
+			sizes = append(sizes, metricsMarshaler.ExponentialHistogramDataPointSize(m.ExponentialHistogram().DataPoints().At(i)))
+		}
+	case pmetric.MetricTypeSummary:
+		for i := 0; i < m.Summary().DataPoints().Len(); i++ {
+			sizes = append(sizes, metricsMarshaler.SummaryDataPointSize(m.Summary().DataPoints().At(i)))
+		}
+	}
+	return sizes
+}
+
+// metricDataHeaderSize returns the size of the data of the metric without its data points: the fields for its
+// aggregation temporality and monotonicity, when they are not zero.
+func metricDataHeaderSize(m pmetric.Metric) int {
+	size := 0
+	var temporality pmetric.AggregationTemporality
+	switch m.Type() {
+	case pmetric.MetricTypeSum:
+		temporality = m.Sum().AggregationTemporality()
+		if m.Sum().IsMonotonic() {
+			size += 2
+		}
+	case pmetric.MetricTypeHistogram:
+		temporality = m.Histogram().AggregationTemporality()
+	case pmetric.MetricTypeExponentialHistogram:
+		temporality = m.ExponentialHistogram().AggregationTemporality()
+	}
+	if temporality != pmetric.AggregationTemporalityUnspecified {
+		size += 1 + protoVarintSize(uint64(temporality))
+	}
+	return size
+}
+
+// copyMetricData sets the data of the destination metric to the type, aggregation temporality and monotonicity of the
+// data of the source metric, without data points.
+func copyMetricData(src, dest pmetric.Metric) {
+	switch src.Type() {
+	case pmetric.MetricTypeGauge:
+		dest.SetEmptyGauge()
+	case pmetric.MetricTypeSum:
+		dest.SetEmptySum().SetAggregationTemporality(src.Sum().AggregationTemporality())
+		dest.Sum().SetIsMonotonic(src.Sum().IsMonotonic())
+	case pmetric.MetricTypeHistogram:
+		dest.SetEmptyHistogram().SetAggregationTemporality(src.Histogram().AggregationTemporality())
+	case pmetric.MetricTypeExponentialHistogram:
+		dest.SetEmptyExponentialHistogram().SetAggregationTemporality(src.ExponentialHistogram().AggregationTemporality())
+	case pmetric.MetricTypeSummary:
+		dest.SetEmptySummary()
+	}
+}
+
+// moveDataPoint moves the data point at index i of the source metric to the end of the data points of the
+// destination metric, which has the same type.
+func moveDataPoint(src pmetric.Metric, i int, dest pmetric.Metric) {
+	switch src.Type() {
+	case pmetric.MetricTypeGauge:
+		src.Gauge().DataPoints().At(i).MoveTo(dest.Gauge().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeSum:
+		src.Sum().DataPoints().At(i).MoveTo(dest.Sum().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeHistogram:
+		src.Histogram().DataPoints().At(i).MoveTo(dest.Histogram().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeExponentialHistogram:
+		src.ExponentialHistogram().DataPoints().At(i).MoveTo(dest.ExponentialHistogram().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeSummary:
+		src.Summary().DataPoints().At(i).MoveTo(dest.Summary().DataPoints().AppendEmpty())
+	}
+}
+
 // extractMetrics extracts metrics from srcMetrics until count of data points is reached.
 func extractMetrics(srcMetrics pmetric.Metrics, count int) pmetric.Metrics {
 	destMetrics := pmetric.NewMetrics()
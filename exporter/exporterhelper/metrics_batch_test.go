@@ -151,3 +151,87 @@ func TestExtractMetricsInvalidMetric(t *testing.T) {
 	assert.Equal(t, testdata.GenerateMetricsMetricTypeInvalid(), extractedMetrics)
 	assert.Equal(t, 0, md.ResourceMetrics().Len())
 }
+
+func TestMergeSplitMetricsBytes(t *testing.T) {
+	// Data points of the same size, so that the size of requests of n of them is known.
+	generate := func(n int) pmetric.Metrics {
+		md := pmetric.NewMetrics()
+		sum := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetEmptySum()
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		sum.SetIsMonotonic(true)
+		for i := 0; i < n; i++ {
+			sum.DataPoints().AppendEmpty().SetIntValue(int64(100 + i))
+		}
+		return md
+	}
+	sizeOf := func(n int) int {
+		return metricsMarshaler.MetricsSize(generate(n))
+	}
+	tests := []struct {
+		name     string
+		cfg      exporterbatcher.MaxSizeConfig
+		expected []int
+	}{
+		{
+			name:     "split_at_limit",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(3)},
+			expected: []int{3, 3, 3, 1},
+		},
+		{
+			name:     "split_below_limit",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(3) - 1},
+			expected: []int{2, 2, 2, 2, 2},
+		},
+		{
+			name:     "items_limit_reached_first",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(5), MaxSizeItems: 4},
+			expected: []int{4, 4, 2},
+		},
+		{
+			name:     "no_split",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(10)},
+			expected: []int{10},
+		},
+		{
+			name:     "data_points_larger_than_limit",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: 1},
+			expected: []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &metricsRequest{md: generate(10)}
+			res, err := req.MergeSplit(context.Background(), tt.cfg, nil)
+			require.NoError(t, err)
+			var counts []int
+			for i, r := range res {
+				md := r.(*metricsRequest).md
+				counts = append(counts, md.DataPointCount())
+				if i < len(res)-1 && md.DataPointCount() > 1 {
+					// Requests are filled up to the byte.
+					assert.Equal(t, sizeOf(md.DataPointCount()), r.(RequestBytesSizer).BytesSize())
+				}
+			}
+			assert.Equal(t, tt.expected, counts)
+		})
+	}
+}
+
+func TestMergeSplitMetricsBytesLimits(t *testing.T) {
+	total := 2 * metricsMarshaler.MetricsSize(testdata.GenerateMetrics(10))
+	for maxBytes := 100; maxBytes <= total; maxBytes += 97 {
+		r1 := &metricsRequest{md: testdata.GenerateMetrics(10)}
+		r2 := &metricsRequest{md: testdata.GenerateMetrics(10)}
+		res, err := r1.MergeSplit(context.Background(), exporterbatcher.MaxSizeConfig{MaxSizeBytes: maxBytes}, r2)
+		require.NoError(t, err)
+		count := 0
+		for _, r := range res {
+			md := r.(*metricsRequest).md
+			count += md.DataPointCount()
+			if md.DataPointCount() > 1 {
+				assert.LessOrEqual(t, metricsMarshaler.MetricsSize(md), maxBytes)
+			}
+		}
+		assert.Equal(t, 2*testdata.GenerateMetrics(10).DataPointCount(), count)
+	}
+}
@@ -24,6 +24,10 @@ func (req *tracesRequest) Merge(_ context.Context, r2 Request) (Request, error)
 // MergeSplit splits and/or merges the provided traces request and the current request into one or more requests
 // conforming with the MaxSizeConfig.
 func (req *tracesRequest) MergeSplit(_ context.Context, cfg exporterbatcher.MaxSizeConfig, r2 Request) ([]Request, error) {
+	if cfg.MaxSizeBytes > 0 {
+		return req.mergeSplitBytes(cfg, r2)
+	}
+
 	var (
 		res          []Request
 		destReq      *tracesRequest
@@ -73,6 +77,83 @@ func (req *tracesRequest) MergeSplit(_ context.Context, cfg exporterbatcher.MaxS
 	return res, nil
 }
 
+// mergeSplitBytes merges the provided traces request and the current request, and splits them into requests of at most
+// MaxSizeBytes bytes and MaxSizeItems spans.
+func (req *tracesRequest) mergeSplitBytes(cfg exporterbatcher.MaxSizeConfig, r2 Request) ([]Request, error) {
+	srcs := []*tracesRequest{req}
+	if r2 != nil {
+		tr2, ok := r2.(*tracesRequest)
+		if !ok {
+			return nil, errors.New("invalid input type")
+		}
+		srcs = append(srcs, tr2)
+	}
+	items, size := 0, 0
+	for _, src := range srcs {
+		items += src.td.SpanCount()
+		size += tracesMarshaler.TracesSize(src.td)
+	}
+	if size <= cfg.MaxSizeBytes && (cfg.MaxSizeItems == 0 || items <= cfg.MaxSizeItems) {
+		for _, src := range srcs[1:] {
+			src.td.ResourceSpans().MoveAndAppendTo(req.td.ResourceSpans())
+		}
+		return []Request{req}, nil
+	}
+
+	var (
+		res      []Request
+		splitter = bytesSplitter{cfg: cfg}
+		dest     = ptrace.NewTraces()
+		srcRS    ptrace.ResourceSpans
+		srcSS    ptrace.ScopeSpans
+		destRS   ptrace.ResourceSpans
+		destSS   ptrace.ScopeSpans
+	)
+	open := func(depth int) {
+		switch depth {
+		case 0:
+			destRS = dest.ResourceSpans().AppendEmpty()
+			destRS.SetSchemaUrl(srcRS.SchemaUrl())
+			srcRS.Resource().CopyTo(destRS.Resource())
+		case 1:
+			destSS = destRS.ScopeSpans().AppendEmpty()
+			destSS.SetSchemaUrl(srcSS.SchemaUrl())
+			srcSS.Scope().CopyTo(destSS.Scope())
+		}
+	}
+	for _, src := range srcs {
+		for i := 0; i < src.td.ResourceSpans().Len(); i++ {
+			srcRS = src.td.ResourceSpans().At(i)
+			rsSize := tracesMarshaler.ResourceSpansSize(srcRS)
+			for j := 0; j < srcRS.ScopeSpans().Len(); j++ {
+				rsSize -= protoFieldSize(tracesMarshaler.ScopeSpansSize(srcRS.ScopeSpans().At(j)))
+			}
+			splitter.enter(0, rsSize)
+			for j := 0; j < srcRS.ScopeSpans().Len(); j++ {
+				srcSS = srcRS.ScopeSpans().At(j)
+				spans := srcSS.Spans()
+				ssSize := tracesMarshaler.ScopeSpansSize(srcSS)
+				sizes := make([]int, spans.Len())
+				for k := range sizes {
+					sizes[k] = tracesMarshaler.SpanSize(spans.At(k))
+					ssSize -= protoFieldSize(sizes[k])
+				}
+				splitter.enter(1, ssSize)
+				for k, size := range sizes {
+					if !splitter.fits(2, size) {
+						res = append(res, &tracesRequest{td: dest, pusher: req.pusher})
+						dest = ptrace.NewTraces()
+						splitter.reset()
+					}
+					splitter.add(2, size, open)
+					spans.At(k).MoveTo(destSS.Spans().AppendEmpty())
+				}
+			}
+		}
+	}
+	return append(res, &tracesRequest{td: dest, pusher: req.pusher}), nil
+}
+
 // extractTraces extracts a new traces with a maximum number of spans.
 func extractTraces(srcTraces ptrace.Traces, count int) ptrace.Traces {
 	destTraces := ptrace.NewTraces()
@@ -5,6 +5,7 @@ package exporterhelper
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -151,3 +152,85 @@ func TestExtractTraces(t *testing.T) {
 		assert.Equal(t, 10-i, td.SpanCount())
 	}
 }
+
+func TestMergeSplitTracesBytes(t *testing.T) {
+	// Spans of the same size, so that the size of requests of n of them is known.
+	generate := func(n int) ptrace.Traces {
+		td := ptrace.NewTraces()
+		spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+		for i := 0; i < n; i++ {
+			spans.AppendEmpty().SetName(fmt.Sprintf("span %03d", i))
+		}
+		return td
+	}
+	sizeOf := func(n int) int {
+		return tracesMarshaler.TracesSize(generate(n))
+	}
+	tests := []struct {
+		name     string
+		cfg      exporterbatcher.MaxSizeConfig
+		expected []int
+	}{
+		{
+			name:     "split_at_limit",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(3)},
+			expected: []int{3, 3, 3, 1},
+		},
+		{
+			name:     "split_below_limit",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(3) - 1},
+			expected: []int{2, 2, 2, 2, 2},
+		},
+		{
+			name:     "items_limit_reached_first",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(5), MaxSizeItems: 4},
+			expected: []int{4, 4, 2},
+		},
+		{
+			name:     "no_split",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: sizeOf(10)},
+			expected: []int{10},
+		},
+		{
+			name:     "spans_larger_than_limit",
+			cfg:      exporterbatcher.MaxSizeConfig{MaxSizeBytes: 1},
+			expected: []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &tracesRequest{td: generate(10)}
+			res, err := req.MergeSplit(context.Background(), tt.cfg, nil)
+			require.NoError(t, err)
+			var counts []int
+			for i, r := range res {
+				td := r.(*tracesRequest).td
+				counts = append(counts, td.SpanCount())
+				if i < len(res)-1 && td.SpanCount() > 1 {
+					// Requests are filled up to the byte.
+					assert.Equal(t, sizeOf(td.SpanCount()), r.(RequestBytesSizer).BytesSize())
+				}
+			}
+			assert.Equal(t, tt.expected, counts)
+		})
+	}
+}
+
+func TestMergeSplitTracesBytesLimits(t *testing.T) {
+	total := 2 * tracesMarshaler.TracesSize(testdata.GenerateTraces(10))
+	for maxBytes := 100; maxBytes <= total; maxBytes += 97 {
+		r1 := &tracesRequest{td: testdata.GenerateTraces(10)}
+		r2 := &tracesRequest{td: testdata.GenerateTraces(10)}
+		res, err := r1.MergeSplit(context.Background(), exporterbatcher.MaxSizeConfig{MaxSizeBytes: maxBytes}, r2)
+		require.NoError(t, err)
+		count := 0
+		for _, r := range res {
+			td := r.(*tracesRequest).td
+			count += td.SpanCount()
+			if td.SpanCount() > 1 {
+				assert.LessOrEqual(t, tracesMarshaler.TracesSize(td), maxBytes)
+			}
+		}
+		assert.Equal(t, 2*testdata.GenerateTraces(10).SpanCount(), count)
+	}
+}
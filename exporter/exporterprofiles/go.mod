@@ -71,3 +71,5 @@ replace go.opentelemetry.io/collector/exporter/exportertest => ../exportertest
 replace go.opentelemetry.io/collector/consumer/consumererror => ../../consumer/consumererror
 
 replace go.opentelemetry.io/collector/receiver/receivertest => ../../receiver/receivertest
+
+replace go.opentelemetry.io/collector/component/componentstatus => ../../component/componentstatus
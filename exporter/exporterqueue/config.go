@@ -17,9 +17,12 @@ type Config struct {
 	// Enabled indicates whether to not enqueue batches before exporting.
 	Enabled bool `mapstructure:"enabled"`
 	// NumConsumers is the number of consumers from the queue.
+	// If the adaptive concurrency is enabled, it's the initial number of requests exported concurrently.
 	NumConsumers int `mapstructure:"num_consumers"`
 	// QueueSize is the maximum number of requests allowed in queue at any given time.
 	QueueSize int `mapstructure:"queue_size"`
+	// AdaptiveConcurrency adjusts the number of requests exported concurrently to the backend.
+	AdaptiveConcurrency AdaptiveConcurrencyConfig `mapstructure:"adaptive_concurrency"`
 }
 
 // NewDefaultConfig returns the default Config.
@@ -27,9 +30,10 @@ type Config struct {
 // until https://github.com/open-telemetry/opentelemetry-collector/issues/8122 is resolved.
 func NewDefaultConfig() Config {
 	return Config{
-		Enabled:      true,
-		NumConsumers: 10,
-		QueueSize:    1_000,
+		Enabled:             true,
+		NumConsumers:        10,
+		QueueSize:           1_000,
+		AdaptiveConcurrency: NewDefaultAdaptiveConcurrencyConfig(),
 	}
 }
 
@@ -44,6 +48,46 @@ func (qCfg *Config) Validate() error {
 	if qCfg.QueueSize <= 0 {
 		return errors.New("queue size must be positive")
 	}
+	return qCfg.AdaptiveConcurrency.Validate()
+}
+
+// AdaptiveConcurrencyConfig defines configuration for adjusting the number of requests exported concurrently, between
+// MinConsumers and MaxConsumers. The number of consumers is increased while the requests succeed without a rise of
+// their latency, and decreased when the backend throttles the requests or their latency rises.
+// Experimental: This API is at the early stage of development and may change without backward compatibility
+// until https://github.com/open-telemetry/opentelemetry-collector/issues/8122 is resolved.
+type AdaptiveConcurrencyConfig struct {
+	// Enabled indicates whether to adjust the number of consumers. If disabled, NumConsumers requests are exported
+	// concurrently.
+	Enabled bool `mapstructure:"enabled"`
+	// MinConsumers is the lowest number of requests exported concurrently.
+	MinConsumers int `mapstructure:"min_consumers"`
+	// MaxConsumers is the highest number of requests exported concurrently.
+	MaxConsumers int `mapstructure:"max_consumers"`
+}
+
+// NewDefaultAdaptiveConcurrencyConfig returns the default AdaptiveConcurrencyConfig.
+// Experimental: This API is at the early stage of development and may change without backward compatibility
+// until https://github.com/open-telemetry/opentelemetry-collector/issues/8122 is resolved.
+func NewDefaultAdaptiveConcurrencyConfig() AdaptiveConcurrencyConfig {
+	return AdaptiveConcurrencyConfig{
+		Enabled:      false,
+		MinConsumers: 1,
+		MaxConsumers: 100,
+	}
+}
+
+// Validate checks if the AdaptiveConcurrencyConfig is valid
+func (acCfg *AdaptiveConcurrencyConfig) Validate() error {
+	if !acCfg.Enabled {
+		return nil
+	}
+	if acCfg.MinConsumers <= 0 {
+		return errors.New("minimum number of consumers must be positive")
+	}
+	if acCfg.MaxConsumers < acCfg.MinConsumers {
+		return errors.New("maximum number of consumers must not be lower than the minimum")
+	}
 	return nil
 }
 
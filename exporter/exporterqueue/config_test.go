@@ -25,3 +25,21 @@ func TestQueueConfig_Validate(t *testing.T) {
 	qCfg.Enabled = false
 	assert.NoError(t, qCfg.Validate())
 }
+
+func TestAdaptiveConcurrencyConfig_Validate(t *testing.T) {
+	qCfg := NewDefaultConfig()
+	qCfg.AdaptiveConcurrency.Enabled = true
+	require.NoError(t, qCfg.Validate())
+
+	qCfg.AdaptiveConcurrency.MinConsumers = 0
+	require.EqualError(t, qCfg.Validate(), "minimum number of consumers must be positive")
+
+	qCfg = NewDefaultConfig()
+	qCfg.AdaptiveConcurrency.Enabled = true
+	qCfg.AdaptiveConcurrency.MaxConsumers = 0
+	require.EqualError(t, qCfg.Validate(), "maximum number of consumers must not be lower than the minimum")
+
+	// Confirm Validate doesn't return error with invalid config when adaptive concurrency is disabled
+	qCfg.AdaptiveConcurrency.Enabled = false
+	assert.NoError(t, qCfg.Validate())
+}
@@ -86,3 +86,43 @@ func NewPersistentQueueFactory[T any](storageID *component.ID, factorySettings P
 		})
 	}
 }
+
+// SpilloverQueueSettings defines developer settings for the spillover queue factory.
+// Experimental: This API is at the early stage of development and may change without backward compatibility
+// until https://github.com/open-telemetry/opentelemetry-collector/issues/8122 is resolved.
+type SpilloverQueueSettings[T any] struct {
+	PersistentQueueSettings[T]
+	// MemoryQueueSize is the maximum number of requests kept in memory before new ones are written to the persistent
+	// storage. If zero, the queue size is used.
+	MemoryQueueSize int
+}
+
+// NewSpilloverQueueFactory returns a factory to create a new spillover queue. The queue keeps the requests in memory,
+// and writes them to the persistent storage only when the memory is full or on shutdown. The requests written to the
+// storage are read again after a restart. cfg.QueueSize is the capacity of the storage.
+// If cfg.StorageID is nil then it falls back to memory queue.
+// Experimental: This API is at the early stage of development and may change without backward compatibility
+// until https://github.com/open-telemetry/opentelemetry-collector/issues/8122 is resolved.
+func NewSpilloverQueueFactory[T any](storageID *component.ID, factorySettings SpilloverQueueSettings[T]) Factory[T] {
+	if storageID == nil {
+		return NewMemoryQueueFactory[T]()
+	}
+	return func(_ context.Context, set Settings, cfg Config) Queue[T] {
+		memoryQueueSize := factorySettings.MemoryQueueSize
+		if memoryQueueSize == 0 {
+			memoryQueueSize = cfg.QueueSize
+		}
+		return queue.NewSpilloverQueue[T](queue.SpilloverQueueSettings[T]{
+			PersistentQueueSettings: queue.PersistentQueueSettings[T]{
+				Sizer:            &queue.RequestSizer[T]{},
+				Capacity:         int64(cfg.QueueSize),
+				Signal:           set.Signal,
+				StorageID:        *storageID,
+				Marshaler:        factorySettings.Marshaler,
+				Unmarshaler:      factorySettings.Unmarshaler,
+				ExporterSettings: set.ExporterSettings,
+			},
+			MemoryCapacity: int64(memoryQueueSize),
+		})
+	}
+}
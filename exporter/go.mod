@@ -6,6 +6,7 @@ require (
 	github.com/cenkalti/backoff/v4 v4.3.0
 	github.com/stretchr/testify v1.9.0
 	go.opentelemetry.io/collector/component v0.112.0
+	go.opentelemetry.io/collector/component/componentstatus v0.112.0
 	go.opentelemetry.io/collector/config/configretry v1.18.0
 	go.opentelemetry.io/collector/config/configtelemetry v0.112.0
 	go.opentelemetry.io/collector/consumer v0.112.0
@@ -89,3 +90,5 @@ replace go.opentelemetry.io/collector/exporter/exporterprofiles => ./exporterpro
 replace go.opentelemetry.io/collector/exporter/exportertest => ./exportertest
 
 replace go.opentelemetry.io/collector/consumer/consumererror => ../consumer/consumererror
+
+replace go.opentelemetry.io/collector/component/componentstatus => ../component/componentstatus
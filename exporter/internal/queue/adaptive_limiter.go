@@ -0,0 +1,135 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package queue // import "go.opentelemetry.io/collector/exporter/internal/queue"
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// limitBackoffRatio is the ratio the limit is multiplied by when the backend is overloaded.
+	limitBackoffRatio = 0.9
+	// rttTolerance is how many times the baseline round trip time a request can take before the backend is considered
+	// overloaded.
+	rttTolerance = 2.0
+	// rttSmoothing is the weight of the round trip time of a request in the baseline.
+	rttSmoothing = 0.05
+)
+
+// AdaptiveConcurrencySettings defines parameters for adjusting the number of requests consumed concurrently.
+type AdaptiveConcurrencySettings struct {
+	// MinConsumers and MaxConsumers are the bounds of the number of requests consumed concurrently.
+	MinConsumers int
+	MaxConsumers int
+	// IsThrottled reports whether the error of an attempt to send a request means the backend is overloaded.
+	IsThrottled func(error) bool
+}
+
+// adaptiveLimiter limits the number of requests consumed concurrently using an AIMD (additive increase, multiplicative
+// decrease) algorithm. The limit is increased by one every round trip while the requests succeed without a rise of
+// their round trip time, and multiplied by limitBackoffRatio when a request is throttled or its round trip time
+// exceeds rttTolerance times the baseline.
+type adaptiveLimiter struct {
+	minLimit    float64
+	maxLimit    float64
+	isThrottled func(error) bool
+
+	// mu guards everything declared below.
+	mu   sync.Mutex
+	cond *sync.Cond
+	// limit is kept as a float so it can grow by a fraction on every request, the integer part is the actual limit.
+	limit float64
+	// acquired is the number of consumers allowed to read from the queue, processing is the number of them consuming
+	// a request.
+	acquired    int
+	processing  int
+	baselineRTT time.Duration
+}
+
+func newAdaptiveLimiter(initialLimit int, set AdaptiveConcurrencySettings) *adaptiveLimiter {
+	l := &adaptiveLimiter{
+		minLimit:    float64(set.MinConsumers),
+		maxLimit:    float64(set.MaxConsumers),
+		isThrottled: set.IsThrottled,
+	}
+	l.cond = sync.NewCond(&l.mu)
+	l.limit = l.clamp(float64(initialLimit))
+	return l
+}
+
+// acquire blocks until the consumer is allowed to read from the queue. Every call must be followed by a call to release.
+func (l *adaptiveLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for l.acquired >= int(l.limit) {
+		l.cond.Wait()
+	}
+	l.acquired++
+}
+
+// release lets another consumer read from the queue.
+func (l *adaptiveLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.acquired--
+	l.cond.Signal()
+}
+
+// onStart records that a consumer started consuming a request.
+func (l *adaptiveLimiter) onStart() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.processing++
+}
+
+// onFinish records that a consumer finished consuming a request.
+func (l *adaptiveLimiter) onFinish() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.processing--
+}
+
+// onAttempt adjusts the limit based on the outcome of an attempt to send a request, made while consuming it.
+func (l *adaptiveLimiter) onAttempt(rtt time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Only increase the limit when it's close to being reached, otherwise it would grow up to the maximum
+	// without any load.
+	saturated := float64(2*l.processing) >= l.limit
+
+	oldLimit := int(l.limit)
+	switch {
+	case err != nil && l.isThrottled != nil && l.isThrottled(err):
+		l.limit = l.clamp(l.limit * limitBackoffRatio)
+	case err != nil:
+		// Other errors are not caused by the load, keep the limit.
+		return
+	case l.baselineRTT > 0 && float64(rtt) > rttTolerance*float64(l.baselineRTT):
+		l.limit = l.clamp(l.limit * limitBackoffRatio)
+		l.updateBaseline(rtt)
+	default:
+		if saturated {
+			l.limit = l.clamp(l.limit + 1/l.limit)
+		}
+		l.updateBaseline(rtt)
+	}
+	if int(l.limit) > oldLimit {
+		l.cond.Broadcast()
+	}
+}
+
+// updateBaseline moves the baseline round trip time towards the round trip time of a successful request, so it
+// follows the lasting changes of the latency of the backend.
+func (l *adaptiveLimiter) updateBaseline(rtt time.Duration) {
+	if l.baselineRTT == 0 {
+		l.baselineRTT = rtt
+		return
+	}
+	l.baselineRTT += time.Duration(rttSmoothing * float64(rtt-l.baselineRTT))
+}
+
+func (l *adaptiveLimiter) clamp(limit float64) float64 {
+	return max(l.minLimit, min(l.maxLimit, limit))
+}
@@ -0,0 +1,122 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errThrottled = errors.New("throttled")
+
+func newTestAdaptiveLimiter(initialLimit, minLimit, maxLimit int) *adaptiveLimiter {
+	return newAdaptiveLimiter(initialLimit, AdaptiveConcurrencySettings{
+		MinConsumers: minLimit,
+		MaxConsumers: maxLimit,
+		IsThrottled: func(err error) bool {
+			return errors.Is(err, errThrottled)
+		},
+	})
+}
+
+func (l *adaptiveLimiter) getLimit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// finish records n requests consumed concurrently, all with the same outcome.
+func (l *adaptiveLimiter) finish(n int, rtt time.Duration, err error) {
+	for i := 0; i < n; i++ {
+		l.onStart()
+	}
+	for i := 0; i < n; i++ {
+		l.onAttempt(rtt, err)
+		l.onFinish()
+	}
+}
+
+func TestAdaptiveLimiter_InitialLimitWithinBounds(t *testing.T) {
+	assert.Equal(t, 2, newTestAdaptiveLimiter(1, 2, 5).getLimit())
+	assert.Equal(t, 5, newTestAdaptiveLimiter(10, 2, 5).getLimit())
+	assert.Equal(t, 3, newTestAdaptiveLimiter(3, 2, 5).getLimit())
+}
+
+func TestAdaptiveLimiter_AdditiveIncrease(t *testing.T) {
+	l := newTestAdaptiveLimiter(4, 1, 6)
+
+	// The limit is not increased while it's far from being reached.
+	for i := 0; i < 10; i++ {
+		l.finish(1, time.Millisecond, nil)
+	}
+	assert.Equal(t, 4, l.getLimit())
+
+	// The limit is increased by a fraction for every request consumed while it's close to being reached.
+	l.finish(4, time.Millisecond, nil)
+	l.finish(4, time.Millisecond, nil)
+	assert.Equal(t, 4, l.getLimit())
+	l.finish(4, time.Millisecond, nil)
+	assert.Equal(t, 5, l.getLimit())
+
+	// The limit is not increased above the maximum.
+	for i := 0; i < 10; i++ {
+		l.finish(6, time.Millisecond, nil)
+	}
+	assert.Equal(t, 6, l.getLimit())
+}
+
+func TestAdaptiveLimiter_MultiplicativeDecreaseOnThrottling(t *testing.T) {
+	l := newTestAdaptiveLimiter(20, 2, 20)
+
+	l.finish(1, time.Millisecond, errThrottled)
+	assert.Equal(t, 18, l.getLimit())
+	l.finish(1, time.Millisecond, errThrottled)
+	assert.Equal(t, 16, l.getLimit())
+
+	// Other errors keep the limit.
+	l.finish(16, time.Millisecond, errors.New("transient error"))
+	assert.Equal(t, 16, l.getLimit())
+
+	// The limit is not decreased below the minimum.
+	l.finish(30, time.Millisecond, errThrottled)
+	assert.Equal(t, 2, l.getLimit())
+}
+
+func TestAdaptiveLimiter_MultiplicativeDecreaseOnRisingRTT(t *testing.T) {
+	l := newTestAdaptiveLimiter(10, 1, 20)
+
+	l.finish(1, 10*time.Millisecond, nil)
+	l.finish(1, 15*time.Millisecond, nil)
+	assert.Equal(t, 10, l.getLimit())
+
+	l.finish(1, 50*time.Millisecond, nil)
+	assert.Equal(t, 9, l.getLimit())
+}
+
+func TestAdaptiveLimiter_AcquireWaitsForLimit(t *testing.T) {
+	l := newTestAdaptiveLimiter(1, 1, 2)
+	l.acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		l.acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired above the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// Increasing the limit lets the waiting consumer through.
+	l.finish(1, time.Millisecond, nil)
+	assert.Equal(t, 2, l.getLimit())
+	<-acquired
+
+	l.release()
+	l.release()
+}
@@ -17,6 +17,27 @@ type batch struct {
 	ctx     context.Context
 	req     internal.Request
 	idxList []uint64
+	// split holds the requests that are only partly in this batch.
+	split []*splitRequest
+}
+
+// splitRequest is a request read from the queue and split across several batches. It is finished once all of them
+// are flushed.
+type splitRequest struct {
+	idx     uint64
+	mu      sync.Mutex
+	pending int
+	err     error
+}
+
+// finish records that a batch holding part of the request was flushed with err. It returns whether it was the last
+// one, along with the errors of all of them.
+func (sr *splitRequest) finish(err error) (bool, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.pending--
+	sr.err = errors.Join(sr.err, err)
+	return sr.pending == 0, sr.err
 }
 
 // Batcher is in charge of reading items from the queue and send them out asynchronously.
@@ -74,6 +95,11 @@ func (qb *BaseBatcher) flush(batchToFlush batch) {
 	for _, idx := range batchToFlush.idxList {
 		qb.queue.OnProcessingFinished(idx, err)
 	}
+	for _, sr := range batchToFlush.split {
+		if done, splitErr := sr.finish(err); done {
+			qb.queue.OnProcessingFinished(sr.idx, splitErr)
+		}
+	}
 }
 
 // flushAsync starts a goroutine that calls flushIfNecessary. It blocks until a worker is available.
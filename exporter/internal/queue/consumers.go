@@ -6,6 +6,7 @@ package queue // import "go.opentelemetry.io/collector/exporter/internal/queue"
 import (
 	"context"
 	"sync"
+	"time"
 
 	"go.opentelemetry.io/collector/component"
 )
@@ -15,6 +16,8 @@ type Consumers[T any] struct {
 	numConsumers int
 	consumeFunc  func(context.Context, T) error
 	stopWG       sync.WaitGroup
+	// limiter, if set, limits the number of the consumers reading from the queue.
+	limiter *adaptiveLimiter
 }
 
 func NewQueueConsumers[T any](q Queue[T], numConsumers int, consumeFunc func(context.Context, T) error) *Consumers[T] {
@@ -26,6 +29,20 @@ func NewQueueConsumers[T any](q Queue[T], numConsumers int, consumeFunc func(con
 	}
 }
 
+// NewAdaptiveQueueConsumers creates consumers adjusting the number of requests consumed concurrently, between
+// the bounds of the given settings, to the outcome of the attempts to send them reported to OnAttempt. numConsumers is
+// the initial number of requests consumed concurrently.
+func NewAdaptiveQueueConsumers[T any](q Queue[T], numConsumers int, set AdaptiveConcurrencySettings,
+	consumeFunc func(context.Context, T) error) *Consumers[T] {
+	return &Consumers[T]{
+		queue:        q,
+		numConsumers: set.MaxConsumers,
+		consumeFunc:  consumeFunc,
+		stopWG:       sync.WaitGroup{},
+		limiter:      newAdaptiveLimiter(numConsumers, set),
+	}
+}
+
 // Start ensures that queue and all consumers are started.
 func (qc *Consumers[T]) Start(_ context.Context, _ component.Host) error {
 	var startWG sync.WaitGroup
@@ -35,6 +52,10 @@ func (qc *Consumers[T]) Start(_ context.Context, _ component.Host) error {
 		go func() {
 			startWG.Done()
 			defer qc.stopWG.Done()
+			if qc.limiter != nil {
+				qc.consumeAdaptive()
+				return
+			}
 			for {
 				index, ctx, req, ok := qc.queue.Read(context.Background())
 				if !ok {
@@ -50,6 +71,33 @@ func (qc *Consumers[T]) Start(_ context.Context, _ component.Host) error {
 	return nil
 }
 
+// consumeAdaptive consumes the requests while the limiter allows it. Once the queue is stopped, the consumers waiting
+// for the limiter read from the queue in turn, and stop as well.
+func (qc *Consumers[T]) consumeAdaptive() {
+	for {
+		qc.limiter.acquire()
+		index, ctx, req, ok := qc.queue.Read(context.Background())
+		if !ok {
+			qc.limiter.release()
+			return
+		}
+		qc.limiter.onStart()
+		consumeErr := qc.consumeFunc(ctx, req)
+		qc.limiter.onFinish()
+		qc.queue.OnProcessingFinished(index, consumeErr)
+		qc.limiter.release()
+	}
+}
+
+// OnAttempt adjusts the number of requests consumed concurrently to the outcome of an attempt to send a request, which
+// took rtt. A request can take several attempts while it is consumed. It does nothing if the consumers are not
+// adaptive.
+func (qc *Consumers[T]) OnAttempt(rtt time.Duration, err error) {
+	if qc.limiter != nil {
+		qc.limiter.onAttempt(rtt, err)
+	}
+}
+
 // Shutdown ensures that queue and all consumers are stopped.
 func (qc *Consumers[T]) Shutdown(_ context.Context) error {
 	qc.stopWG.Wait()
@@ -0,0 +1,52 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package queue
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/component/componenttest"
+)
+
+func TestAdaptiveQueueConsumers(t *testing.T) {
+	q := NewBoundedMemoryQueue[fakeReq](MemoryQueueSettings[fakeReq]{Sizer: &RequestSizer[fakeReq]{}, Capacity: 1000})
+	var consumed, active, maxActive atomic.Int64
+	var consumers *Consumers[fakeReq]
+	consumers = NewAdaptiveQueueConsumers(q, 2, AdaptiveConcurrencySettings{MinConsumers: 1, MaxConsumers: 4},
+		func(context.Context, fakeReq) error {
+			n := active.Add(1)
+			for {
+				m := maxActive.Load()
+				if n <= m || maxActive.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			consumers.OnAttempt(time.Millisecond, nil)
+			active.Add(-1)
+			consumed.Add(1)
+			return nil
+		})
+	require.NoError(t, q.Start(context.Background(), componenttest.NewNopHost()))
+	require.NoError(t, consumers.Start(context.Background(), componenttest.NewNopHost()))
+
+	for i := 0; i < 200; i++ {
+		require.NoError(t, q.Offer(context.Background(), fakeReq{1}))
+	}
+	assert.Eventually(t, func() bool {
+		return consumers.limiter.getLimit() > 2
+	}, time.Second, time.Millisecond)
+
+	// All the requests left in the queue are consumed on shutdown, even by the consumers waiting for the limiter.
+	require.NoError(t, q.Shutdown(context.Background()))
+	require.NoError(t, consumers.Shutdown(context.Background()))
+	assert.EqualValues(t, 200, consumed.Load())
+	assert.LessOrEqual(t, maxActive.Load(), int64(4))
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/exporter/internal"
 )
 
 // DefaultBatcher continuously reads from the queue and flushes asynchronously if size limit is met or on timeout.
@@ -41,6 +42,20 @@ func (qb *DefaultBatcher) startReadingFlushingGoroutine() {
 			}
 
 			qb.currentBatchMu.Lock()
+			if qb.batchCfg.MaxSizeBytes > 0 {
+				batchesToFlush := qb.mergeSplit(ctx, idx, req)
+				qb.currentBatchMu.Unlock()
+
+				// flushAsync() blocks until successfully started a goroutine for flushing.
+				for _, batchToFlush := range batchesToFlush {
+					qb.flushAsync(batchToFlush)
+				}
+				if len(batchesToFlush) > 0 {
+					qb.resetTimer()
+				}
+				continue
+			}
+
 			if qb.currentBatch == nil || qb.currentBatch.req == nil {
 				qb.resetTimer()
 				qb.currentBatch = &batch{
@@ -60,7 +75,7 @@ func (qb *DefaultBatcher) startReadingFlushingGoroutine() {
 					idxList: append(qb.currentBatch.idxList, idx)}
 			}
 
-			if qb.currentBatch.req.ItemsCount() > qb.batchCfg.MinSizeItems {
+			if qb.ready(qb.currentBatch.req) {
 				batchToFlush := *qb.currentBatch
 				qb.currentBatch = nil
 				qb.currentBatchMu.Unlock()
@@ -75,6 +90,53 @@ func (qb *DefaultBatcher) startReadingFlushingGoroutine() {
 	}()
 }
 
+// mergeSplit merges req into the current batch and splits the result into batches of at most cfg.MaxSizeBytes. It
+// returns the batches to flush, and keeps the last one as the current batch unless it is ready too. The caller must
+// hold currentBatchMu.
+func (qb *DefaultBatcher) mergeSplit(ctx context.Context, idx uint64, req internal.Request) []batch {
+	var reqs []internal.Request
+	var err error
+	current := batch{}
+	if qb.currentBatch == nil || qb.currentBatch.req == nil {
+		qb.resetTimer()
+		reqs, err = req.MergeSplit(ctx, qb.batchCfg.MaxSizeConfig, nil)
+	} else {
+		current = *qb.currentBatch
+		ctx = current.ctx
+		reqs, err = current.req.MergeSplit(ctx, qb.batchCfg.MaxSizeConfig, req)
+	}
+	if err != nil || len(reqs) == 0 {
+		qb.queue.OnProcessingFinished(idx, err)
+		return nil
+	}
+
+	// The current batch is not larger than the limit, so it is entirely in the first batch.
+	batches := make([]batch, len(reqs))
+	batches[0] = batch{ctx: ctx, req: reqs[0], idxList: current.idxList, split: current.split}
+	if len(reqs) == 1 {
+		batches[0].idxList = append(batches[0].idxList, idx)
+	} else {
+		sr := &splitRequest{idx: idx, pending: len(reqs)}
+		batches[0].split = append(batches[0].split, sr)
+		for i := 1; i < len(reqs); i++ {
+			batches[i] = batch{ctx: ctx, req: reqs[i], split: []*splitRequest{sr}}
+		}
+	}
+
+	last := batches[len(batches)-1]
+	if qb.ready(last.req) {
+		qb.currentBatch = nil
+		return batches
+	}
+	qb.currentBatch = &last
+	return batches[:len(batches)-1]
+}
+
+// ready reports whether req is large enough to be flushed without waiting for the timeout.
+func (qb *DefaultBatcher) ready(req internal.Request) bool {
+	return req.ItemsCount() > qb.batchCfg.MinSizeItems || internal.MinSizeBytesReached(qb.batchCfg.MinSizeConfig, req)
+}
+
 // startTimeBasedFlushingGoroutine starts a goroutine that flushes on timeout.
 func (qb *DefaultBatcher) startTimeBasedFlushingGoroutine() {
 	qb.stopWG.Add(1)
@@ -6,6 +6,7 @@ package queue
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -200,6 +201,72 @@ func TestDefaultBatcher_WithTimeout(t *testing.T) {
 	}
 }
 
+func TestDefaultBatcher_SizeBytes(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxWorkers int
+	}{
+		{
+			name:       "infinate_workers",
+			maxWorkers: 0,
+		},
+		{
+			name:       "one_worker",
+			maxWorkers: 1,
+		},
+		{
+			name:       "three_workers",
+			maxWorkers: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := exporterbatcher.NewDefaultConfig()
+			cfg.Enabled = true
+			cfg.FlushTimeout = 0
+			cfg.MinSizeConfig = exporterbatcher.MinSizeConfig{
+				MinSizeItems: 100,
+				MinSizeBytes: 100,
+			}
+			cfg.MaxSizeConfig = exporterbatcher.MaxSizeConfig{
+				MaxSizeBytes: 100,
+			}
+
+			q := NewBoundedMemoryQueue[internal.Request](
+				MemoryQueueSettings[internal.Request]{
+					Sizer:    &RequestSizer[internal.Request]{},
+					Capacity: 10,
+				})
+			finished := newFinishedRecorder(q)
+
+			ba, err := NewBatcher(cfg, finished, tt.maxWorkers)
+			require.NoError(t, err)
+
+			require.NoError(t, q.Start(context.Background(), componenttest.NewNopHost()))
+			require.NoError(t, ba.Start(context.Background(), componenttest.NewNopHost()))
+			t.Cleanup(func() {
+				require.NoError(t, q.Shutdown(context.Background()))
+				require.NoError(t, ba.Shutdown(context.Background()))
+			})
+
+			sink := newFakeRequestSink()
+
+			// Split into 100, 100 and 50 bytes, the last waiting for more data.
+			require.NoError(t, q.Offer(context.Background(), &fakeRequest{items: 5, bytes: 250, sink: sink}))
+			assert.Eventually(t, func() bool {
+				return sink.requestsCount.Load() == 2 && sink.itemsCount.Load() == 4
+			}, 100*time.Millisecond, 10*time.Millisecond)
+			// The request is not finished until all of its parts are flushed.
+			assert.Equal(t, 0, finished.count())
+
+			require.NoError(t, q.Offer(context.Background(), &fakeRequest{items: 1, bytes: 50, sink: sink}))
+			assert.Eventually(t, func() bool {
+				return sink.requestsCount.Load() == 3 && sink.itemsCount.Load() == 6 && finished.count() == 2
+			}, 100*time.Millisecond, 10*time.Millisecond)
+		})
+	}
+}
+
 func TestDisabledBatcher_SplitNotImplemented(t *testing.T) {
 	cfg := exporterbatcher.NewDefaultConfig()
 	cfg.Enabled = true
@@ -215,3 +282,27 @@ func TestDisabledBatcher_SplitNotImplemented(t *testing.T) {
 	_, err := NewBatcher(cfg, q, maxWorkers)
 	require.Error(t, err)
 }
+
+// finishedRecorder is a queue counting the requests finished by the batcher.
+type finishedRecorder struct {
+	Queue[internal.Request]
+	mu       sync.Mutex
+	finished int
+}
+
+func newFinishedRecorder(q Queue[internal.Request]) *finishedRecorder {
+	return &finishedRecorder{Queue: q}
+}
+
+func (r *finishedRecorder) OnProcessingFinished(index uint64, consumeErr error) {
+	r.mu.Lock()
+	r.finished++
+	r.mu.Unlock()
+	r.Queue.OnProcessingFinished(index, consumeErr)
+}
+
+func (r *finishedRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.finished
+}
@@ -27,6 +27,7 @@ func newFakeRequestSink() *fakeRequestSink {
 
 type fakeRequest struct {
 	items     int
+	bytes     int
 	exportErr error
 
 	mergeErr error
@@ -54,6 +55,10 @@ func (r *fakeRequest) ItemsCount() int {
 	return r.items
 }
 
+func (r *fakeRequest) BytesSize() int {
+	return r.bytes
+}
+
 func (r *fakeRequest) Merge(_ context.Context,
 	r2 internal.Request) (internal.Request, error) {
 	fr2 := r2.(*fakeRequest)
@@ -62,13 +67,42 @@ func (r *fakeRequest) Merge(_ context.Context,
 	}
 	return &fakeRequest{
 		items:     r.items + fr2.items,
+		bytes:     r.bytes + fr2.bytes,
 		sink:      r.sink,
 		exportErr: fr2.exportErr,
 		delay:     r.delay + fr2.delay,
 	}, nil
 }
 
-func (r *fakeRequest) MergeSplit(_ context.Context, _ exporterbatcher.MaxSizeConfig,
-	_ internal.Request) ([]internal.Request, error) {
-	return nil, errors.New("not implemented")
+// MergeSplit only supports MaxSizeBytes, splitting requests whose items all have the same size.
+func (r *fakeRequest) MergeSplit(ctx context.Context, cfg exporterbatcher.MaxSizeConfig,
+	r2 internal.Request) ([]internal.Request, error) {
+	if cfg.MaxSizeItems != 0 || cfg.MaxSizeBytes == 0 {
+		return nil, errors.New("not implemented")
+	}
+	merged := internal.Request(r)
+	if r2 != nil {
+		var err error
+		if merged, err = r.Merge(ctx, r2); err != nil {
+			return nil, err
+		}
+	}
+	fr := merged.(*fakeRequest)
+	if fr.bytes <= cfg.MaxSizeBytes || fr.items <= 1 {
+		return []internal.Request{fr}, nil
+	}
+	itemBytes := fr.bytes / fr.items
+	perRequest := max(1, cfg.MaxSizeBytes/itemBytes)
+	var res []internal.Request
+	for items := fr.items; items > 0; items -= perRequest {
+		n := min(items, perRequest)
+		res = append(res, &fakeRequest{
+			items:     n,
+			bytes:     n * itemBytes,
+			sink:      fr.sink,
+			exportErr: fr.exportErr,
+			delay:     fr.delay,
+		})
+	}
+	return res, nil
 }
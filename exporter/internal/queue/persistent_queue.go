@@ -127,7 +127,8 @@ func (pq *persistentQueue[T]) initPersistentContiguousStorage(ctx context.Contex
 	wiOp := storage.GetOperation(writeIndexKey)
 
 	err := pq.client.Batch(ctx, riOp, wiOp)
-	if err == nil {
+	// The read index is not set until the first item is read, while the items written before are still there.
+	if err == nil && (riOp.Value != nil || wiOp.Value == nil) {
 		pq.readIndex, err = bytesToItemIndex(riOp.Value)
 	}
 
@@ -240,25 +241,7 @@ func (pq *persistentQueue[T]) Offer(ctx context.Context, req T) error {
 // putInternal is the internal version that requires caller to hold the mutex lock.
 func (pq *persistentQueue[T]) putInternal(ctx context.Context, req T) error {
 	err := pq.sizedChannel.push(permanentQueueEl{}, pq.set.Sizer.Sizeof(req), func() error {
-		itemKey := getItemKey(pq.writeIndex)
-		newIndex := pq.writeIndex + 1
-
-		reqBuf, err := pq.set.Marshaler(req)
-		if err != nil {
-			return err
-		}
-
-		// Carry out a transaction where we both add the item and update the write index
-		ops := []storage.Operation{
-			storage.SetOperation(writeIndexKey, itemIndexToBytes(newIndex)),
-			storage.SetOperation(itemKey, reqBuf),
-		}
-		if storageErr := pq.client.Batch(ctx, ops...); storageErr != nil {
-			return storageErr
-		}
-
-		pq.writeIndex = newIndex
-		return nil
+		return pq.storeInternal(ctx, req)
 	})
 	if err != nil {
 		return err
@@ -275,6 +258,30 @@ func (pq *persistentQueue[T]) putInternal(ctx context.Context, req T) error {
 	return nil
 }
 
+// storeInternal writes the request to the storage at the write index, and moves the write index. It does not notify
+// the consumers. The caller must hold the mutex lock.
+func (pq *persistentQueue[T]) storeInternal(ctx context.Context, req T) error {
+	itemKey := getItemKey(pq.writeIndex)
+	newIndex := pq.writeIndex + 1
+
+	reqBuf, err := pq.set.Marshaler(req)
+	if err != nil {
+		return err
+	}
+
+	// Carry out a transaction where we both add the item and update the write index
+	ops := []storage.Operation{
+		storage.SetOperation(writeIndexKey, itemIndexToBytes(newIndex)),
+		storage.SetOperation(itemKey, reqBuf),
+	}
+	if storageErr := pq.client.Batch(ctx, ops...); storageErr != nil {
+		return storageErr
+	}
+
+	pq.writeIndex = newIndex
+	return nil
+}
+
 func (pq *persistentQueue[T]) Read(ctx context.Context) (uint64, context.Context, T, bool) {
 	for {
 		var (
@@ -545,6 +545,22 @@ func TestPersistentQueueStartWithNonDispatched(t *testing.T) {
 	require.Equal(t, 6, newPs.Size())
 }
 
+func TestPersistentQueueStartWithoutReads(t *testing.T) {
+	ext := NewMockStorageExtension(nil)
+	ps := createTestPersistentQueueWithRequestsCapacity(t, ext, 5)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, ps.Offer(context.Background(), newTracesRequest(1, 10)))
+	}
+	require.NoError(t, ps.Shutdown(context.Background()))
+
+	// No item was read, so the read index was never written.
+	newPs := createTestPersistentQueueWithRequestsCapacity(t, ext, 5)
+	require.Equal(t, 3, newPs.Size())
+	require.True(t, consume(newPs, func(context.Context, tracesRequest) error { return nil }))
+	require.Equal(t, 2, newPs.Size())
+	require.NoError(t, newPs.Shutdown(context.Background()))
+}
+
 func TestPersistentQueueStartWithNonDispatchedConcurrent(t *testing.T) {
 	req := newTracesRequest(1, 1)
 
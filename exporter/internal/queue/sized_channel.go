@@ -77,15 +77,18 @@ func (vcq *sizedChannel[T]) pop(callback func(T) (size int64)) (T, bool) {
 		return el, false
 	}
 
-	size := callback(el)
+	vcq.release(callback(el))
+	return el, true
+}
 
+// release frees the size of an element received from the channel directly.
+func (vcq *sizedChannel[T]) release(size int64) {
 	// The used size and the channel size might be not in sync with the queue in case it's restored from the disk
 	// because we don't flush the current queue size on the disk on every read/write.
 	// In that case we need to make sure it doesn't go below 0.
 	if vcq.used.Add(-size) < 0 {
 		vcq.used.Store(0)
 	}
-	return el, true
 }
 
 // syncSize updates the used size to 0 if the queue is empty.
@@ -0,0 +1,232 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package queue // import "go.opentelemetry.io/collector/exporter/internal/queue"
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/exporter/internal/experr"
+)
+
+// memoryItemIndex marks the indexes of the requests read from memory by the spilloverQueue, keeping them apart from
+// the indexes of its persistent queue.
+const memoryItemIndex = uint64(1) << 63
+
+// spilloverQueue keeps the requests in memory, and writes them to a persistent queue only when the memory is full.
+// On shutdown, the requests still in memory, and the requests whose processing fails with a shutdown error, are
+// written to the persistent queue as well, so they are read again after a restart.
+//
+// Requests written to the storage while the memory is full can be read after requests offered later, which are kept
+// in memory.
+type spilloverQueue[T any] struct {
+	// mem is used for two purposes:
+	// 1. a communication channel notifying the consumers that a new request is in memory.
+	// 2. capacity control of the memory based on the size of the requests.
+	mem  *sizedChannel[struct{}]
+	disk *persistentQueue[T]
+	set  SpilloverQueueSettings[T]
+
+	// mu guards everything declared below.
+	mu sync.Mutex
+	// items are the requests in memory, in the order they were offered.
+	items []memQueueEl[T]
+	// dispatched are the requests read from memory that are being processed, by index.
+	dispatched map[uint64]memQueueEl[T]
+	nextIndex  uint64
+	stopped    bool
+}
+
+// SpilloverQueueSettings defines internal parameters for spilloverQueue creation.
+type SpilloverQueueSettings[T any] struct {
+	PersistentQueueSettings[T]
+	// MemoryCapacity is the capacity of the memory, measured by Sizer. Capacity is the capacity of the storage.
+	MemoryCapacity int64
+}
+
+// NewSpilloverQueue creates a new queue keeping the requests in memory and spilling them to the storage when the memory
+// is full or on shutdown.
+func NewSpilloverQueue[T any](set SpilloverQueueSettings[T]) Queue[T] {
+	return &spilloverQueue[T]{
+		mem:        newSizedChannel[struct{}](set.MemoryCapacity, nil, 0),
+		disk:       NewPersistentQueue[T](set.PersistentQueueSettings).(*persistentQueue[T]),
+		set:        set,
+		dispatched: make(map[uint64]memQueueEl[T]),
+	}
+}
+
+// Start starts the persistent queue, which moves the requests left in the storage by a previous run back to the queue.
+func (q *spilloverQueue[T]) Start(ctx context.Context, host component.Host) error {
+	if err := q.disk.Start(ctx, host); err != nil {
+		return err
+	}
+	// Hold a reference to the storage client until the requests read from memory are processed, as they are written
+	// to the storage if the processing fails because of a shutdown. Holding a single reference for all of them keeps
+	// the memory consumers off the lock of the persistent queue, which is held while writing to the storage.
+	q.disk.mu.Lock()
+	defer q.disk.mu.Unlock()
+	if q.disk.client != nil {
+		q.disk.refClient++
+	}
+	return nil
+}
+
+// Offer keeps the request in memory, or writes it to the storage if the memory is full.
+// It returns ErrQueueIsFull if both are full. Calling this method on a stopped queue will panic.
+func (q *spilloverQueue[T]) Offer(ctx context.Context, req T) error {
+	q.mu.Lock()
+	err := q.mem.push(struct{}{}, q.set.Sizer.Sizeof(req), nil)
+	if err == nil {
+		q.items = append(q.items, memQueueEl[T]{ctx: ctx, req: req})
+	}
+	q.mu.Unlock()
+	if err != nil {
+		// The memory is full. The request is written outside of the lock, so the producers and consumers of the
+		// memory are not blocked while writing to the storage.
+		return q.disk.Offer(ctx, req)
+	}
+	return nil
+}
+
+func (q *spilloverQueue[T]) Read(ctx context.Context) (uint64, context.Context, T, bool) {
+	memCh, diskCh := q.mem.ch, q.disk.sizedChannel.ch
+	for memCh != nil || diskCh != nil {
+		select {
+		case _, ok := <-memCh:
+			if !ok {
+				memCh = nil
+				continue
+			}
+			if index, el, found := q.getNextMemoryItem(); found {
+				return index, el.ctx, el.req, true
+			}
+		case _, ok := <-diskCh:
+			if !ok {
+				diskCh = nil
+				continue
+			}
+			if index, req, consumed := q.disk.getNextItem(ctx); consumed {
+				q.disk.sizedChannel.release(q.set.Sizer.Sizeof(req))
+				return index, context.TODO(), req, true
+			}
+		}
+		// If the request was not found, it was written to the storage on shutdown, or the queue is stopped. In this
+		// case, we still process all the other events in the channels, so we will free them fast and get to the stop.
+	}
+	var req T
+	return 0, nil, req, false
+}
+
+// getNextMemoryItem pulls the next request from memory and assigns it an index. It returns false if there is none left
+// because the queue was shut down.
+func (q *spilloverQueue[T]) getNextMemoryItem() (uint64, memQueueEl[T], bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return 0, memQueueEl[T]{}, false
+	}
+	el := q.items[0]
+	q.items[0] = memQueueEl[T]{}
+	q.items = q.items[1:]
+	q.mem.release(q.set.Sizer.Sizeof(el.req))
+
+	index := memoryItemIndex | q.nextIndex
+	q.nextIndex++
+	q.dispatched[index] = el
+	return index, el, true
+}
+
+// OnProcessingFinished should be called to remove the request of the given index from the queue once processing is
+// finished. A request read from memory is written to the storage if the processing failed because of a shutdown.
+func (q *spilloverQueue[T]) OnProcessingFinished(index uint64, consumeErr error) {
+	if index&memoryItemIndex == 0 {
+		q.disk.OnProcessingFinished(index, consumeErr)
+		return
+	}
+
+	if experr.IsShutdownErr(consumeErr) {
+		q.mu.Lock()
+		el := q.dispatched[index]
+		q.mu.Unlock()
+		// The queue is shutting down, write the request to the storage, so it's picked up again after restart. The
+		// request stays dispatched until it is written, so the storage client is not released in the meantime.
+		q.disk.mu.Lock()
+		if err := q.disk.storeInternal(context.Background(), el.req); err != nil {
+			q.disk.logger.Error("Error writing request to storage, dropping it", zap.Error(err))
+		}
+		q.disk.mu.Unlock()
+	}
+
+	q.mu.Lock()
+	delete(q.dispatched, index)
+	// The last request read from memory releases the storage client once the queue is stopped.
+	release := q.stopped && len(q.dispatched) == 0
+	q.mu.Unlock()
+	if release {
+		if err := q.releaseClient(context.Background()); err != nil {
+			q.disk.logger.Error("Error closing the storage client", zap.Error(err))
+		}
+	}
+}
+
+// releaseClient releases the reference to the storage client held by the queue.
+func (q *spilloverQueue[T]) releaseClient(ctx context.Context) error {
+	q.disk.mu.Lock()
+	defer q.disk.mu.Unlock()
+	return q.disk.unrefClient(ctx)
+}
+
+// Shutdown writes the requests still in memory to the storage and stops the queue. They are written even if the
+// storage is full, so more requests than its capacity can be read after a restart.
+func (q *spilloverQueue[T]) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	q.stopped = true
+	q.mem.shutdown()
+	items := q.items
+	q.items = nil
+	for _, el := range items {
+		q.mem.release(q.set.Sizer.Sizeof(el.req))
+	}
+	// If requests read from memory are still processed, the last one of them releases the storage client.
+	release := len(q.dispatched) == 0
+	q.mu.Unlock()
+
+	// If the queue is not initialized, there is no storage to write the requests to.
+	if q.disk.client == nil {
+		return nil
+	}
+
+	// The requests are written outside of the queue lock, so the consumers finishing their requests are not blocked.
+	// The persistent queue keeps its own reference to the storage client until its shutdown below.
+	var storeErr error
+	q.disk.mu.Lock()
+	for _, el := range items {
+		if err := q.disk.storeInternal(ctx, el.req); err != nil {
+			storeErr = multierr.Append(storeErr, err)
+			continue
+		}
+		// Account for the request in the queue size backed up to the storage.
+		q.disk.sizedChannel.used.Add(q.set.Sizer.Sizeof(el.req))
+	}
+	q.disk.mu.Unlock()
+	err := multierr.Combine(storeErr, q.disk.Shutdown(ctx))
+	if release {
+		err = multierr.Append(err, q.releaseClient(ctx))
+	}
+	return err
+}
+
+// Size returns the size of the requests in memory and in the storage.
+func (q *spilloverQueue[T]) Size() int {
+	return q.mem.Size() + q.disk.Size()
+}
+
+// Capacity returns the capacity of the memory and of the storage.
+func (q *spilloverQueue[T]) Capacity() int {
+	return q.mem.Capacity() + q.disk.Capacity()
+}
@@ -0,0 +1,237 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package queue
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/exporter/internal/experr"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+	"go.opentelemetry.io/collector/pipeline"
+)
+
+func createTestSpilloverQueue(t *testing.T, ext storage.Extension, memoryCapacity, capacity int64) *spilloverQueue[tracesRequest] {
+	q := NewSpilloverQueue[tracesRequest](SpilloverQueueSettings[tracesRequest]{
+		PersistentQueueSettings: PersistentQueueSettings[tracesRequest]{
+			Sizer:            &RequestSizer[tracesRequest]{},
+			Capacity:         capacity,
+			Signal:           pipeline.SignalTraces,
+			StorageID:        component.ID{},
+			Marshaler:        marshalTracesRequest,
+			Unmarshaler:      unmarshalTracesRequest,
+			ExporterSettings: exportertest.NewNopSettings(),
+		},
+		MemoryCapacity: memoryCapacity,
+	}).(*spilloverQueue[tracesRequest])
+	require.NoError(t, q.Start(context.Background(), &mockHost{ext: map[component.ID]component.Component{{}: ext}}))
+	return q
+}
+
+func TestSpilloverQueue_SpillsWhenMemoryIsFull(t *testing.T) {
+	q := createTestSpilloverQueue(t, NewMockStorageExtension(nil), 2, 2)
+	req := newTracesRequest(1, 10)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, q.Offer(context.Background(), req))
+	}
+	assert.ErrorIs(t, q.Offer(context.Background(), req), ErrQueueIsFull)
+	assert.Equal(t, 4, q.Size())
+	assert.Equal(t, 4, q.Capacity())
+	// Only the requests that did not fit in memory are in the storage.
+	assert.Equal(t, uint64(2), q.disk.writeIndex)
+
+	for i := 0; i < 4; i++ {
+		require.True(t, consume[tracesRequest](q, func(_ context.Context, got tracesRequest) error {
+			assert.Equal(t, 10, got.ItemsCount())
+			return nil
+		}))
+	}
+	assert.Equal(t, 0, q.Size())
+	require.NoError(t, q.Shutdown(context.Background()))
+}
+
+// gatedStorageExtension returns storage clients whose writes wait for the gate while it is armed.
+type gatedStorageExtension struct {
+	storage.Extension
+	armed   atomic.Bool
+	waiting chan struct{}
+	gate    chan struct{}
+}
+
+func (e *gatedStorageExtension) GetClient(ctx context.Context, kind component.Kind, id component.ID, name string) (storage.Client, error) {
+	client, err := e.Extension.GetClient(ctx, kind, id, name)
+	if err != nil {
+		return nil, err
+	}
+	return &gatedStorageClient{Client: client, ext: e}, nil
+}
+
+type gatedStorageClient struct {
+	storage.Client
+	ext *gatedStorageExtension
+}
+
+func (c *gatedStorageClient) Batch(ctx context.Context, ops ...storage.Operation) error {
+	if c.ext.armed.Load() {
+		c.ext.waiting <- struct{}{}
+		<-c.ext.gate
+	}
+	return c.Client.Batch(ctx, ops...)
+}
+
+func TestSpilloverQueue_MemoryNotBlockedBySpilling(t *testing.T) {
+	ext := &gatedStorageExtension{
+		Extension: NewMockStorageExtension(nil),
+		waiting:   make(chan struct{}),
+		gate:      make(chan struct{}),
+	}
+	q := createTestSpilloverQueue(t, ext, 1, 5)
+	require.NoError(t, q.Offer(context.Background(), newTracesRequest(1, 10)))
+
+	ext.armed.Store(true)
+	spilled := make(chan error)
+	go func() {
+		spilled <- q.Offer(context.Background(), newTracesRequest(1, 20))
+	}()
+	<-ext.waiting
+
+	// While the second request is being written to the storage, the memory is still consumed and offered to.
+	require.True(t, consume[tracesRequest](q, func(_ context.Context, got tracesRequest) error {
+		assert.Equal(t, 10, got.ItemsCount())
+		return nil
+	}))
+	require.NoError(t, q.Offer(context.Background(), newTracesRequest(1, 30)))
+
+	ext.armed.Store(false)
+	close(ext.gate)
+	require.NoError(t, <-spilled)
+	var items []int
+	for i := 0; i < 2; i++ {
+		require.True(t, consume[tracesRequest](q, func(_ context.Context, got tracesRequest) error {
+			items = append(items, got.ItemsCount())
+			return nil
+		}))
+	}
+	assert.ElementsMatch(t, []int{20, 30}, items)
+	require.NoError(t, q.Shutdown(context.Background()))
+}
+
+func TestSpilloverQueue_SpillsOnShutdown(t *testing.T) {
+	ext := NewMockStorageExtension(nil)
+	q := createTestSpilloverQueue(t, ext, 5, 2)
+	req := newTracesRequest(1, 10)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, q.Offer(context.Background(), req))
+	}
+	assert.Equal(t, uint64(0), q.disk.writeIndex)
+	require.NoError(t, q.Shutdown(context.Background()))
+
+	// The requests are written even though the storage can only hold two of them.
+	newQ := createTestSpilloverQueue(t, ext, 5, 2)
+	assert.Equal(t, 3, newQ.Size())
+	for i := 0; i < 3; i++ {
+		require.True(t, consume[tracesRequest](newQ, func(context.Context, tracesRequest) error { return nil }))
+	}
+	assert.Equal(t, 0, newQ.Size())
+	require.NoError(t, newQ.Shutdown(context.Background()))
+}
+
+func TestSpilloverQueue_ShutdownWhileConsuming(t *testing.T) {
+	ext := NewMockStorageExtension(nil)
+	q := createTestSpilloverQueue(t, ext, 5, 5)
+	require.NoError(t, q.Offer(context.Background(), newTracesRequest(1, 10)))
+	require.NoError(t, q.Offer(context.Background(), newTracesRequest(1, 20)))
+
+	// The first request is being processed while the queue shuts down, and fails because of it.
+	index, _, _, ok := q.Read(context.Background())
+	require.True(t, ok)
+	require.NoError(t, q.Shutdown(context.Background()))
+	q.OnProcessingFinished(index, experr.NewShutdownErr(nil))
+
+	_, _, _, ok = q.Read(context.Background())
+	assert.False(t, ok)
+
+	newQ := createTestSpilloverQueue(t, ext, 5, 5)
+	assert.Equal(t, 2, newQ.Size())
+	var items []int
+	for i := 0; i < 2; i++ {
+		require.True(t, consume[tracesRequest](newQ, func(_ context.Context, got tracesRequest) error {
+			items = append(items, got.ItemsCount())
+			return nil
+		}))
+	}
+	assert.ElementsMatch(t, []int{10, 20}, items)
+	require.NoError(t, newQ.Shutdown(context.Background()))
+}
+
+func TestSpilloverQueue_ConcurrentShutdownErrors(t *testing.T) {
+	ext := NewMockStorageExtension(nil)
+	q := createTestSpilloverQueue(t, ext, 10, 10)
+	for i := 1; i <= 10; i++ {
+		require.NoError(t, q.Offer(context.Background(), newTracesRequest(1, i)))
+	}
+	var indexes []uint64
+	for i := 0; i < 10; i++ {
+		index, _, _, ok := q.Read(context.Background())
+		require.True(t, ok)
+		indexes = append(indexes, index)
+	}
+
+	// All the requests fail at once because of the shutdown. Each of them is written to the storage before the last
+	// one releases the storage client.
+	require.NoError(t, q.Shutdown(context.Background()))
+	var wg sync.WaitGroup
+	for _, index := range indexes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.OnProcessingFinished(index, experr.NewShutdownErr(nil))
+		}()
+	}
+	wg.Wait()
+
+	newQ := createTestSpilloverQueue(t, ext, 10, 10)
+	assert.Equal(t, 10, newQ.Size())
+	require.NoError(t, newQ.Shutdown(context.Background()))
+}
+
+func TestSpilloverQueue_RestoresNotDispatched(t *testing.T) {
+	ext := NewMockStorageExtension(nil)
+	q := createTestSpilloverQueue(t, ext, 1, 5)
+	require.NoError(t, q.Offer(context.Background(), newTracesRequest(1, 10)))
+	require.NoError(t, q.Offer(context.Background(), newTracesRequest(1, 20)))
+
+	// Read both the memory and the storage requests, the latter being left dispatched in the storage.
+	for i := 0; i < 2; i++ {
+		require.True(t, consume[tracesRequest](q, func(context.Context, tracesRequest) error {
+			return experr.NewShutdownErr(nil)
+		}))
+	}
+	require.NoError(t, q.Shutdown(context.Background()))
+
+	newQ := createTestSpilloverQueue(t, ext, 1, 5)
+	assert.Equal(t, 2, newQ.Size())
+	require.NoError(t, newQ.Shutdown(context.Background()))
+}
+
+func TestSpilloverQueue_ShutdownWithoutStart(t *testing.T) {
+	q := NewSpilloverQueue[tracesRequest](SpilloverQueueSettings[tracesRequest]{
+		PersistentQueueSettings: PersistentQueueSettings[tracesRequest]{
+			Sizer:            &RequestSizer[tracesRequest]{},
+			Capacity:         1,
+			ExporterSettings: exportertest.NewNopSettings(),
+		},
+		MemoryCapacity: 1,
+	})
+	require.NoError(t, q.Shutdown(context.Background()))
+}
@@ -48,3 +48,24 @@ type RequestErrorHandler interface {
 	// Otherwise, it should return the original Request.
 	OnError(error) Request
 }
+
+// RequestBytesSizer is an optional interface that can be implemented by Request to report its size in bytes, for
+// batching based on min_size_bytes and max_size_bytes. For OTLP data, the size is the size of the request serialized
+// with protobuf. MergeSplit of a Request implementing it MUST honor MaxSizeBytes.
+// Experimental: This API is at the early stage of development and may change without backward compatibility
+// until https://github.com/open-telemetry/opentelemetry-collector/issues/8122 is resolved.
+type RequestBytesSizer interface {
+	Request
+	// BytesSize returns the size of the request in bytes.
+	BytesSize() int
+}
+
+// MinSizeBytesReached reports whether the request reached the minimum size of a batch in bytes. It is false when
+// MinSizeBytes is not set or the request does not implement RequestBytesSizer.
+func MinSizeBytesReached(cfg exporterbatcher.MinSizeConfig, req Request) bool {
+	if cfg.MinSizeBytes == 0 {
+		return false
+	}
+	sizer, ok := req.(RequestBytesSizer)
+	return ok && sizer.BytesSize() >= cfg.MinSizeBytes
+}
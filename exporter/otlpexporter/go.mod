@@ -51,6 +51,7 @@ require (
 	github.com/mostynb/go-grpc-compression v1.2.3 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	go.opentelemetry.io/collector/client v1.18.0 // indirect
+	go.opentelemetry.io/collector/component/componentstatus v0.112.0 // indirect
 	go.opentelemetry.io/collector/config/confignet v1.18.0 // indirect
 	go.opentelemetry.io/collector/config/configtelemetry v0.112.0 // indirect
 	go.opentelemetry.io/collector/config/internal v0.112.0 // indirect
@@ -148,3 +149,5 @@ retract (
 )
 
 replace go.opentelemetry.io/collector/exporter/exportertest => ../exportertest
+
+replace go.opentelemetry.io/collector/component/componentstatus => ../../component/componentstatus
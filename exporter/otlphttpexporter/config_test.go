@@ -20,6 +20,7 @@ import (
 	"go.opentelemetry.io/collector/confmap"
 	"go.opentelemetry.io/collector/confmap/confmaptest"
 	"go.opentelemetry.io/collector/exporter/exporterhelper"
+	"go.opentelemetry.io/collector/exporter/exporterqueue"
 )
 
 func TestUnmarshalDefaultConfig(t *testing.T) {
@@ -53,9 +54,10 @@ func TestUnmarshalConfig(t *testing.T) {
 				MaxElapsedTime:      10 * time.Minute,
 			},
 			QueueConfig: exporterhelper.QueueConfig{
-				Enabled:      true,
-				NumConsumers: 2,
-				QueueSize:    10,
+				Enabled:             true,
+				NumConsumers:        2,
+				QueueSize:           10,
+				AdaptiveConcurrency: exporterqueue.NewDefaultAdaptiveConcurrencyConfig(),
 			},
 			Encoding: EncodingProto,
 			ClientConfig: confighttp.ClientConfig{
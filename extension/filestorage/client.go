@@ -0,0 +1,414 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package filestorage // import "go.opentelemetry.io/collector/extension/filestorage"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+var errClientClosed = errors.New("storage client is closed")
+
+// compactSuffix is appended to the path of a file while it is compacted.
+// Sanitized file names never contain a dot, so no component file ends with
+// it.
+const compactSuffix = ".compact"
+
+// valueLocation is where the value of a key is stored in the file.
+type valueLocation struct {
+	offset int64
+	size   int
+}
+
+// fileClient is a storage.Client keeping its keys in an append-only file
+// of records. The file is read once, when the client is created, to build
+// an index of where the latest value of every key is; values are then read
+// from the file on demand.
+type fileClient struct {
+	path     string
+	cfg      *Config
+	logger   *zap.Logger
+	released func()
+
+	mu    sync.Mutex
+	file  *os.File
+	size  int64
+	index map[string]valueLocation
+	// live is the size of the keys and values in the index. The rest of
+	// the file holds overwritten and deleted keys, and record framing.
+	live   int64
+	dirty  bool
+	closed bool
+	// broken is set when the file could not be opened again after a
+	// compaction. The client has no file then, and every operation fails
+	// with it.
+	broken error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+var _ storage.Client = (*fileClient)(nil)
+
+// newFileClient opens, or creates, the file at path and recovers its
+// index. released is called once the client is closed.
+func newFileClient(path string, cfg *Config, logger *zap.Logger, released func()) (*fileClient, error) {
+	// A compaction interrupted by a crash leaves its file behind, while the
+	// original file is still complete.
+	if err := os.Remove(path + compactSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	c := &fileClient{
+		path:     path,
+		cfg:      cfg,
+		logger:   logger,
+		released: released,
+		file:     file,
+	}
+	if err = c.load(); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to load %s: %w", path, err), file.Close())
+	}
+	if cfg.Compaction.OnStart && c.garbage() > 0 {
+		if err = c.compact(); err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to compact %s: %w", path, err), c.file.Close())
+		}
+	}
+	if cfg.Fsync == FsyncInterval {
+		c.stop = make(chan struct{})
+		c.done = make(chan struct{})
+		go c.syncPeriodically(cfg.FsyncInterval)
+	}
+	return c, nil
+}
+
+// load reads the records of the file into the index. A record that was
+// only partly written when the process or the machine stopped is cut off,
+// which discards the whole batch it holds. A damaged record in the middle
+// of the file fails the load, so no record after it is lost.
+func (c *fileClient) load() error {
+	info, err := c.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		if _, err = c.file.WriteAt([]byte(fileMagic), 0); err != nil {
+			return err
+		}
+		c.size = int64(len(fileMagic))
+		c.index = make(map[string]valueLocation)
+		return c.file.Sync()
+	}
+
+	index, size, err := readRecords(c.file, info.Size())
+	if err != nil {
+		return err
+	}
+	if size < info.Size() {
+		c.logger.Warn("Discarding an incomplete record at the end of the storage file",
+			zap.String("path", c.path),
+			zap.Int64("offset", size),
+			zap.Int64("discarded_bytes", info.Size()-size))
+		if err = c.file.Truncate(size); err != nil {
+			return err
+		}
+	}
+	c.index = index
+	c.size = size
+	for key, loc := range index {
+		c.live += int64(len(key) + loc.size)
+	}
+	return nil
+}
+
+// Get returns the value of key, or nil when it has none.
+func (c *fileClient) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.usable(); err != nil {
+		return nil, err
+	}
+	return c.read(key)
+}
+
+// Set stores value under key.
+func (c *fileClient) Set(ctx context.Context, key string, value []byte) error {
+	return c.Batch(ctx, storage.SetOperation(key, value))
+}
+
+// Delete removes key.
+func (c *fileClient) Delete(ctx context.Context, key string) error {
+	return c.Batch(ctx, storage.DeleteOperation(key))
+}
+
+// Batch runs the operations in order. Gets see the sets and deletes that
+// precede them. The sets and deletes are written as a single record, so
+// after a crash either all of them or none are found.
+func (c *fileClient) Batch(_ context.Context, ops ...storage.Operation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.usable(); err != nil {
+		return err
+	}
+
+	var writes []storage.Operation
+	pending := make(map[string]storage.Operation)
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			if write, ok := pending[op.Key]; ok {
+				op.Value = nil
+				if write.Type == storage.Set {
+					op.Value = append([]byte{}, write.Value...)
+				}
+				continue
+			}
+			value, err := c.read(op.Key)
+			if err != nil {
+				return err
+			}
+			op.Value = value
+		case storage.Set, storage.Delete:
+			writes = append(writes, op)
+			pending[op.Key] = op
+		default:
+			return fmt.Errorf("unsupported operation type %d", op.Type)
+		}
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	return c.write(writes)
+}
+
+// usable returns the error operations fail with, if any.
+func (c *fileClient) usable() error {
+	if c.closed {
+		return errClientClosed
+	}
+	return c.broken
+}
+
+// read returns a copy of the value of key.
+func (c *fileClient) read(key string) ([]byte, error) {
+	loc, ok := c.index[key]
+	if !ok {
+		return nil, nil
+	}
+	value := make([]byte, loc.size)
+	if _, err := c.file.ReadAt(value, loc.offset); err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", key, err)
+	}
+	return value, nil
+}
+
+// write appends a record of the operations and applies them to the index.
+func (c *fileClient) write(ops []storage.Operation) error {
+	record, locations := encodeRecord(ops, c.size)
+	if _, err := c.file.WriteAt(record, c.size); err != nil {
+		// Cut off whatever part of the record was written, so that it is
+		// not found on the next start.
+		return errors.Join(fmt.Errorf("failed to write %s: %w", c.path, err), c.file.Truncate(c.size))
+	}
+	if c.cfg.Fsync == FsyncAlways {
+		if err := c.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync %s: %w", c.path, err)
+		}
+	} else {
+		c.dirty = true
+	}
+	c.size += int64(len(record))
+
+	for i, op := range ops {
+		if old, ok := c.index[op.Key]; ok {
+			c.live -= int64(len(op.Key) + old.size)
+			delete(c.index, op.Key)
+		}
+		if op.Type == storage.Set {
+			c.index[op.Key] = locations[i]
+			c.live += int64(len(op.Key) + locations[i].size)
+		}
+	}
+
+	if c.shouldCompact() {
+		if err := c.compact(); err != nil {
+			// The write itself succeeded, and the file is left as it was.
+			c.logger.Warn("Failed to compact the storage file", zap.String("path", c.path), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// garbage returns the number of bytes that compaction would remove.
+func (c *fileClient) garbage() int64 {
+	compacted := int64(len(fileMagic))
+	for key, loc := range c.index {
+		compacted += recordSize(key, loc.size)
+	}
+	return c.size - compacted
+}
+
+// shouldCompact reports whether overwritten and deleted keys take up
+// enough of the file to compact it after a write.
+func (c *fileClient) shouldCompact() bool {
+	ratio := c.cfg.Compaction.MaxGarbageRatio
+	if ratio == 0 || c.size < c.cfg.Compaction.MinFileSizeMiB<<20 {
+		return false
+	}
+	return float64(c.size-c.live) >= ratio*float64(c.size)
+}
+
+// compact rewrites the file with a record for each key of the index and
+// replaces the file with it. Until the rename, the original file is
+// untouched, so a failed or interrupted compaction loses nothing.
+func (c *fileClient) compact() error {
+	tmpPath := c.path + compactSuffix
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	index, size, err := c.copyLive(tmp)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		return errors.Join(err, tmp.Close(), os.Remove(tmpPath))
+	}
+
+	// The compacted file is renamed over the original while both are open,
+	// so the client keeps a usable file whether or not the rename succeeds.
+	if err = os.Rename(tmpPath, c.path); err == nil {
+		if err = c.file.Close(); err != nil {
+			c.logger.Debug("Failed to close the replaced storage file", zap.Error(err))
+		}
+		c.file = tmp
+	} else if err = c.replaceClosed(tmp, tmpPath); err != nil {
+		return err
+	}
+	if err = syncDir(c.path); err != nil {
+		c.logger.Debug("Failed to sync the storage directory", zap.Error(err))
+	}
+	c.index = index
+	c.size = size
+	c.dirty = false
+	return nil
+}
+
+// replaceClosed renames the compacted file over the original with both of
+// them closed, for the platforms where open files cannot be renamed over,
+// and opens the result. If it cannot be opened, the client is broken.
+func (c *fileClient) replaceClosed(tmp *os.File, tmpPath string) error {
+	if err := errors.Join(tmp.Close(), c.file.Close()); err != nil {
+		c.logger.Debug("Failed to close the storage files for compaction", zap.Error(err))
+	}
+	renameErr := os.Rename(tmpPath, c.path)
+	// The path holds a complete file either way: the compacted one, or the
+	// original when the rename failed.
+	file, err := os.OpenFile(c.path, os.O_RDWR, 0o600)
+	if err != nil {
+		c.broken = fmt.Errorf("failed to reopen %s after compaction: %w", c.path, err)
+		return errors.Join(renameErr, c.broken)
+	}
+	c.file = file
+	if renameErr != nil {
+		return errors.Join(renameErr, os.Remove(tmpPath))
+	}
+	return nil
+}
+
+// copyLive writes the header and a record for each key of the index to
+// dst, and returns the index of dst.
+func (c *fileClient) copyLive(dst *os.File) (map[string]valueLocation, int64, error) {
+	if _, err := dst.WriteAt([]byte(fileMagic), 0); err != nil {
+		return nil, 0, err
+	}
+	size := int64(len(fileMagic))
+	index := make(map[string]valueLocation, len(c.index))
+	for key := range c.index {
+		value, err := c.read(key)
+		if err != nil {
+			return nil, 0, err
+		}
+		record, locations := encodeRecord([]storage.Operation{storage.SetOperation(key, value)}, size)
+		if _, err = dst.WriteAt(record, size); err != nil {
+			return nil, 0, err
+		}
+		index[key] = locations[0]
+		size += int64(len(record))
+	}
+	return index, size, nil
+}
+
+// syncPeriodically flushes the writes of the interval policy.
+func (c *fileClient) syncPeriodically(interval time.Duration) {
+	defer close(c.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			if c.dirty && !c.closed && c.broken == nil {
+				if err := c.file.Sync(); err != nil {
+					c.logger.Warn("Failed to sync the storage file", zap.String("path", c.path), zap.Error(err))
+				} else {
+					c.dirty = false
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// Close flushes the file, unless the policy is never, and closes it.
+func (c *fileClient) Close(context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		<-c.done
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.released()
+	if c.broken != nil {
+		// The file was already closed by the compaction.
+		return nil
+	}
+	var err error
+	if c.dirty && c.cfg.Fsync != FsyncNever {
+		err = c.file.Sync()
+	}
+	return errors.Join(err, c.file.Close())
+}
+
+// syncDir flushes the directory entry of path, so that a rename survives a
+// crash. Not every platform can sync directories.
+func syncDir(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	return errors.Join(dir.Sync(), dir.Close())
+}
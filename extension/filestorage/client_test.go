@@ -0,0 +1,356 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package filestorage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+func newTestConfig(t *testing.T) *Config {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Directory = t.TempDir()
+	return cfg
+}
+
+func openTestClient(t *testing.T, cfg *Config) *fileClient {
+	client, err := newFileClient(filepath.Join(cfg.Directory, "client"), cfg, zap.NewNop(), func() {})
+	require.NoError(t, err)
+	return client
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return info.Size()
+}
+
+func TestClientPersistsAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	client := openTestClient(t, cfg)
+	require.NoError(t, client.Set(ctx, "kept", []byte("value")))
+	require.NoError(t, client.Set(ctx, "overwritten", []byte("old")))
+	require.NoError(t, client.Set(ctx, "overwritten", []byte("new")))
+	require.NoError(t, client.Set(ctx, "deleted", []byte("value")))
+	require.NoError(t, client.Delete(ctx, "deleted"))
+	require.NoError(t, client.Set(ctx, "empty", []byte{}))
+	require.NoError(t, client.Close(ctx))
+
+	client = openTestClient(t, cfg)
+	defer func() { require.NoError(t, client.Close(ctx)) }()
+	for key, expected := range map[string][]byte{
+		"kept":        []byte("value"),
+		"overwritten": []byte("new"),
+		"deleted":     nil,
+		"empty":       {},
+		"missing":     nil,
+	} {
+		value, err := client.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, expected, value, key)
+	}
+}
+
+func TestClientBatch(t *testing.T) {
+	ctx := context.Background()
+	client := openTestClient(t, newTestConfig(t))
+	defer func() { require.NoError(t, client.Close(ctx)) }()
+	require.NoError(t, client.Set(ctx, "a", []byte("1")))
+
+	ops := []storage.Operation{
+		storage.GetOperation("a"),
+		storage.SetOperation("a", []byte("2")),
+		storage.GetOperation("a"),
+		storage.SetOperation("b", []byte("3")),
+		storage.DeleteOperation("a"),
+		storage.GetOperation("a"),
+		storage.GetOperation("b"),
+	}
+	require.NoError(t, client.Batch(ctx, ops...))
+	assert.Equal(t, []byte("1"), ops[0].Value)
+	assert.Equal(t, []byte("2"), ops[2].Value)
+	assert.Nil(t, ops[5].Value)
+	assert.Equal(t, []byte("3"), ops[6].Value)
+
+	value, err := client.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+	value, err = client.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("3"), value)
+}
+
+func TestClientDiscardsIncompleteRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, path string, size int64)
+	}{
+		{
+			name: "torn record",
+			corrupt: func(t *testing.T, path string, size int64) {
+				require.NoError(t, os.Truncate(path, size-3))
+			},
+		},
+		{
+			name: "torn header",
+			corrupt: func(t *testing.T, path string, _ int64) {
+				f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+				require.NoError(t, err)
+				_, err = f.Write([]byte{42, 0})
+				require.NoError(t, err)
+				require.NoError(t, f.Close())
+			},
+		},
+		{
+			name: "checksum mismatch",
+			corrupt: func(t *testing.T, path string, size int64) {
+				f, err := os.OpenFile(path, os.O_RDWR, 0o600)
+				require.NoError(t, err)
+				_, err = f.WriteAt([]byte{0xff}, size-1)
+				require.NoError(t, err)
+				require.NoError(t, f.Close())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := newTestConfig(t)
+			cfg.Compaction.OnStart = false
+			client := openTestClient(t, cfg)
+			require.NoError(t, client.Set(ctx, "committed", []byte("value")))
+			committedSize := client.size
+			require.NoError(t, client.Batch(ctx,
+				storage.SetOperation("first", []byte("1")),
+				storage.SetOperation("second", []byte("2")),
+			))
+			require.NoError(t, client.Close(ctx))
+
+			tt.corrupt(t, client.path, fileSize(t, client.path))
+
+			client = openTestClient(t, cfg)
+			defer func() { require.NoError(t, client.Close(ctx)) }()
+			if tt.name != "torn header" {
+				// The whole batch is gone, not only the key whose bytes
+				// were lost.
+				assert.Equal(t, committedSize, fileSize(t, client.path))
+				for _, key := range []string{"first", "second"} {
+					value, err := client.Get(ctx, key)
+					require.NoError(t, err)
+					assert.Nil(t, value, key)
+				}
+			}
+			value, err := client.Get(ctx, "committed")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("value"), value)
+
+			// Writes continue after the last complete record.
+			require.NoError(t, client.Set(ctx, "after", []byte("recovery")))
+			require.NoError(t, client.Close(ctx))
+			client = openTestClient(t, cfg)
+			value, err = client.Get(ctx, "after")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("recovery"), value)
+		})
+	}
+}
+
+func TestClientRejectsDamagedRecords(t *testing.T) {
+	tests := []struct {
+		name string
+		// at is the offset of the damaged byte in the damaged record.
+		at    int64
+		value byte
+	}{
+		{
+			name:  "checksum mismatch",
+			at:    recordHeaderSize + 2,
+			value: 0xff,
+		},
+		{
+			name:  "length past the end",
+			at:    3,
+			value: 0x7f,
+		},
+		{
+			name:  "length inside the file",
+			at:    0,
+			value: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := newTestConfig(t)
+			cfg.Compaction.OnStart = false
+			client := openTestClient(t, cfg)
+			require.NoError(t, client.Set(ctx, "before", []byte("1")))
+			damaged := client.size
+			require.NoError(t, client.Set(ctx, "damaged", []byte("2")))
+			require.NoError(t, client.Delete(ctx, "before"))
+			require.NoError(t, client.Set(ctx, "after", []byte("3")))
+			size := client.size
+			require.NoError(t, client.Close(ctx))
+
+			f, err := os.OpenFile(client.path, os.O_RDWR, 0o600)
+			require.NoError(t, err)
+			_, err = f.WriteAt([]byte{tt.value}, damaged+tt.at)
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			// The records after the damaged one are neither skipped nor cut
+			// off.
+			_, err = newFileClient(client.path, cfg, zap.NewNop(), func() {})
+			require.ErrorContains(t, err, fmt.Sprintf("damaged record at offset %d", damaged))
+			assert.Equal(t, size, fileSize(t, client.path))
+		})
+	}
+}
+
+func TestClientRejectsForeignFile(t *testing.T) {
+	cfg := newTestConfig(t)
+	path := filepath.Join(cfg.Directory, "client")
+	require.NoError(t, os.WriteFile(path, []byte("not a storage file"), 0o600))
+	_, err := newFileClient(path, cfg, zap.NewNop(), func() {})
+	assert.ErrorContains(t, err, "not a file storage file")
+}
+
+func TestClientCompactsAfterWrites(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	cfg.Compaction.MinFileSizeMiB = 0
+	client := openTestClient(t, cfg)
+	value := make([]byte, 1024)
+	for i := range 100 {
+		require.NoError(t, client.Set(ctx, "key", value))
+		require.NoError(t, client.Set(ctx, strconv.Itoa(i%5), value))
+	}
+	// Six live keys, and never more garbage than live data.
+	assert.Less(t, fileSize(t, client.path), int64(12*recordSize("key", len(value))))
+	_, err := os.Stat(client.path + compactSuffix)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	require.NoError(t, client.Close(ctx))
+
+	client = openTestClient(t, cfg)
+	defer func() { require.NoError(t, client.Close(ctx)) }()
+	assert.Len(t, client.index, 6)
+	got, err := client.Get(ctx, "4")
+	require.NoError(t, err)
+	assert.Equal(t, value, got)
+}
+
+func TestClientCompactsOnStart(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	cfg.Compaction.MaxGarbageRatio = 0
+	client := openTestClient(t, cfg)
+	for i := range 100 {
+		require.NoError(t, client.Set(ctx, "key", []byte(strconv.Itoa(i))))
+	}
+	require.NoError(t, client.Close(ctx))
+	uncompacted := fileSize(t, client.path)
+
+	// A compaction interrupted by a crash is discarded.
+	require.NoError(t, os.WriteFile(client.path+compactSuffix, []byte("partial"), 0o600))
+	client = openTestClient(t, cfg)
+	defer func() { require.NoError(t, client.Close(ctx)) }()
+	assert.Equal(t, int64(len(fileMagic))+recordSize("key", 2), fileSize(t, client.path))
+	assert.Less(t, fileSize(t, client.path), uncompacted)
+	_, err := os.Stat(client.path + compactSuffix)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	value, err := client.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("99"), value)
+}
+
+func TestClientBrokenWhenReopenFails(t *testing.T) {
+	ctx := context.Background()
+	released := 0
+	cfg := newTestConfig(t)
+	client, err := newFileClient(filepath.Join(cfg.Directory, "client"), cfg, zap.NewNop(), func() { released++ })
+	require.NoError(t, err)
+	require.NoError(t, client.Set(ctx, "key", []byte("value")))
+
+	// Neither the compacted file nor the original can be found, so the
+	// file cannot be opened again.
+	tmpPath := client.path + compactSuffix
+	tmp, err := os.Create(tmpPath)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(tmpPath))
+	require.NoError(t, os.Remove(client.path))
+	client.mu.Lock()
+	err = client.replaceClosed(tmp, tmpPath)
+	client.mu.Unlock()
+	require.ErrorContains(t, err, "failed to reopen")
+
+	_, err = client.Get(ctx, "key")
+	require.ErrorContains(t, err, "failed to reopen")
+	require.ErrorContains(t, client.Set(ctx, "key", nil), "failed to reopen")
+	require.NoError(t, client.Close(ctx))
+	assert.Equal(t, 1, released)
+}
+
+func TestClientFsyncPolicies(t *testing.T) {
+	for _, policy := range []string{FsyncAlways, FsyncInterval, FsyncNever} {
+		t.Run(policy, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := newTestConfig(t)
+			cfg.Fsync = policy
+			cfg.FsyncInterval = 10 * time.Millisecond
+			client := openTestClient(t, cfg)
+			require.NoError(t, client.Set(ctx, "key", []byte("value")))
+
+			isDirty := func() bool {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				return client.dirty
+			}
+			switch policy {
+			case FsyncAlways:
+				assert.False(t, isDirty())
+			case FsyncInterval:
+				assert.Eventually(t, func() bool { return !isDirty() }, time.Second, 10*time.Millisecond)
+			case FsyncNever:
+				assert.True(t, isDirty())
+			}
+			require.NoError(t, client.Close(ctx))
+
+			client = openTestClient(t, cfg)
+			defer func() { require.NoError(t, client.Close(ctx)) }()
+			value, err := client.Get(ctx, "key")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("value"), value)
+		})
+	}
+}
+
+func TestClientClosed(t *testing.T) {
+	ctx := context.Background()
+	released := 0
+	cfg := newTestConfig(t)
+	client, err := newFileClient(filepath.Join(cfg.Directory, "client"), cfg, zap.NewNop(), func() { released++ })
+	require.NoError(t, err)
+	require.NoError(t, client.Close(ctx))
+	require.NoError(t, client.Close(ctx))
+	assert.Equal(t, 1, released)
+
+	_, err = client.Get(ctx, "key")
+	require.ErrorIs(t, err, errClientClosed)
+	require.ErrorIs(t, client.Set(ctx, "key", nil), errClientClosed)
+	require.ErrorIs(t, client.Delete(ctx, "key"), errClientClosed)
+	require.ErrorIs(t, client.Batch(ctx, storage.GetOperation("key")), errClientClosed)
+}
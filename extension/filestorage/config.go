@@ -0,0 +1,86 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package filestorage // import "go.opentelemetry.io/collector/extension/filestorage"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// FsyncAlways flushes every write to disk before it returns.
+	FsyncAlways = "always"
+	// FsyncInterval flushes the writes of each file periodically.
+	FsyncInterval = "interval"
+	// FsyncNever leaves flushing to the operating system.
+	FsyncNever = "never"
+)
+
+// Config defines the configuration of the file storage extension.
+type Config struct {
+	// Directory holds the files, one for each component using the
+	// extension.
+	Directory string `mapstructure:"directory"`
+	// CreateDirectory creates Directory on start when it does not exist.
+	CreateDirectory bool `mapstructure:"create_directory"`
+
+	// Fsync is when writes are flushed to disk: always, interval or never.
+	// Writes survive a restart of the collector under every policy; the
+	// policy decides how many may be lost when the machine crashes.
+	Fsync string `mapstructure:"fsync"`
+	// FsyncInterval is the period of the interval policy.
+	FsyncInterval time.Duration `mapstructure:"fsync_interval"`
+
+	// Compaction controls when the files are rewritten without the space
+	// taken by overwritten and deleted keys.
+	Compaction CompactionConfig `mapstructure:"compaction"`
+}
+
+// CompactionConfig controls the compaction of the files.
+type CompactionConfig struct {
+	// OnStart compacts each file when it is opened.
+	OnStart bool `mapstructure:"on_start"`
+	// MaxGarbageRatio is the share of a file taken by overwritten and
+	// deleted keys that triggers a compaction after a write. Zero only
+	// compacts on start.
+	MaxGarbageRatio float64 `mapstructure:"max_garbage_ratio"`
+	// MinFileSizeMiB is the size below which files are not compacted after
+	// writes, whatever their garbage ratio.
+	MinFileSizeMiB int64 `mapstructure:"min_file_size_mib"`
+}
+
+// Validate checks that the directory exists and that the policies are
+// valid.
+func (cfg *Config) Validate() error {
+	if cfg.Directory == "" {
+		return errors.New("directory must be specified")
+	}
+	if !cfg.CreateDirectory {
+		info, err := os.Stat(cfg.Directory)
+		if err != nil {
+			return fmt.Errorf("directory %q must exist: %w", cfg.Directory, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%q is not a directory", cfg.Directory)
+		}
+	}
+	switch cfg.Fsync {
+	case FsyncAlways, FsyncNever:
+	case FsyncInterval:
+		if cfg.FsyncInterval <= 0 {
+			return errors.New("fsync_interval must be positive with the interval fsync policy")
+		}
+	default:
+		return fmt.Errorf("fsync %q is not supported, use %s, %s or %s", cfg.Fsync, FsyncAlways, FsyncInterval, FsyncNever)
+	}
+	if cfg.Compaction.MaxGarbageRatio < 0 || cfg.Compaction.MaxGarbageRatio >= 1 {
+		return errors.New("compaction::max_garbage_ratio must be at least 0 and less than 1")
+	}
+	if cfg.Compaction.MinFileSizeMiB < 0 {
+		return errors.New("compaction::min_file_size_mib must not be negative")
+	}
+	return nil
+}
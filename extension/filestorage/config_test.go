@@ -0,0 +1,123 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package filestorage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/confmap"
+	"go.opentelemetry.io/collector/confmap/confmaptest"
+)
+
+func TestUnmarshalDefaultConfig(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig()
+	require.NoError(t, confmap.New().Unmarshal(&cfg))
+	assert.Equal(t, factory.CreateDefaultConfig(), cfg)
+}
+
+func TestUnmarshalConfig(t *testing.T) {
+	cm, err := confmaptest.LoadConf(filepath.Join("testdata", "config.yaml"))
+	require.NoError(t, err)
+	cfg := NewFactory().CreateDefaultConfig()
+	require.NoError(t, cm.Unmarshal(&cfg))
+	assert.Equal(t,
+		&Config{
+			Directory:       "/var/lib/otelcol/queues",
+			CreateDirectory: true,
+			Fsync:           FsyncAlways,
+			FsyncInterval:   time.Second,
+			Compaction: CompactionConfig{
+				OnStart:         false,
+				MaxGarbageRatio: 0.25,
+				MinFileSizeMiB:  16,
+			},
+		}, cfg)
+	assert.NoError(t, cfg.(*Config).Validate())
+}
+
+func TestValidateConfig(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	tests := []struct {
+		name      string
+		configure func(*Config)
+		expected  string
+	}{
+		{
+			name:      "valid",
+			configure: func(*Config) {},
+		},
+		{
+			name:      "missing directory",
+			configure: func(cfg *Config) { cfg.Directory = "" },
+			expected:  "directory must be specified",
+		},
+		{
+			name:      "nonexistent directory",
+			configure: func(cfg *Config) { cfg.Directory = filepath.Join(dir, "missing") },
+			expected:  "must exist",
+		},
+		{
+			name: "nonexistent directory created on start",
+			configure: func(cfg *Config) {
+				cfg.Directory = filepath.Join(dir, "missing")
+				cfg.CreateDirectory = true
+			},
+		},
+		{
+			name:      "not a directory",
+			configure: func(cfg *Config) { cfg.Directory = file },
+			expected:  "is not a directory",
+		},
+		{
+			name:      "zero fsync interval",
+			configure: func(cfg *Config) { cfg.FsyncInterval = 0 },
+			expected:  "fsync_interval must be positive with the interval fsync policy",
+		},
+		{
+			name: "fsync interval ignored by other policies",
+			configure: func(cfg *Config) {
+				cfg.Fsync = FsyncNever
+				cfg.FsyncInterval = 0
+			},
+		},
+		{
+			name:      "unknown fsync policy",
+			configure: func(cfg *Config) { cfg.Fsync = "sometimes" },
+			expected:  `fsync "sometimes" is not supported, use always, interval or never`,
+		},
+		{
+			name:      "garbage ratio of one",
+			configure: func(cfg *Config) { cfg.Compaction.MaxGarbageRatio = 1 },
+			expected:  "compaction::max_garbage_ratio must be at least 0 and less than 1",
+		},
+		{
+			name:      "negative minimum file size",
+			configure: func(cfg *Config) { cfg.Compaction.MinFileSizeMiB = -1 },
+			expected:  "compaction::min_file_size_mib must not be negative",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Directory = dir
+			tt.configure(cfg)
+			err := cfg.Validate()
+			if tt.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,9 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate mdatagen metadata.yaml
+
+// Package filestorage implements a storage extension that keeps the data
+// of each component in a file of its own, so that persistent queues and
+// other stateful components survive restarts.
+package filestorage // import "go.opentelemetry.io/collector/extension/filestorage"
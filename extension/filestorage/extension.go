@@ -0,0 +1,116 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package filestorage // import "go.opentelemetry.io/collector/extension/filestorage"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+type fileStorage struct {
+	cfg    *Config
+	logger *zap.Logger
+
+	mu      sync.Mutex
+	clients map[string]*fileClient
+}
+
+var _ storage.Extension = (*fileStorage)(nil)
+
+func newFileStorage(cfg *Config, logger *zap.Logger) *fileStorage {
+	return &fileStorage{
+		cfg:     cfg,
+		logger:  logger,
+		clients: make(map[string]*fileClient),
+	}
+}
+
+// Start creates the directory when configured to.
+func (fs *fileStorage) Start(context.Context, component.Host) error {
+	if fs.cfg.CreateDirectory {
+		return os.MkdirAll(fs.cfg.Directory, 0o750)
+	}
+	return nil
+}
+
+// Shutdown closes the clients that their components left open.
+func (fs *fileStorage) Shutdown(ctx context.Context) error {
+	fs.mu.Lock()
+	clients := make([]*fileClient, 0, len(fs.clients))
+	for _, client := range fs.clients {
+		clients = append(clients, client)
+	}
+	fs.mu.Unlock()
+
+	var errs error
+	for _, client := range clients {
+		errs = errors.Join(errs, client.Close(ctx))
+	}
+	return errs
+}
+
+// GetClient returns a client storing the data of the component in a file of
+// its own. A file is used by a single client at a time.
+func (fs *fileStorage) GetClient(_ context.Context, kind component.Kind, id component.ID, storageName string) (storage.Client, error) {
+	name := fileName(kind, id, storageName)
+	path := filepath.Join(fs.cfg.Directory, name)
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.clients[name]; ok {
+		return nil, fmt.Errorf("storage %s is already in use", name)
+	}
+	client, err := newFileClient(path, fs.cfg, fs.logger.With(zap.String("file", name)), func() {
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+		delete(fs.clients, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	fs.clients[name] = client
+	return client, nil
+}
+
+// fileName returns the name of the file of a component, made of its kind,
+// type and name, and the storage name if any.
+func fileName(kind component.Kind, id component.ID, storageName string) string {
+	parts := []string{strings.ToLower(kind.String()), id.Type().String()}
+	if id.Name() != "" {
+		parts = append(parts, id.Name())
+	}
+	if storageName != "" {
+		parts = append(parts, storageName)
+	}
+	for i, part := range parts {
+		parts[i] = sanitize(part)
+	}
+	return strings.Join(parts, "_")
+}
+
+// sanitize escapes every character but ASCII letters, digits and dashes, so
+// that names are safe on every file system and distinct names map to
+// distinct files.
+func sanitize(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			b.WriteRune(r)
+		default:
+			fmt.Fprintf(&b, "~%04X", r)
+		}
+	}
+	return b.String()
+}
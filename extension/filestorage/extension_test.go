@@ -0,0 +1,83 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package filestorage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+)
+
+func newTestExtension(t *testing.T, cfg *Config) storage.Extension {
+	ext, err := NewFactory().Create(context.Background(), extensiontest.NewNopSettings(), cfg)
+	require.NoError(t, err)
+	require.NoError(t, ext.Start(context.Background(), componenttest.NewNopHost()))
+	return ext.(storage.Extension)
+}
+
+func TestExtensionCreatesDirectory(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.Directory = filepath.Join(cfg.Directory, "nested", "queues")
+	cfg.CreateDirectory = true
+	ext := newTestExtension(t, cfg)
+	defer func() { require.NoError(t, ext.Shutdown(context.Background())) }()
+
+	info, err := os.Stat(cfg.Directory)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+}
+
+func TestExtensionClientPerComponent(t *testing.T) {
+	ctx := context.Background()
+	cfg := newTestConfig(t)
+	ext := newTestExtension(t, cfg)
+	id := component.MustNewIDWithName("otlp", "backup")
+
+	queue, err := ext.GetClient(ctx, component.KindExporter, id, "")
+	require.NoError(t, err)
+	checkpoints, err := ext.GetClient(ctx, component.KindExporter, id, "checkpoints")
+	require.NoError(t, err)
+	receiver, err := ext.GetClient(ctx, component.KindReceiver, id, "")
+	require.NoError(t, err)
+	require.NoError(t, queue.Set(ctx, "key", []byte("queue")))
+	require.NoError(t, checkpoints.Set(ctx, "key", []byte("checkpoints")))
+	value, err := receiver.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	for _, name := range []string{"exporter_otlp_backup", "exporter_otlp_backup_checkpoints", "receiver_otlp_backup"} {
+		assert.FileExists(t, filepath.Join(cfg.Directory, name))
+	}
+
+	// A file has a single client at a time.
+	_, err = ext.GetClient(ctx, component.KindExporter, id, "")
+	require.ErrorContains(t, err, "storage exporter_otlp_backup is already in use")
+	require.NoError(t, queue.Close(ctx))
+	queue, err = ext.GetClient(ctx, component.KindExporter, id, "")
+	require.NoError(t, err)
+	value, err = queue.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("queue"), value)
+
+	// Shutdown closes the clients left open.
+	require.NoError(t, ext.Shutdown(ctx))
+	_, err = checkpoints.Get(ctx, "key")
+	assert.ErrorIs(t, err, errClientClosed)
+}
+
+func TestFileName(t *testing.T) {
+	assert.Equal(t, "processor_batch", fileName(component.KindProcessor, component.MustNewID("batch"), ""))
+	assert.Equal(t, "exporter_otlp_a~002Fb_c~002Ed",
+		fileName(component.KindExporter, component.MustNewIDWithName("otlp", "a/b"), "c.d"))
+	assert.Equal(t, "exporter_otlp_a~005Fb", fileName(component.KindExporter, component.MustNewIDWithName("otlp", "a_b"), ""))
+}
@@ -0,0 +1,43 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package filestorage // import "go.opentelemetry.io/collector/extension/filestorage"
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/extension/filestorage/internal/metadata"
+)
+
+// defaultDirectory is the directory the files are kept in by default.
+var defaultDirectory = filepath.Join(string(filepath.Separator), "var", "lib", "otelcol", "file_storage")
+
+// NewFactory returns a new factory for the file storage extension.
+func NewFactory() extension.Factory {
+	return extension.NewFactory(
+		metadata.Type,
+		createDefaultConfig,
+		create,
+		metadata.ExtensionStability)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Directory:     defaultDirectory,
+		Fsync:         FsyncInterval,
+		FsyncInterval: time.Second,
+		Compaction: CompactionConfig{
+			OnStart:         true,
+			MaxGarbageRatio: 0.5,
+			MinFileSizeMiB:  1,
+		},
+	}
+}
+
+func create(_ context.Context, set extension.Settings, cfg component.Config) (extension.Extension, error) {
+	return newFileStorage(cfg.(*Config), set.TelemetrySettings.Logger), nil
+}
@@ -0,0 +1,211 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package filestorage // import "go.opentelemetry.io/collector/extension/filestorage"
+
+// A file starts with fileMagic, followed by records:
+//
+//	length  uint32, little endian, of the payload
+//	crc     uint32, little endian, CRC-32 (IEEE) of the payload
+//	payload uvarint count of operations, then for each operation:
+//	        type byte, set or delete
+//	        uvarint length of the key, the key
+//	        uvarint length of the value, the value (sets only)
+//
+// Each call to Set, Delete or Batch appends exactly one record.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+const (
+	fileMagic        = "OTELFS\x00\x01"
+	recordHeaderSize = 8
+
+	opSet    byte = 1
+	opDelete byte = 2
+)
+
+// encodeRecord returns the record of the sets and deletes in ops, to be
+// written at offset, and where the value of each set will be in the file.
+func encodeRecord(ops []storage.Operation, offset int64) ([]byte, []valueLocation) {
+	buf := make([]byte, recordHeaderSize, recordHeaderSize+64)
+	buf = binary.AppendUvarint(buf, uint64(len(ops)))
+	locations := make([]valueLocation, len(ops))
+	for i, op := range ops {
+		if op.Type == storage.Delete {
+			buf = append(buf, opDelete)
+			buf = binary.AppendUvarint(buf, uint64(len(op.Key)))
+			buf = append(buf, op.Key...)
+			continue
+		}
+		buf = append(buf, opSet)
+		buf = binary.AppendUvarint(buf, uint64(len(op.Key)))
+		buf = append(buf, op.Key...)
+		buf = binary.AppendUvarint(buf, uint64(len(op.Value)))
+		locations[i] = valueLocation{offset: offset + int64(len(buf)), size: len(op.Value)}
+		buf = append(buf, op.Value...)
+	}
+	payload := buf[recordHeaderSize:]
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(payload))
+	return buf, locations
+}
+
+// recordSize returns the size of a record setting a single key.
+func recordSize(key string, valueSize int) int64 {
+	payload := uvarintSize(1) + 1 + uvarintSize(len(key)) + len(key) + uvarintSize(valueSize) + valueSize
+	return int64(recordHeaderSize + payload)
+}
+
+func uvarintSize(n int) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], uint64(n))
+}
+
+// readRecords reads a file of size bytes and returns the index of its keys
+// and the offset where its last complete record ends. Any bytes after the
+// end belong to a record that was not completely written. A damaged record
+// followed by complete ones is an error: skipping it could lose a Delete
+// and bring back stale keys, and its length can no longer be trusted.
+func readRecords(r io.ReaderAt, size int64) (map[string]valueLocation, int64, error) {
+	br := bufio.NewReader(io.NewSectionReader(r, 0, size))
+	magic := make([]byte, len(fileMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != fileMagic {
+		return nil, 0, errors.New("not a file storage file")
+	}
+
+	index := make(map[string]valueLocation)
+	offset := int64(len(fileMagic))
+	header := make([]byte, recordHeaderSize)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return index, offset, nil
+			}
+			return nil, 0, err
+		}
+		length := int64(binary.LittleEndian.Uint32(header[0:4]))
+		if length > size-offset-recordHeaderSize {
+			// Either the last record was not completely written, or the
+			// length of a record was damaged.
+			found, err := recordFollows(r, offset+1, size)
+			if err != nil {
+				return nil, 0, err
+			}
+			if found {
+				return nil, 0, fmt.Errorf("damaged record at offset %d", offset)
+			}
+			return index, offset, nil
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return nil, 0, err
+		}
+		end := offset + recordHeaderSize + length
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:8]) {
+			if end < size {
+				return nil, 0, fmt.Errorf("damaged record at offset %d", offset)
+			}
+			// The last record was not completely written.
+			return index, offset, nil
+		}
+		if err := applyRecord(index, payload, offset+recordHeaderSize); err != nil {
+			// The checksum matched, so the file was not written by this
+			// extension.
+			return nil, 0, fmt.Errorf("record at offset %d: %w", offset, err)
+		}
+		offset = end
+	}
+}
+
+// recordFollows returns whether a complete record starts anywhere between
+// from and the end of a file of size bytes.
+func recordFollows(r io.ReaderAt, from, size int64) (bool, error) {
+	const chunkSize = 64 << 10
+	// Chunks overlap by a header, so headers across chunks are read too.
+	buf := make([]byte, chunkSize+recordHeaderSize-1)
+	for start := from; start+recordHeaderSize <= size; start += chunkSize {
+		n, err := r.ReadAt(buf[:min(int64(len(buf)), size-start)], start)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, err
+		}
+		for i := 0; i < chunkSize && i+recordHeaderSize <= n; i++ {
+			offset := start + int64(i)
+			length := int64(binary.LittleEndian.Uint32(buf[i : i+4]))
+			// Every record holds at least the count of its operations.
+			if length == 0 || length > size-offset-recordHeaderSize {
+				continue
+			}
+			payload := make([]byte, length)
+			if _, err := r.ReadAt(payload, offset+recordHeaderSize); err != nil {
+				return false, err
+			}
+			if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(buf[i+4:i+8]) {
+				continue
+			}
+			if applyRecord(make(map[string]valueLocation), payload, 0) == nil {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+var errMalformedRecord = errors.New("malformed record")
+
+// applyRecord applies the operations of a payload found at offset to the
+// index.
+func applyRecord(index map[string]valueLocation, payload []byte, offset int64) error {
+	pos := 0
+	next := func() (int, bool) {
+		n, size := binary.Uvarint(payload[pos:])
+		if size <= 0 || n > uint64(len(payload)-pos-size) {
+			return 0, false
+		}
+		pos += size
+		return int(n), true
+	}
+
+	count, ok := next()
+	if !ok {
+		return errMalformedRecord
+	}
+	for range count {
+		if pos >= len(payload) {
+			return errMalformedRecord
+		}
+		op := payload[pos]
+		pos++
+		keyLen, ok := next()
+		if !ok {
+			return errMalformedRecord
+		}
+		key := string(payload[pos : pos+keyLen])
+		pos += keyLen
+		switch op {
+		case opDelete:
+			delete(index, key)
+		case opSet:
+			valueLen, ok := next()
+			if !ok {
+				return errMalformedRecord
+			}
+			index[key] = valueLocation{offset: offset + int64(pos), size: valueLen}
+			pos += valueLen
+		default:
+			return errMalformedRecord
+		}
+	}
+	if pos != len(payload) {
+		return errMalformedRecord
+	}
+	return nil
+}
@@ -22,6 +22,24 @@ func (e *ProtoMarshaler) LogsSize(ld Logs) int {
 	return pb.Size()
 }
 
+// ResourceLogsSize returns the size in bytes of a ResourceLogs marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) ResourceLogsSize(rl ResourceLogs) int {
+	return rl.orig.Size()
+}
+
+// ScopeLogsSize returns the size in bytes of a ScopeLogs marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) ScopeLogsSize(sl ScopeLogs) int {
+	return sl.orig.Size()
+}
+
+// LogRecordSize returns the size in bytes of a LogRecord marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) LogRecordSize(lr LogRecord) int {
+	return lr.orig.Size()
+}
+
 var _ Unmarshaler = (*ProtoUnmarshaler)(nil)
 
 type ProtoUnmarshaler struct{}
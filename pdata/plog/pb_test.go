@@ -4,6 +4,7 @@
 package plog
 
 import (
+	"encoding/binary"
 	"testing"
 	"time"
 
@@ -36,6 +37,29 @@ func TestProtoSizerEmptyLogs(t *testing.T) {
 	assert.Equal(t, 0, sizer.LogsSize(NewLogs()))
 }
 
+func TestProtoSizerNestedMessages(t *testing.T) {
+	marshaler := &ProtoMarshaler{}
+	ld := NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("scope")
+	sl.LogRecords().AppendEmpty().Body().SetStr("first")
+	sl.LogRecords().AppendEmpty().Body().SetStr("second")
+
+	// Each message takes its own size, plus a tag and its length in the
+	// message holding it.
+	fieldSize := func(size int) int { return 1 + len(binary.AppendUvarint(nil, uint64(size))) + size }
+	assert.Equal(t, marshaler.LogsSize(ld), fieldSize(marshaler.ResourceLogsSize(rl)))
+
+	rlSize := marshaler.ResourceLogsSize(rl)
+	slSize := marshaler.ScopeLogsSize(sl)
+	lrSize := marshaler.LogRecordSize(sl.LogRecords().At(1))
+	sl.LogRecords().RemoveIf(func(lr LogRecord) bool { return lr.Body().Str() == "second" })
+	assert.Equal(t, slSize-fieldSize(lrSize), marshaler.ScopeLogsSize(sl))
+	assert.Equal(t, rlSize-fieldSize(slSize)+fieldSize(marshaler.ScopeLogsSize(sl)), marshaler.ResourceLogsSize(rl))
+}
+
 func BenchmarkLogsToProto(b *testing.B) {
 	marshaler := &ProtoMarshaler{}
 	logs := generateBenchmarkLogs(128)
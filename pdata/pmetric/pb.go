@@ -22,6 +22,48 @@ func (e *ProtoMarshaler) MetricsSize(md Metrics) int {
 	return pb.Size()
 }
 
+// ResourceMetricsSize returns the size in bytes of a ResourceMetrics marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) ResourceMetricsSize(rm ResourceMetrics) int {
+	return rm.orig.Size()
+}
+
+// ScopeMetricsSize returns the size in bytes of a ScopeMetrics marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) ScopeMetricsSize(sm ScopeMetrics) int {
+	return sm.orig.Size()
+}
+
+// MetricSize returns the size in bytes of a Metric marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) MetricSize(m Metric) int {
+	return m.orig.Size()
+}
+
+// NumberDataPointSize returns the size in bytes of a NumberDataPoint marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) NumberDataPointSize(dp NumberDataPoint) int {
+	return dp.orig.Size()
+}
+
+// HistogramDataPointSize returns the size in bytes of a HistogramDataPoint marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) HistogramDataPointSize(dp HistogramDataPoint) int {
+	return dp.orig.Size()
+}
+
+// ExponentialHistogramDataPointSize returns the size in bytes of an ExponentialHistogramDataPoint marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) ExponentialHistogramDataPointSize(dp ExponentialHistogramDataPoint) int {
+	return dp.orig.Size()
+}
+
+// SummaryDataPointSize returns the size in bytes of a SummaryDataPoint marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) SummaryDataPointSize(dp SummaryDataPoint) int {
+	return dp.orig.Size()
+}
+
 type ProtoUnmarshaler struct{}
 
 func (d *ProtoUnmarshaler) UnmarshalMetrics(buf []byte) (Metrics, error) {
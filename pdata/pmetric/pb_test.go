@@ -4,6 +4,7 @@
 package pmetric
 
 import (
+	"encoding/binary"
 	"testing"
 	"time"
 
@@ -36,6 +37,57 @@ func TestProtoSizerEmptyMetrics(t *testing.T) {
 	assert.Equal(t, 0, sizer.MetricsSize(NewMetrics()))
 }
 
+func TestProtoSizerNestedMessages(t *testing.T) {
+	marshaler := &ProtoMarshaler{}
+	md := NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("scope")
+	metrics := sm.Metrics()
+	metrics.AppendEmpty().SetEmptySum().DataPoints().AppendEmpty().SetIntValue(1)
+	metrics.AppendEmpty().SetEmptyHistogram().DataPoints().AppendEmpty().SetCount(2)
+	metrics.AppendEmpty().SetEmptyExponentialHistogram().DataPoints().AppendEmpty().SetCount(3)
+	metrics.AppendEmpty().SetEmptySummary().DataPoints().AppendEmpty().SetCount(4)
+
+	// Each message takes its own size, plus a tag and its length in the
+	// message holding it.
+	fieldSize := func(size int) int { return 1 + len(binary.AppendUvarint(nil, uint64(size))) + size }
+	assert.Equal(t, marshaler.MetricsSize(md), fieldSize(marshaler.ResourceMetricsSize(rm)))
+	rmSize := marshaler.ResourceMetricsSize(rm)
+	smSize := marshaler.ScopeMetricsSize(sm)
+	metricsSize := 0
+	for i := 0; i < metrics.Len(); i++ {
+		metricsSize += fieldSize(marshaler.MetricSize(metrics.At(i)))
+	}
+	sm.Metrics().RemoveIf(func(Metric) bool { return true })
+	assert.Equal(t, smSize-metricsSize, marshaler.ScopeMetricsSize(sm))
+	assert.Equal(t, rmSize-fieldSize(smSize)+fieldSize(marshaler.ScopeMetricsSize(sm)), marshaler.ResourceMetricsSize(rm))
+
+	// Data points are held in the data of the metric, which is held in the
+	// metric.
+	gauge := NewMetric()
+	gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetDoubleValue(1.5)
+	sum := NewMetric()
+	sum.SetEmptySum().DataPoints().AppendEmpty().SetIntValue(1)
+	histogram := NewMetric()
+	histogram.SetEmptyHistogram().DataPoints().AppendEmpty().SetCount(2)
+	exponential := NewMetric()
+	exponential.SetEmptyExponentialHistogram().DataPoints().AppendEmpty().SetCount(3)
+	summary := NewMetric()
+	summary.SetEmptySummary().DataPoints().AppendEmpty().SetCount(4)
+	for metric, pointSize := range map[Metric]int{
+		gauge:       marshaler.NumberDataPointSize(gauge.Gauge().DataPoints().At(0)),
+		sum:         marshaler.NumberDataPointSize(sum.Sum().DataPoints().At(0)),
+		histogram:   marshaler.HistogramDataPointSize(histogram.Histogram().DataPoints().At(0)),
+		exponential: marshaler.ExponentialHistogramDataPointSize(exponential.ExponentialHistogram().DataPoints().At(0)),
+		summary:     marshaler.SummaryDataPointSize(summary.Summary().DataPoints().At(0)),
+	} {
+		assert.NotZero(t, pointSize)
+		assert.Equal(t, fieldSize(fieldSize(pointSize)), marshaler.MetricSize(metric), metric.Type().String())
+	}
+}
+
 func BenchmarkMetricsToProto(b *testing.B) {
 	marshaler := &ProtoMarshaler{}
 	metrics := generateBenchmarkMetrics(128)
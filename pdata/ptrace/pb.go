@@ -22,6 +22,24 @@ func (e *ProtoMarshaler) TracesSize(td Traces) int {
 	return pb.Size()
 }
 
+// ResourceSpansSize returns the size in bytes of a ResourceSpans marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) ResourceSpansSize(rs ResourceSpans) int {
+	return rs.orig.Size()
+}
+
+// ScopeSpansSize returns the size in bytes of a ScopeSpans marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) ScopeSpansSize(ss ScopeSpans) int {
+	return ss.orig.Size()
+}
+
+// SpanSize returns the size in bytes of a Span marshaled with protobuf, without the
+// tag and length of the field holding it.
+func (e *ProtoMarshaler) SpanSize(span Span) int {
+	return span.orig.Size()
+}
+
 type ProtoUnmarshaler struct{}
 
 func (d *ProtoUnmarshaler) UnmarshalTraces(buf []byte) (Traces, error) {
@@ -4,6 +4,7 @@
 package ptrace
 
 import (
+	"encoding/binary"
 	"testing"
 	"time"
 
@@ -37,6 +38,29 @@ func TestProtoSizerEmptyTraces(t *testing.T) {
 	assert.Equal(t, 0, sizer.TracesSize(NewTraces()))
 }
 
+func TestProtoSizerNestedMessages(t *testing.T) {
+	marshaler := &ProtoMarshaler{}
+	td := NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("scope")
+	ss.Spans().AppendEmpty().SetName("first")
+	ss.Spans().AppendEmpty().SetName("second")
+
+	// Each message takes its own size, plus a tag and its length in the
+	// message holding it.
+	fieldSize := func(size int) int { return 1 + len(binary.AppendUvarint(nil, uint64(size))) + size }
+	assert.Equal(t, marshaler.TracesSize(td), fieldSize(marshaler.ResourceSpansSize(rs)))
+
+	rsSize := marshaler.ResourceSpansSize(rs)
+	ssSize := marshaler.ScopeSpansSize(ss)
+	spanSize := marshaler.SpanSize(ss.Spans().At(1))
+	ss.Spans().RemoveIf(func(span Span) bool { return span.Name() == "second" })
+	assert.Equal(t, ssSize-fieldSize(spanSize), marshaler.ScopeSpansSize(ss))
+	assert.Equal(t, rsSize-fieldSize(ssSize)+fieldSize(marshaler.ScopeSpansSize(ss)), marshaler.ResourceSpansSize(rs))
+}
+
 func BenchmarkTracesToProto(b *testing.B) {
 	marshaler := &ProtoMarshaler{}
 	traces := generateBenchmarkTraces(128)
@@ -0,0 +1,95 @@
+// receiver/clickhousereceiver/config.go
+package clickhousereceiver
+
+import (
+    "errors"
+    "fmt"
+    "regexp"
+    "time"
+
+    "go.opentelemetry.io/collector/component"
+    "go.opentelemetry.io/collector/config/configopaque"
+    "go.opentelemetry.io/collector/config/configtls"
+    "go.opentelemetry.io/collector/receiver/scraperhelper"
+)
+
+// Config defines configuration for the ClickHouse receiver. The connection
+// settings and table names match those of the ClickHouse exporter, so that
+// the tables it writes can be read back.
+type Config struct {
+    scraperhelper.ControllerConfig `mapstructure:",squash"`
+
+    // Endpoint is the address of the server, using the native protocol.
+    Endpoint string              `mapstructure:"endpoint"`
+    Username string              `mapstructure:"username"`
+    Password configopaque.String `mapstructure:"password"`
+    Database string              `mapstructure:"database"`
+    // Secure connects over TLS, configured by TLSSetting.
+    Secure     bool                   `mapstructure:"secure"`
+    TLSSetting configtls.ClientConfig `mapstructure:"tls"`
+    // DialTimeout is the timeout for establishing a connection.
+    DialTimeout time.Duration `mapstructure:"dial_timeout"`
+
+    // Tables holds the names of the tables read, inside Database.
+    Tables TablesConfig `mapstructure:"tables"`
+
+    // StartTime is where the replay starts when no watermark is stored.
+    // Zero replays every row.
+    StartTime time.Time `mapstructure:"start_time"`
+    // Window is the longest span of time replayed by one scrape, which
+    // bounds the size of the emitted payloads.
+    Window time.Duration `mapstructure:"window"`
+    // Delay holds back the most recent rows, so that rows still being
+    // inserted are not skipped by the watermark.
+    Delay time.Duration `mapstructure:"delay"`
+    // StorageID is the storage extension the watermark is persisted with.
+    // Without it, a restart replays from StartTime again.
+    StorageID *component.ID `mapstructure:"storage"`
+}
+
+// TablesConfig names the metric tables read. Empty names are not read.
+type TablesConfig struct {
+    Metrics                     string `mapstructure:"metrics"`
+    MetricsHistogram            string `mapstructure:"metrics_histogram"`
+    MetricsExponentialHistogram string `mapstructure:"metrics_exponential_histogram"`
+    MetricsSummary              string `mapstructure:"metrics_summary"`
+}
+
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Validate checks that the configuration is usable.
+func (cfg *Config) Validate() error {
+    if err := cfg.ControllerConfig.Validate(); err != nil {
+        return err
+    }
+    if cfg.Endpoint == "" {
+        return errors.New("endpoint must be specified")
+    }
+    if cfg.Database == "" {
+        return errors.New("database must be specified")
+    }
+    // Names are interpolated into SQL, so only plain identifiers are allowed.
+    names := [][2]string{
+        {"database", cfg.Database},
+        {"tables::metrics", cfg.Tables.Metrics},
+        {"tables::metrics_histogram", cfg.Tables.MetricsHistogram},
+        {"tables::metrics_exponential_histogram", cfg.Tables.MetricsExponentialHistogram},
+        {"tables::metrics_summary", cfg.Tables.MetricsSummary},
+    }
+    for _, name := range names {
+        // Tables may be left out.
+        if name[1] == "" {
+            continue
+        }
+        if !identifierRe.MatchString(name[1]) {
+            return fmt.Errorf("%s %q is not a valid identifier", name[0], name[1])
+        }
+    }
+    if cfg.Tables == (TablesConfig{}) {
+        return errors.New("tables must name at least one table")
+    }
+    if cfg.Window < 0 || cfg.Delay < 0 {
+        return errors.New("window and delay must not be negative")
+    }
+    return nil
+}
@@ -0,0 +1,78 @@
+// receiver/clickhousereceiver/config_test.go
+package clickhousereceiver
+
+import (
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.opentelemetry.io/collector/component"
+    "go.opentelemetry.io/collector/confmap"
+    "go.opentelemetry.io/collector/confmap/confmaptest"
+)
+
+func TestUnmarshalDefaultConfig(t *testing.T) {
+    factory := NewFactory()
+    cfg := factory.CreateDefaultConfig()
+    require.NoError(t, confmap.New().Unmarshal(&cfg))
+    assert.Equal(t, factory.CreateDefaultConfig(), cfg)
+    // The endpoint has no default, so the default config is invalid.
+    assert.Error(t, component.ValidateConfig(cfg))
+}
+
+func TestUnmarshalConfig(t *testing.T) {
+    cm, err := confmaptest.LoadConf(filepath.Join("testdata", "config.yaml"))
+    require.NoError(t, err)
+    factory := NewFactory()
+    cfg := factory.CreateDefaultConfig()
+    require.NoError(t, cm.Unmarshal(&cfg))
+
+    storage := component.MustNewID("file_storage")
+    expected := factory.CreateDefaultConfig().(*Config)
+    expected.Endpoint = "clickhouse.example.com:9440"
+    expected.Username = "otel"
+    expected.Password = "s3cr3t"
+    expected.Database = "telemetry"
+    expected.TLSSetting.CAFile = "/etc/clickhouse/ca.pem"
+    expected.DialTimeout = 5 * time.Second
+    expected.CollectionInterval = 30 * time.Second
+    expected.Tables.Metrics = "otel_metrics"
+    expected.Tables.MetricsSummary = ""
+    expected.StartTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    expected.Window = 15 * time.Minute
+    expected.Delay = 2 * time.Minute
+    expected.StorageID = &storage
+    assert.Equal(t, expected, cfg)
+    assert.NoError(t, component.ValidateConfig(cfg))
+}
+
+func TestConfigValidate(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = "localhost:9000"
+    assert.NoError(t, cfg.Validate())
+
+    cfg.Tables.MetricsHistogram = "metrics_histogram; DROP TABLE otel.metrics"
+    assert.EqualError(t, cfg.Validate(), `tables::metrics_histogram "metrics_histogram; DROP TABLE otel.metrics" is not a valid identifier`)
+
+    cfg.Tables = TablesConfig{}
+    assert.EqualError(t, cfg.Validate(), "tables must name at least one table")
+
+    cfg.Tables.Metrics = "metrics"
+    cfg.Window = -time.Minute
+    assert.EqualError(t, cfg.Validate(), "window and delay must not be negative")
+
+    cfg.Window = time.Minute
+    cfg.CollectionInterval = 0
+    assert.Error(t, cfg.Validate())
+
+    cfg.CollectionInterval = time.Minute
+    assert.NoError(t, cfg.Validate())
+
+    cfg.Database = ""
+    assert.EqualError(t, cfg.Validate(), "database must be specified")
+
+    cfg.Endpoint = ""
+    assert.EqualError(t, cfg.Validate(), "endpoint must be specified")
+}
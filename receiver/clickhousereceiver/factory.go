@@ -0,0 +1,62 @@
+// receiver/clickhousereceiver/factory.go
+package clickhousereceiver
+
+import (
+    "context"
+    "time"
+
+    "go.opentelemetry.io/collector/component"
+    "go.opentelemetry.io/collector/config/configtls"
+    "go.opentelemetry.io/collector/consumer"
+    "go.opentelemetry.io/collector/receiver"
+    "go.opentelemetry.io/collector/receiver/scraperhelper"
+)
+
+const typeStr = "clickhouse"
+
+func NewFactory() receiver.Factory {
+    return receiver.NewFactory(
+        component.MustNewType(typeStr),
+        createDefaultConfig,
+        receiver.WithMetrics(createMetricsReceiver, component.StabilityLevelDevelopment),
+    )
+}
+
+func createDefaultConfig() component.Config {
+    return &Config{
+        ControllerConfig: scraperhelper.NewDefaultControllerConfig(),
+        Username:         "default",
+        Database:         "otel",
+        Secure:           true,
+        TLSSetting:       configtls.NewDefaultClientConfig(),
+        DialTimeout:      30 * time.Second,
+        Tables: TablesConfig{
+            Metrics:                     "metrics",
+            MetricsHistogram:            "metrics_histogram",
+            MetricsExponentialHistogram: "metrics_exponential_histogram",
+            MetricsSummary:              "metrics_summary",
+        },
+        Window: time.Hour,
+        Delay:  time.Minute,
+    }
+}
+
+func createMetricsReceiver(
+    _ context.Context,
+    set receiver.Settings,
+    cfg component.Config,
+    next consumer.Metrics,
+) (receiver.Metrics, error) {
+    oCfg := cfg.(*Config)
+    s := newReplayScraper(set, oCfg)
+    scraper, err := scraperhelper.NewScraper(set.ID.Type(), s.scrape,
+        scraperhelper.WithStart(s.start),
+        scraperhelper.WithShutdown(s.shutdown),
+    )
+    if err != nil {
+        return nil, err
+    }
+    return scraperhelper.NewScraperControllerReceiver(&oCfg.ControllerConfig, set, s.consumer(next),
+        scraperhelper.AddScraper(scraper),
+    )
+}
@@ -0,0 +1,320 @@
+// receiver/clickhousereceiver/metrics.go
+package clickhousereceiver
+
+import (
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+    "go.opentelemetry.io/collector/pdata/pcommon"
+    "go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// table is a metric table with the columns read from it and the function
+// converting one of its rows.
+type table struct {
+    name    string
+    columns string
+    read    func(rows driver.Rows, b *metricsBuilder) error
+}
+
+// metaColumns are read from every metric table, in the order of the
+// fields of rowMeta. The exemplars columns are not read, so the replayed
+// data points carry no exemplars.
+const metaColumns = `timestamp, start_time_unix, flags, metric_name, metric_description, metric_unit,
+    labels, labels_int, labels_float, labels_bool,
+    resource_attributes, resource_schema_url,
+    scope_name, scope_version, scope_attributes, scope_schema_url`
+
+const (
+    numberColumns = metaColumns + `,
+    metric_type, value, is_monotonic, temporality`
+    histogramColumns = metaColumns + `,
+    temporality, count, sum, min, max, bucket_counts, explicit_bounds`
+    exponentialHistogramColumns = metaColumns + `,
+    temporality, count, sum, min, max, scale, zero_count,
+    positive_offset, positive_bucket_counts, negative_offset, negative_bucket_counts`
+    summaryColumns = metaColumns + `,
+    count, sum, quantiles.quantile, quantiles.value`
+)
+
+// rowMeta holds the columns shared by every metric table.
+type rowMeta struct {
+    timestamp          time.Time
+    startTime          time.Time
+    flags              uint32
+    name               string
+    description        string
+    unit               string
+    labels             map[string]string
+    labelsInt          map[string]int64
+    labelsFloat        map[string]float64
+    labelsBool         map[string]bool
+    resourceAttributes map[string]string
+    resourceSchemaURL  string
+    scopeName          string
+    scopeVersion       string
+    scopeAttributes    map[string]string
+    scopeSchemaURL     string
+}
+
+// dest returns the scan destinations of the shared columns, followed by
+// those of the table.
+func (m *rowMeta) dest(columns ...any) []any {
+    return append([]any{
+        &m.timestamp, &m.startTime, &m.flags, &m.name, &m.description, &m.unit,
+        &m.labels, &m.labelsInt, &m.labelsFloat, &m.labelsBool,
+        &m.resourceAttributes, &m.resourceSchemaURL,
+        &m.scopeName, &m.scopeVersion, &m.scopeAttributes, &m.scopeSchemaURL,
+    }, columns...)
+}
+
+// fill sets the timestamps, flags and attributes of a data point.
+func (m *rowMeta) fill(timestamp, startTime func(pcommon.Timestamp), flags func(pmetric.DataPointFlags), attributes pcommon.Map) {
+    timestamp(pcommon.NewTimestampFromTime(m.timestamp))
+    if !m.startTime.IsZero() && m.startTime.Unix() > 0 {
+        startTime(pcommon.NewTimestampFromTime(m.startTime))
+    }
+    flags(pmetric.DataPointFlags(m.flags))
+    attributes.EnsureCapacity(len(m.labels) + len(m.labelsInt) + len(m.labelsFloat) + len(m.labelsBool))
+    for k, v := range m.labels {
+        attributes.PutStr(k, v)
+    }
+    for k, v := range m.labelsInt {
+        attributes.PutInt(k, v)
+    }
+    for k, v := range m.labelsFloat {
+        attributes.PutDouble(k, v)
+    }
+    for k, v := range m.labelsBool {
+        attributes.PutBool(k, v)
+    }
+}
+
+func readNumberRow(rows driver.Rows, b *metricsBuilder) error {
+    var (
+        meta        rowMeta
+        metricType  string
+        value       float64
+        isMonotonic bool
+        temporality string
+    )
+    if err := rows.Scan(meta.dest(&metricType, &value, &isMonotonic, &temporality)...); err != nil {
+        return err
+    }
+    var dp pmetric.NumberDataPoint
+    if metricType == "sum" {
+        sum := b.metric(&meta, pmetric.MetricTypeSum, temporality, isMonotonic).Sum()
+        dp = sum.DataPoints().AppendEmpty()
+    } else {
+        dp = b.metric(&meta, pmetric.MetricTypeGauge, "", false).Gauge().DataPoints().AppendEmpty()
+    }
+    meta.fill(dp.SetTimestamp, dp.SetStartTimestamp, dp.SetFlags, dp.Attributes())
+    dp.SetDoubleValue(value)
+    return nil
+}
+
+func readHistogramRow(rows driver.Rows, b *metricsBuilder) error {
+    var (
+        meta           rowMeta
+        temporality    string
+        count          uint64
+        sum, min, max  *float64
+        bucketCounts   []uint64
+        explicitBounds []float64
+    )
+    if err := rows.Scan(meta.dest(&temporality, &count, &sum, &min, &max, &bucketCounts, &explicitBounds)...); err != nil {
+        return err
+    }
+    dp := b.metric(&meta, pmetric.MetricTypeHistogram, temporality, false).Histogram().DataPoints().AppendEmpty()
+    meta.fill(dp.SetTimestamp, dp.SetStartTimestamp, dp.SetFlags, dp.Attributes())
+    dp.SetCount(count)
+    if sum != nil {
+        dp.SetSum(*sum)
+    }
+    if min != nil {
+        dp.SetMin(*min)
+    }
+    if max != nil {
+        dp.SetMax(*max)
+    }
+    dp.BucketCounts().FromRaw(bucketCounts)
+    dp.ExplicitBounds().FromRaw(explicitBounds)
+    return nil
+}
+
+func readExponentialHistogramRow(rows driver.Rows, b *metricsBuilder) error {
+    var (
+        meta                 rowMeta
+        temporality          string
+        count, zeroCount     uint64
+        sum, min, max        *float64
+        scale                int32
+        positiveOffset       int32
+        positiveBucketCounts []uint64
+        negativeOffset       int32
+        negativeBucketCounts []uint64
+    )
+    if err := rows.Scan(meta.dest(&temporality, &count, &sum, &min, &max, &scale, &zeroCount,
+        &positiveOffset, &positiveBucketCounts, &negativeOffset, &negativeBucketCounts)...); err != nil {
+        return err
+    }
+    dp := b.metric(&meta, pmetric.MetricTypeExponentialHistogram, temporality, false).ExponentialHistogram().DataPoints().AppendEmpty()
+    meta.fill(dp.SetTimestamp, dp.SetStartTimestamp, dp.SetFlags, dp.Attributes())
+    dp.SetCount(count)
+    if sum != nil {
+        dp.SetSum(*sum)
+    }
+    if min != nil {
+        dp.SetMin(*min)
+    }
+    if max != nil {
+        dp.SetMax(*max)
+    }
+    dp.SetScale(scale)
+    dp.SetZeroCount(zeroCount)
+    dp.Positive().SetOffset(positiveOffset)
+    dp.Positive().BucketCounts().FromRaw(positiveBucketCounts)
+    dp.Negative().SetOffset(negativeOffset)
+    dp.Negative().BucketCounts().FromRaw(negativeBucketCounts)
+    return nil
+}
+
+func readSummaryRow(rows driver.Rows, b *metricsBuilder) error {
+    var (
+        meta      rowMeta
+        count     uint64
+        sum       float64
+        quantiles []float64
+        values    []float64
+    )
+    if err := rows.Scan(meta.dest(&count, &sum, &quantiles, &values)...); err != nil {
+        return err
+    }
+    dp := b.metric(&meta, pmetric.MetricTypeSummary, "", false).Summary().DataPoints().AppendEmpty()
+    meta.fill(dp.SetTimestamp, dp.SetStartTimestamp, dp.SetFlags, dp.Attributes())
+    dp.SetCount(count)
+    dp.SetSum(sum)
+    for i := range min(len(quantiles), len(values)) {
+        q := dp.QuantileValues().AppendEmpty()
+        q.SetQuantile(quantiles[i])
+        q.SetValue(values[i])
+    }
+    return nil
+}
+
+// metricsBuilder groups the rows read into resources, scopes and metrics,
+// so that data points of the same metric end up in a single metric again.
+type metricsBuilder struct {
+    md        pmetric.Metrics
+    resources map[string]*resourceBuilder
+}
+
+type resourceBuilder struct {
+    metrics pmetric.ResourceMetrics
+    scopes  map[string]*scopeBuilder
+}
+
+type scopeBuilder struct {
+    metrics pmetric.ScopeMetrics
+    byKey   map[string]pmetric.Metric
+}
+
+func newMetricsBuilder(md pmetric.Metrics) *metricsBuilder {
+    return &metricsBuilder{md: md, resources: map[string]*resourceBuilder{}}
+}
+
+// metric returns the metric a row belongs to, creating it, its scope and
+// its resource as needed.
+func (b *metricsBuilder) metric(m *rowMeta, metricType pmetric.MetricType, temporality string, isMonotonic bool) pmetric.Metric {
+    resourceKey := mapKey(m.resourceAttributes) + "\x00" + m.resourceSchemaURL
+    rb, ok := b.resources[resourceKey]
+    if !ok {
+        rm := b.md.ResourceMetrics().AppendEmpty()
+        rm.SetSchemaUrl(m.resourceSchemaURL)
+        putStrings(rm.Resource().Attributes(), m.resourceAttributes)
+        rb = &resourceBuilder{metrics: rm, scopes: map[string]*scopeBuilder{}}
+        b.resources[resourceKey] = rb
+    }
+
+    scopeKey := strings.Join([]string{m.scopeName, m.scopeVersion, mapKey(m.scopeAttributes), m.scopeSchemaURL}, "\x00")
+    sb, ok := rb.scopes[scopeKey]
+    if !ok {
+        sm := rb.metrics.ScopeMetrics().AppendEmpty()
+        sm.SetSchemaUrl(m.scopeSchemaURL)
+        sm.Scope().SetName(m.scopeName)
+        sm.Scope().SetVersion(m.scopeVersion)
+        putStrings(sm.Scope().Attributes(), m.scopeAttributes)
+        sb = &scopeBuilder{metrics: sm, byKey: map[string]pmetric.Metric{}}
+        rb.scopes[scopeKey] = sb
+    }
+
+    metricKey := strings.Join([]string{m.name, m.description, m.unit, metricType.String(), temporality, boolKey(isMonotonic)}, "\x00")
+    metric, ok := sb.byKey[metricKey]
+    if !ok {
+        metric = sb.metrics.Metrics().AppendEmpty()
+        metric.SetName(m.name)
+        metric.SetDescription(m.description)
+        metric.SetUnit(m.unit)
+        switch metricType {
+        case pmetric.MetricTypeGauge:
+            metric.SetEmptyGauge()
+        case pmetric.MetricTypeSum:
+            metric.SetEmptySum().SetAggregationTemporality(parseTemporality(temporality))
+            metric.Sum().SetIsMonotonic(isMonotonic)
+        case pmetric.MetricTypeHistogram:
+            metric.SetEmptyHistogram().SetAggregationTemporality(parseTemporality(temporality))
+        case pmetric.MetricTypeExponentialHistogram:
+            metric.SetEmptyExponentialHistogram().SetAggregationTemporality(parseTemporality(temporality))
+        case pmetric.MetricTypeSummary:
+            metric.SetEmptySummary()
+        }
+        sb.byKey[metricKey] = metric
+    }
+    return metric
+}
+
+// parseTemporality returns the temporality of a value of the temporality
+// enum column.
+func parseTemporality(temporality string) pmetric.AggregationTemporality {
+    switch temporality {
+    case "delta":
+        return pmetric.AggregationTemporalityDelta
+    case "cumulative":
+        return pmetric.AggregationTemporalityCumulative
+    }
+    return pmetric.AggregationTemporalityUnspecified
+}
+
+// mapKey serializes a map with its keys sorted, so that equal maps have
+// equal keys.
+func mapKey(m map[string]string) string {
+    keys := make([]string, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    var sb strings.Builder
+    for _, k := range keys {
+        sb.WriteString(k)
+        sb.WriteByte('\x01')
+        sb.WriteString(m[k])
+        sb.WriteByte('\x02')
+    }
+    return sb.String()
+}
+
+func putStrings(dest pcommon.Map, m map[string]string) {
+    dest.EnsureCapacity(len(m))
+    for k, v := range m {
+        dest.PutStr(k, v)
+    }
+}
+
+func boolKey(b bool) string {
+    if b {
+        return "1"
+    }
+    return "0"
+}
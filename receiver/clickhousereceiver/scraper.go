@@ -0,0 +1,285 @@
+// receiver/clickhousereceiver/scraper.go
+package clickhousereceiver
+
+import (
+    "context"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/ClickHouse/clickhouse-go/v2"
+    "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+    "go.opentelemetry.io/collector/component"
+    "go.opentelemetry.io/collector/consumer"
+    "go.opentelemetry.io/collector/extension/experimental/storage"
+    "go.opentelemetry.io/collector/pdata/pmetric"
+    "go.opentelemetry.io/collector/receiver"
+    "go.uber.org/zap"
+)
+
+// watermarkKey is the storage key of the high watermark: the timestamp up
+// to which every row has been replayed.
+const watermarkKey = "high_watermark"
+
+// dbConn is the part of the ClickHouse client the receiver depends on.
+type dbConn interface {
+    Query(ctx context.Context, query string, args ...any) (driver.Rows, error)
+    QueryRow(ctx context.Context, query string, args ...any) driver.Row
+    Ping(ctx context.Context) error
+    Close() error
+}
+
+// replayScraper reads the rows written since the watermark on every scrape
+// and converts them back into metrics.
+type replayScraper struct {
+    cfg    *Config
+    id     component.ID
+    logger *zap.Logger
+    open   func(*clickhouse.Options) (dbConn, error)
+    now    func() time.Time
+
+    conn      dbConn
+    storage   storage.Client
+    watermark time.Time
+    // pending is the watermark reached by the last scrape. It is committed
+    // once the replayed metrics were consumed, and is zero when there is
+    // nothing to commit.
+    pending time.Time
+}
+
+func newReplayScraper(set receiver.Settings, cfg *Config) *replayScraper {
+    return &replayScraper{
+        cfg:    cfg,
+        id:     set.ID,
+        logger: set.Logger,
+        open:   openConn,
+        now:    time.Now,
+    }
+}
+
+func openConn(opts *clickhouse.Options) (dbConn, error) {
+    conn, err := clickhouse.Open(opts)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open ClickHouse connection: %w", err)
+    }
+    return conn, nil
+}
+
+// start connects to ClickHouse and loads the stored watermark.
+func (s *replayScraper) start(ctx context.Context, host component.Host) error {
+    client, err := s.storageClient(ctx, host)
+    if err != nil {
+        return err
+    }
+    s.storage = client
+
+    s.watermark = s.cfg.StartTime
+    stored, err := s.storage.Get(ctx, watermarkKey)
+    if err != nil {
+        return fmt.Errorf("failed to load watermark: %w", err)
+    }
+    if len(stored) == 8 {
+        s.watermark = time.Unix(0, int64(binary.BigEndian.Uint64(stored))).UTC()
+    }
+
+    opts := &clickhouse.Options{
+        Addr: []string{s.cfg.Endpoint},
+        Auth: clickhouse.Auth{
+            Username: s.cfg.Username,
+            Password: string(s.cfg.Password),
+        },
+        DialTimeout: s.cfg.DialTimeout,
+    }
+    if s.cfg.Secure {
+        tlsConfig, err := s.cfg.TLSSetting.LoadTLSConfig(ctx)
+        if err != nil {
+            return fmt.Errorf("failed to load TLS configuration: %w", err)
+        }
+        opts.TLS = tlsConfig
+    }
+    conn, err := s.open(opts)
+    if err != nil {
+        return err
+    }
+    if err := conn.Ping(ctx); err != nil {
+        _ = conn.Close()
+        return fmt.Errorf("failed to ping ClickHouse: %w", err)
+    }
+    s.conn = conn
+
+    s.logger.Info("Replaying ClickHouse tables",
+        zap.String("endpoint", s.cfg.Endpoint),
+        zap.String("database", s.cfg.Database),
+        zap.Time("watermark", s.watermark),
+    )
+    return nil
+}
+
+// storageClient returns a client of the configured storage extension, or
+// one that stores nothing.
+func (s *replayScraper) storageClient(ctx context.Context, host component.Host) (storage.Client, error) {
+    if s.cfg.StorageID == nil {
+        return storage.NewNopClient(), nil
+    }
+    ext, ok := host.GetExtensions()[*s.cfg.StorageID]
+    if !ok {
+        return nil, fmt.Errorf("storage extension %s not found", s.cfg.StorageID)
+    }
+    storageExt, ok := ext.(storage.Extension)
+    if !ok {
+        return nil, fmt.Errorf("extension %s is not a storage extension", s.cfg.StorageID)
+    }
+    client, err := storageExt.GetClient(ctx, component.KindReceiver, s.id, "")
+    if err != nil {
+        return nil, fmt.Errorf("failed to get storage client: %w", err)
+    }
+    return client, nil
+}
+
+func (s *replayScraper) shutdown(ctx context.Context) error {
+    var errs error
+    if s.conn != nil {
+        errs = errors.Join(errs, s.conn.Close())
+    }
+    if s.storage != nil {
+        errs = errors.Join(errs, s.storage.Close(ctx))
+    }
+    return errs
+}
+
+// scrape replays the rows after the watermark, up to one window past the
+// first of them and no later than the delay allows. The watermark is only
+// moved, and stored, once every table has been read and the next consumer
+// accepted the metrics, so a failed scrape or export is retried from the
+// same place.
+func (s *replayScraper) scrape(ctx context.Context) (pmetric.Metrics, error) {
+    md := pmetric.NewMetrics()
+    s.pending = time.Time{}
+
+    limit := s.now().Add(-s.cfg.Delay)
+    if !s.watermark.Before(limit) {
+        return md, nil
+    }
+    first, err := s.firstTimestamp(ctx)
+    if err != nil {
+        return md, err
+    }
+    // Without rows, or with rows only past the limit, there is nothing to
+    // replay yet.
+    if first.IsZero() || first.After(limit) {
+        return md, nil
+    }
+    upper := limit
+    if s.cfg.Window > 0 && first.Add(s.cfg.Window).Before(upper) {
+        // The window starts at the first row, so that gaps in the data are
+        // skipped in one step.
+        upper = first.Add(s.cfg.Window)
+    }
+
+    b := newMetricsBuilder(md)
+    for _, t := range s.tables() {
+        if err := s.readTable(ctx, t, upper, b); err != nil {
+            return pmetric.NewMetrics(), fmt.Errorf("failed to read %s: %w", t.name, err)
+        }
+    }
+
+    s.pending = upper
+    return md, nil
+}
+
+// commit moves the watermark to the one reached by the last scrape and
+// stores it. The metrics were delivered at that point, so the watermark
+// moves even when it cannot be stored; the next commit stores it again.
+func (s *replayScraper) commit(ctx context.Context) {
+    if s.pending.IsZero() {
+        return
+    }
+    s.watermark, s.pending = s.pending, time.Time{}
+
+    value := make([]byte, 8)
+    binary.BigEndian.PutUint64(value, uint64(s.watermark.UnixNano()))
+    if err := s.storage.Set(ctx, watermarkKey, value); err != nil {
+        s.logger.Error("Failed to store watermark", zap.Time("watermark", s.watermark), zap.Error(err))
+    }
+}
+
+// consumer returns a consumer passing the replayed metrics to next, which
+// commits the watermark once next accepted them. The scraper controller
+// hands the metrics of a scrape to it before the next scrape starts.
+func (s *replayScraper) consumer(next consumer.Metrics) consumer.Metrics {
+    return committingConsumer{Metrics: next, scraper: s}
+}
+
+type committingConsumer struct {
+    consumer.Metrics
+    scraper *replayScraper
+}
+
+func (c committingConsumer) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+    if err := c.Metrics.ConsumeMetrics(ctx, md); err != nil {
+        // The rows are replayed again by the next scrape.
+        c.scraper.pending = time.Time{}
+        return err
+    }
+    c.scraper.commit(ctx)
+    return nil
+}
+
+// firstTimestamp returns the timestamp of the first row after the
+// watermark in any table, or the zero time when there is none.
+func (s *replayScraper) firstTimestamp(ctx context.Context) (time.Time, error) {
+    var first time.Time
+    for _, t := range s.tables() {
+        var ts *time.Time
+        query := fmt.Sprintf("SELECT minOrNull(timestamp) FROM %s WHERE timestamp > fromUnixTimestamp64Nano(?)", s.table(t.name))
+        if err := s.conn.QueryRow(ctx, query, s.watermark.UnixNano()).Scan(&ts); err != nil {
+            return time.Time{}, fmt.Errorf("failed to read first timestamp of %s: %w", t.name, err)
+        }
+        if ts != nil && (first.IsZero() || ts.Before(first)) {
+            first = ts.UTC()
+        }
+    }
+    return first, nil
+}
+
+// readTable converts the rows of a table between the watermark and upper.
+func (s *replayScraper) readTable(ctx context.Context, t table, upper time.Time, b *metricsBuilder) error {
+    query := fmt.Sprintf(`SELECT %s
+FROM %s
+WHERE timestamp > fromUnixTimestamp64Nano(?) AND timestamp <= fromUnixTimestamp64Nano(?)
+ORDER BY timestamp`, t.columns, s.table(t.name))
+    rows, err := s.conn.Query(ctx, query, s.watermark.UnixNano(), upper.UnixNano())
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+    for rows.Next() {
+        if err := t.read(rows, b); err != nil {
+            return err
+        }
+    }
+    return rows.Err()
+}
+
+// table returns the fully qualified name of a table.
+func (s *replayScraper) table(name string) string {
+    return s.cfg.Database + "." + name
+}
+
+// tables returns the configured tables with the columns read from them.
+func (s *replayScraper) tables() []table {
+    all := []table{
+        {name: s.cfg.Tables.Metrics, columns: numberColumns, read: readNumberRow},
+        {name: s.cfg.Tables.MetricsHistogram, columns: histogramColumns, read: readHistogramRow},
+        {name: s.cfg.Tables.MetricsExponentialHistogram, columns: exponentialHistogramColumns, read: readExponentialHistogramRow},
+        {name: s.cfg.Tables.MetricsSummary, columns: summaryColumns, read: readSummaryRow},
+    }
+    tables := all[:0]
+    for _, t := range all {
+        if t.name != "" {
+            tables = append(tables, t)
+        }
+    }
+    return tables
+}
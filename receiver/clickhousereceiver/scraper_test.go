@@ -0,0 +1,441 @@
+// receiver/clickhousereceiver/scraper_test.go
+package clickhousereceiver
+
+import (
+    "context"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "reflect"
+    "regexp"
+    "sort"
+    "testing"
+    "time"
+
+    "github.com/ClickHouse/clickhouse-go/v2"
+    "github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.opentelemetry.io/collector/component"
+    "go.opentelemetry.io/collector/component/componenttest"
+    "go.opentelemetry.io/collector/consumer/consumertest"
+    "go.opentelemetry.io/collector/extension/experimental/storage"
+    "go.opentelemetry.io/collector/pdata/pmetric"
+    "go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fakeConn serves the rows of its tables, selecting them by the bounds of
+// the queries like ClickHouse would.
+type fakeConn struct {
+    tables  map[string][][]any
+    failing string
+    queries []string
+    closed  bool
+}
+
+var fromRe = regexp.MustCompile(`FROM (\S+)`)
+
+func (c *fakeConn) selectRows(query string, args []any) ([][]any, error) {
+    c.queries = append(c.queries, query)
+    table := fromRe.FindStringSubmatch(query)[1]
+    if table == c.failing {
+        return nil, errors.New("table unavailable")
+    }
+    lower := time.Unix(0, args[0].(int64))
+    upper := time.Unix(1<<62, 0)
+    if len(args) > 1 {
+        upper = time.Unix(0, args[1].(int64))
+    }
+    var rows [][]any
+    for _, row := range c.tables[table] {
+        ts := row[0].(time.Time)
+        if ts.After(lower) && !ts.After(upper) {
+            rows = append(rows, row)
+        }
+    }
+    sort.SliceStable(rows, func(i, j int) bool { return rows[i][0].(time.Time).Before(rows[j][0].(time.Time)) })
+    return rows, nil
+}
+
+func (c *fakeConn) Query(_ context.Context, query string, args ...any) (driver.Rows, error) {
+    rows, err := c.selectRows(query, args)
+    if err != nil {
+        return nil, err
+    }
+    return &fakeRows{rows: rows, index: -1}, nil
+}
+
+func (c *fakeConn) QueryRow(_ context.Context, query string, args ...any) driver.Row {
+    rows, err := c.selectRows(query, args)
+    var first *time.Time
+    if len(rows) > 0 {
+        ts := rows[0][0].(time.Time)
+        first = &ts
+    }
+    return &fakeRows{rows: [][]any{{first}}, err: err}
+}
+
+func (c *fakeConn) Ping(context.Context) error { return nil }
+
+func (c *fakeConn) Close() error {
+    c.closed = true
+    return nil
+}
+
+// fakeRows scans its values into destinations of the same type.
+type fakeRows struct {
+    driver.Rows
+    rows  [][]any
+    index int
+    err   error
+}
+
+func (r *fakeRows) Next() bool {
+    r.index++
+    return r.index < len(r.rows)
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+    if r.err != nil {
+        return r.err
+    }
+    row := r.rows[max(r.index, 0)]
+    if len(dest) != len(row) {
+        return fmt.Errorf("scanning %d columns into %d destinations", len(row), len(dest))
+    }
+    for i, value := range row {
+        target := reflect.ValueOf(dest[i]).Elem()
+        if value == nil {
+            target.SetZero()
+            continue
+        }
+        target.Set(reflect.ValueOf(value))
+    }
+    return nil
+}
+
+func (r *fakeRows) Err() error   { return r.err }
+func (r *fakeRows) Close() error { return nil }
+
+// metaValues returns the shared columns of a row of the metric name, in a
+// resource of the service.
+func metaValues(ts time.Time, name, service string) []any {
+    return []any{
+        ts, ts.Add(-time.Minute), uint32(0), name, "description of " + name, "ms",
+        map[string]string{"path": "/"}, map[string]int64{"status": 200}, map[string]float64{}, map[string]bool{"ok": true},
+        map[string]string{"service.name": service}, "",
+        "replay", "1.0.0", map[string]string{}, "",
+    }
+}
+
+func gaugeRow(ts time.Time, name, service string, value float64) []any {
+    return append(metaValues(ts, name, service), "gauge", value, false, "unspecified")
+}
+
+func sumRow(ts time.Time, name, service string, value float64) []any {
+    return append(metaValues(ts, name, service), "sum", value, true, "cumulative")
+}
+
+func histogramRow(ts time.Time, name, service string) []any {
+    sum := 12.5
+    return append(metaValues(ts, name, service), "delta", uint64(3), &sum, (*float64)(nil), (*float64)(nil),
+        []uint64{1, 2}, []float64{10})
+}
+
+func summaryRow(ts time.Time, name, service string) []any {
+    return append(metaValues(ts, name, service), uint64(4), 20.0, []float64{0.5, 0.99}, []float64{4, 9})
+}
+
+// memClient is a storage client keeping its values in memory.
+type memClient struct {
+    storage.Client
+    values map[string][]byte
+}
+
+func (c *memClient) Get(_ context.Context, key string) ([]byte, error) { return c.values[key], nil }
+
+func (c *memClient) Set(_ context.Context, key string, value []byte) error {
+    c.values[key] = value
+    return nil
+}
+
+func (c *memClient) Close(context.Context) error { return nil }
+
+type storageExtension struct {
+    component.Component
+    client *memClient
+}
+
+func (e *storageExtension) GetClient(context.Context, component.Kind, component.ID, string) (storage.Client, error) {
+    return e.client, nil
+}
+
+type storageHost struct {
+    component.Host
+    extensions map[component.ID]component.Component
+}
+
+func (h storageHost) GetExtensions() map[component.ID]component.Component { return h.extensions }
+
+var storageID = component.MustNewID("file_storage")
+
+func newStorageHost(client *memClient) component.Host {
+    return storageHost{
+        Host:       componenttest.NewNopHost(),
+        extensions: map[component.ID]component.Component{storageID: &storageExtension{client: client}},
+    }
+}
+
+// newTestScraper returns a started scraper reading from conn, whose clock
+// is at now.
+func newTestScraper(t *testing.T, conn *fakeConn, client *memClient, now time.Time, configure func(*Config)) *replayScraper {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = "localhost:9000"
+    cfg.Secure = false
+    cfg.StorageID = &storageID
+    if configure != nil {
+        configure(cfg)
+    }
+    require.NoError(t, cfg.Validate())
+    s := newReplayScraper(receivertest.NewNopSettings(), cfg)
+    s.open = func(*clickhouse.Options) (dbConn, error) { return conn, nil }
+    s.now = func() time.Time { return now }
+    require.NoError(t, s.start(context.Background(), newStorageHost(client)))
+    return s
+}
+
+func storedWatermark(t *testing.T, client *memClient) time.Time {
+    value := client.values[watermarkKey]
+    require.Len(t, value, 8)
+    return time.Unix(0, int64(binary.BigEndian.Uint64(value))).UTC()
+}
+
+// scrape scrapes s and passes the metrics to a consumer accepting them,
+// like the scraper controller does.
+func scrape(s *replayScraper) (pmetric.Metrics, error) {
+    md, err := s.scrape(context.Background())
+    if err != nil {
+        return md, err
+    }
+    return md, s.consumer(consumertest.NewNop()).ConsumeMetrics(context.Background(), md)
+}
+
+func TestScrapeRebuildsMetrics(t *testing.T) {
+    conn := &fakeConn{tables: map[string][][]any{
+        "otel.metrics": {
+            gaugeRow(t0.Add(time.Second), "memory", "api", 1),
+            gaugeRow(t0.Add(2*time.Second), "memory", "api", 2),
+            sumRow(t0.Add(3*time.Second), "requests", "api", 10),
+            gaugeRow(t0.Add(4*time.Second), "memory", "worker", 3),
+        },
+        "otel.metrics_histogram": {histogramRow(t0.Add(5*time.Second), "latency", "api")},
+        "otel.metrics_summary":   {summaryRow(t0.Add(6*time.Second), "duration", "api")},
+    }}
+    client := &memClient{values: map[string][]byte{}}
+    s := newTestScraper(t, conn, client, t0.Add(time.Hour), nil)
+
+    md, err := scrape(s)
+    require.NoError(t, err)
+    assert.Equal(t, 6, md.DataPointCount())
+    require.Equal(t, 2, md.ResourceMetrics().Len())
+
+    api := md.ResourceMetrics().At(0)
+    service, _ := api.Resource().Attributes().Get("service.name")
+    assert.Equal(t, "api", service.Str())
+    require.Equal(t, 1, api.ScopeMetrics().Len())
+    scope := api.ScopeMetrics().At(0)
+    assert.Equal(t, "replay", scope.Scope().Name())
+    assert.Equal(t, "1.0.0", scope.Scope().Version())
+
+    // Rows of the same metric are data points of a single metric again.
+    metrics := scope.Metrics()
+    require.Equal(t, 4, metrics.Len())
+    memory := metrics.At(0)
+    assert.Equal(t, "memory", memory.Name())
+    assert.Equal(t, "ms", memory.Unit())
+    require.Equal(t, pmetric.MetricTypeGauge, memory.Type())
+    require.Equal(t, 2, memory.Gauge().DataPoints().Len())
+    dp := memory.Gauge().DataPoints().At(1)
+    assert.Equal(t, 2.0, dp.DoubleValue())
+    assert.Equal(t, t0.Add(2*time.Second), dp.Timestamp().AsTime())
+    assert.Equal(t, t0.Add(2*time.Second-time.Minute), dp.StartTimestamp().AsTime())
+    assert.Equal(t, map[string]any{"path": "/", "status": int64(200), "ok": true}, dp.Attributes().AsRaw())
+
+    requests := metrics.At(1)
+    require.Equal(t, pmetric.MetricTypeSum, requests.Type())
+    assert.True(t, requests.Sum().IsMonotonic())
+    assert.Equal(t, pmetric.AggregationTemporalityCumulative, requests.Sum().AggregationTemporality())
+
+    latency := metrics.At(2)
+    require.Equal(t, pmetric.MetricTypeHistogram, latency.Type())
+    assert.Equal(t, pmetric.AggregationTemporalityDelta, latency.Histogram().AggregationTemporality())
+    hdp := latency.Histogram().DataPoints().At(0)
+    assert.Equal(t, uint64(3), hdp.Count())
+    assert.Equal(t, 12.5, hdp.Sum())
+    assert.False(t, hdp.HasMin())
+    assert.Equal(t, []uint64{1, 2}, hdp.BucketCounts().AsRaw())
+    assert.Equal(t, []float64{10}, hdp.ExplicitBounds().AsRaw())
+
+    duration := metrics.At(3)
+    require.Equal(t, pmetric.MetricTypeSummary, duration.Type())
+    quantiles := duration.Summary().DataPoints().At(0).QuantileValues()
+    require.Equal(t, 2, quantiles.Len())
+    assert.Equal(t, 0.99, quantiles.At(1).Quantile())
+    assert.Equal(t, 9.0, quantiles.At(1).Value())
+
+    // The window reaches past the delay, so the scrape reads up to the delay.
+    assert.Equal(t, t0.Add(59*time.Minute), storedWatermark(t, client))
+}
+
+func TestScrapeWindowAndDelay(t *testing.T) {
+    conn := &fakeConn{tables: map[string][][]any{
+        "otel.metrics": {
+            gaugeRow(t0.Add(time.Minute), "memory", "api", 1),
+            gaugeRow(t0.Add(30*time.Minute), "memory", "api", 2),
+            gaugeRow(t0.Add(5*time.Hour), "memory", "api", 3),
+            gaugeRow(t0.Add(6*time.Hour-30*time.Second), "memory", "api", 4),
+        },
+    }}
+    client := &memClient{values: map[string][]byte{}}
+    s := newTestScraper(t, conn, client, t0.Add(6*time.Hour), func(cfg *Config) {
+        cfg.Window = time.Hour
+        cfg.Delay = time.Minute
+    })
+
+    // The window starts at the first row.
+    md, err := scrape(s)
+    require.NoError(t, err)
+    assert.Equal(t, 2, md.DataPointCount())
+    assert.Equal(t, t0.Add(time.Hour+time.Minute), storedWatermark(t, client))
+
+    // The gap up to the next row is skipped in one scrape.
+    md, err = scrape(s)
+    require.NoError(t, err)
+    assert.Equal(t, 1, md.DataPointCount())
+    assert.Equal(t, t0.Add(6*time.Hour-time.Minute), storedWatermark(t, client))
+
+    // The last row is within the delay, so it is held back.
+    md, err = scrape(s)
+    require.NoError(t, err)
+    assert.Equal(t, 0, md.DataPointCount())
+    assert.Equal(t, t0.Add(6*time.Hour-time.Minute), storedWatermark(t, client))
+
+    s.now = func() time.Time { return t0.Add(7 * time.Hour) }
+    md, err = scrape(s)
+    require.NoError(t, err)
+    assert.Equal(t, 1, md.DataPointCount())
+}
+
+func TestScrapeErrorKeepsWatermark(t *testing.T) {
+    conn := &fakeConn{
+        tables: map[string][][]any{
+            "otel.metrics":         {gaugeRow(t0.Add(time.Second), "memory", "api", 1)},
+            "otel.metrics_summary": {summaryRow(t0.Add(2*time.Second), "duration", "api")},
+        },
+        failing: "otel.metrics_summary",
+    }
+    client := &memClient{values: map[string][]byte{}}
+    s := newTestScraper(t, conn, client, t0.Add(time.Hour), nil)
+
+    _, err := scrape(s)
+    assert.ErrorContains(t, err, "table unavailable")
+    assert.Empty(t, client.values)
+
+    // The next scrape replays the same rows.
+    conn.failing = ""
+    md, err := scrape(s)
+    require.NoError(t, err)
+    assert.Equal(t, 2, md.DataPointCount())
+}
+
+func TestConsumeErrorKeepsWatermark(t *testing.T) {
+    conn := &fakeConn{tables: map[string][][]any{
+        "otel.metrics": {gaugeRow(t0.Add(time.Second), "memory", "api", 1)},
+    }}
+    client := &memClient{values: map[string][]byte{}}
+    s := newTestScraper(t, conn, client, t0.Add(time.Hour), nil)
+
+    md, err := s.scrape(context.Background())
+    require.NoError(t, err)
+    assert.Equal(t, 1, md.DataPointCount())
+    // Nothing is stored before the metrics are consumed.
+    assert.Empty(t, client.values)
+    err = s.consumer(consumertest.NewErr(errors.New("pipeline full"))).ConsumeMetrics(context.Background(), md)
+    assert.EqualError(t, err, "pipeline full")
+    assert.Empty(t, client.values)
+
+    // The next scrape replays the same rows.
+    sink := new(consumertest.MetricsSink)
+    md, err = s.scrape(context.Background())
+    require.NoError(t, err)
+    require.NoError(t, s.consumer(sink).ConsumeMetrics(context.Background(), md))
+    assert.Equal(t, 1, sink.DataPointCount())
+    assert.Equal(t, t0.Add(59*time.Minute), storedWatermark(t, client))
+}
+
+func TestStartResumesFromWatermark(t *testing.T) {
+    conn := &fakeConn{tables: map[string][][]any{
+        "otel.metrics": {
+            gaugeRow(t0.Add(time.Second), "memory", "api", 1),
+            gaugeRow(t0.Add(time.Hour), "memory", "api", 2),
+        },
+    }}
+    client := &memClient{values: map[string][]byte{}}
+    s := newTestScraper(t, conn, client, t0.Add(2*time.Hour), func(cfg *Config) {
+        cfg.Window = time.Minute
+    })
+    md, err := scrape(s)
+    require.NoError(t, err)
+    assert.Equal(t, 1, md.DataPointCount())
+    require.NoError(t, s.shutdown(context.Background()))
+    assert.True(t, conn.closed)
+
+    // A restarted scraper continues after the replayed rows.
+    s = newTestScraper(t, conn, client, t0.Add(2*time.Hour), nil)
+    md, err = scrape(s)
+    require.NoError(t, err)
+    require.Equal(t, 1, md.DataPointCount())
+    value := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints().At(0).DoubleValue()
+    assert.Equal(t, 2.0, value)
+}
+
+func TestStartTime(t *testing.T) {
+    conn := &fakeConn{tables: map[string][][]any{
+        "otel.metrics": {
+            gaugeRow(t0.Add(time.Second), "memory", "api", 1),
+            gaugeRow(t0.Add(time.Hour), "memory", "api", 2),
+        },
+    }}
+    s := newTestScraper(t, conn, &memClient{values: map[string][]byte{}}, t0.Add(2*time.Hour), func(cfg *Config) {
+        cfg.StartTime = t0.Add(time.Minute)
+        cfg.Tables = TablesConfig{Metrics: "metrics"}
+    })
+    md, err := scrape(s)
+    require.NoError(t, err)
+    assert.Equal(t, 1, md.DataPointCount())
+    // Tables left out of the configuration are not queried.
+    for _, query := range conn.queries {
+        assert.Regexp(t, `FROM otel\.metrics\s`, query)
+    }
+}
+
+func TestStartWithoutStorageExtension(t *testing.T) {
+    cfg := createDefaultConfig().(*Config)
+    cfg.Endpoint = "localhost:9000"
+    cfg.StorageID = &storageID
+    s := newReplayScraper(receivertest.NewNopSettings(), cfg)
+    assert.EqualError(t, s.start(context.Background(), componenttest.NewNopHost()), "storage extension file_storage not found")
+}
+
+func TestCreateMetricsReceiver(t *testing.T) {
+    factory := NewFactory()
+    cfg := factory.CreateDefaultConfig().(*Config)
+    cfg.Endpoint = "localhost:9000"
+    r, err := factory.CreateMetrics(context.Background(), receivertest.NewNopSettings(), cfg, consumertest.NewNop())
+    require.NoError(t, err)
+    require.NotNil(t, r)
+    // Shutting down a receiver that was not started succeeds.
+    assert.NoError(t, r.Shutdown(context.Background()))
+}